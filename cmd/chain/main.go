@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/chain"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/memcache"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/resp"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
+)
+
+func main() {
+	// Parse command line flags
+	nodeID := flag.String("node-id", "", "Unique identifier for this node (required)")
+	chainAddrsStr := flag.String("chain-addrs", "", "Comma-separated list of node addresses in chain order, head first and tail last (required)")
+	port := flag.String("port", "8080", "Port to listen on")
+	faultProfile := flag.String("fault-profile", fault.ProfileHomework, "Fault injection profile: 'homework' (original fixed delays) or 'none'")
+	faultConfig := flag.String("fault-config", "", "Path to a JSON fault injection config (overrides --fault-profile)")
+	maxValueSize := flag.Int64("max-value-size", kvstore.DefaultMaxValueSize, "Largest value accepted in bytes (0 = unlimited)")
+	respPort := flag.String("resp-port", "", "Port for the Redis protocol (RESP) frontend (empty = disabled)")
+	memcachedPort := flag.String("memcached-port", "", "Port for the memcached text protocol frontend (empty = disabled)")
+	transport := flag.String("transport", "http", "Transport for writes and reads between nodes: 'http' (JSON) or 'grpc'")
+	flag.Parse()
+
+	// Validate required flags
+	if *nodeID == "" {
+		log.Fatal("--node-id is required")
+	}
+	if *chainAddrsStr == "" {
+		log.Fatal("--chain-addrs is required")
+	}
+	if *transport != "http" && *transport != "grpc" {
+		log.Fatal("--transport must be 'http' or 'grpc'")
+	}
+
+	// Parse chain addresses
+	chainAddrs := strings.Split(*chainAddrsStr, ",")
+	// Trim whitespace
+	for i, addr := range chainAddrs {
+		chainAddrs[i] = strings.TrimSpace(addr)
+	}
+
+	// Determine this node's address
+	myAddr := "localhost:" + *port
+	if envAddr := os.Getenv("MY_ADDR"); envAddr != "" {
+		myAddr = envAddr
+	}
+
+	// Create node configuration
+	config := chain.NewConfig(*nodeID, myAddr, chainAddrs)
+	if config.GetPosition() < 0 {
+		log.Fatalf("node address %s not found in chain-addrs list", myAddr)
+	}
+
+	// Load injected delays and faults (adjustable later via /admin/faults)
+	faults, err := fault.Load(*faultProfile, *faultConfig)
+	if err != nil {
+		log.Fatalf("Failed to load fault injection config: %v", err)
+	}
+	config.SetFaults(faults)
+	if *transport == "grpc" {
+		config.SetGRPC(rpc.NewPool(faults))
+	}
+
+	// Create KV store
+	store := kvstore.NewStore()
+	store.SetMaxValueSize(*maxValueSize)
+
+	// Create handler
+	handler := chain.NewHandler(store, config)
+
+	// Namespaces, whose settings are kept in a replicated key
+	namespaces := namespace.NewRegistry(store, handler.FrontendBackend(), handler.CheckNamespace)
+	config.SetNamespaces(namespaces)
+	namespaceServer := namespace.NewServer(store, handler.FrontendBackend(), namespaces)
+	namespaces.Start()
+
+	// Setup router
+	r := mux.NewRouter()
+
+	// External API routes
+	r.HandleFunc("/set", handler.SetHandler).Methods("POST", "PUT")
+	r.HandleFunc("/get", handler.GetHandler).Methods("GET")
+	r.HandleFunc("/mget", handler.MGetHandler).Methods("POST")
+	r.HandleFunc("/mset", handler.MSetHandler).Methods("POST")
+	r.HandleFunc("/incr", handler.IncrHandler).Methods("POST")
+	r.HandleFunc("/decr", handler.DecrHandler).Methods("POST")
+	r.HandleFunc("/local_read", handler.LocalReadHandler).Methods("GET") // For testing
+	r.HandleFunc("/kv/{key:.+}", handler.PutValueHandler).Methods("PUT")
+	r.HandleFunc("/kv/{key:.+}", handler.GetValueHandler).Methods("GET")
+	r.HandleFunc("/health", handler.HealthHandler).Methods("GET")
+
+	// Resource-style API of namespaces, also reachable with ?namespace=
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE").Queries("namespace", "{namespace}")
+	r.HandleFunc("/ns/{namespace}/query", namespaceServer.QueryHandler).Methods("GET")
+	r.HandleFunc("/query", namespaceServer.QueryHandler).Methods("GET").Queries("namespace", "{namespace}")
+
+	// Resource-style API
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/admin/namespaces", namespaceServer.ListHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces", namespaceServer.CreateHandler).Methods("POST")
+	r.HandleFunc("/admin/namespaces/{namespace}", namespaceServer.NamespaceHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces/{namespace}/indexes", namespaceServer.IndexCreateHandler).Methods("POST")
+	r.HandleFunc("/admin/faults", faults.AdminHandler).Methods("GET", "POST")
+	r.HandleFunc("/config", handler.ConfigHandler).Methods("GET")
+
+	// Internal API routes (for replication)
+	r.HandleFunc("/internal/replicate_write", handler.ReplicateWriteHandler).Methods("POST")
+	r.HandleFunc("/internal/replicate_batch", handler.ReplicateBatchHandler).Methods("POST")
+	r.HandleFunc("/internal/read", handler.InternalReadHandler).Methods("GET")
+	r.HandleFunc("/internal/read_batch", handler.InternalReadBatchHandler).Methods("POST")
+
+	// Optional Redis protocol frontend
+	if *respPort != "" {
+		respServer := resp.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(respServer.ListenAndServe(":" + *respPort))
+		}()
+		log.Printf("Serving RESP on port %s", *respPort)
+	}
+
+	// Optional memcached protocol frontend
+	if *memcachedPort != "" {
+		memcacheServer := memcache.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(memcacheServer.ListenAndServe(":" + *memcachedPort))
+		}()
+		log.Printf("Serving memcached protocol on port %s", *memcachedPort)
+	}
+
+	// Get port from environment or flag
+	listenPort := os.Getenv("PORT")
+	if listenPort == "" {
+		listenPort = *port
+	}
+
+	log.Printf("Starting Chain node: %s (role: %s) on port %s", *nodeID, config.GetRole(), listenPort)
+	log.Printf("Chain: %v", chainAddrs)
+	log.Printf("This node address: %s", myAddr)
+	// gRPC is served on the same port
+	log.Fatal(rpc.ListenAndServe(":"+listenPort, r, rpc.NewServer(handler.FrontendBackend(), handler.ReplicationService())))
+}
@@ -1,31 +1,89 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"net/http"
 	"os"
 
 	"github.com/gorilla/mux"
 	"github.com/yourusername/distributed-kv-store/internal/api"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/memcache"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/resp"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
 )
 
 func main() {
+	maxValueSize := flag.Int64("max-value-size", kvstore.DefaultMaxValueSize, "Largest value accepted in bytes (0 = unlimited)")
+	respPort := flag.String("resp-port", "", "Port for the Redis protocol (RESP) frontend (empty = disabled)")
+	memcachedPort := flag.String("memcached-port", "", "Port for the memcached text protocol frontend (empty = disabled)")
+	flag.Parse()
+
 	// Create KV store
 	store := kvstore.NewStore()
+	store.SetMaxValueSize(*maxValueSize)
 
 	// Create API handler
 	handler := api.NewHandler(store)
 
+	// Namespaces, whose settings are kept in a replicated key
+	namespaces := namespace.NewRegistry(store, handler.FrontendBackend(), handler.CheckNamespace)
+	namespaceServer := namespace.NewServer(store, handler.FrontendBackend(), namespaces)
+	namespaces.Start()
+
 	// Setup router
 	r := mux.NewRouter()
 
 	// API routes
 	r.HandleFunc("/set", handler.SetHandler).Methods("POST", "PUT")
 	r.HandleFunc("/get", handler.GetHandler).Methods("GET")
+	r.HandleFunc("/mget", handler.MGetHandler).Methods("POST")
+	r.HandleFunc("/mset", handler.MSetHandler).Methods("POST")
+	r.HandleFunc("/incr", handler.IncrHandler).Methods("POST")
+	r.HandleFunc("/decr", handler.DecrHandler).Methods("POST")
 	r.HandleFunc("/local_read", handler.LocalReadHandler).Methods("GET") // For testing
+	r.HandleFunc("/kv/{key:.+}", handler.PutValueHandler).Methods("PUT")
+	r.HandleFunc("/kv/{key:.+}", handler.GetValueHandler).Methods("GET")
 	r.HandleFunc("/health", handler.HealthHandler).Methods("GET")
 
+	// Resource-style API of namespaces, also reachable with ?namespace=
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE").Queries("namespace", "{namespace}")
+	r.HandleFunc("/ns/{namespace}/query", namespaceServer.QueryHandler).Methods("GET")
+	r.HandleFunc("/query", namespaceServer.QueryHandler).Methods("GET").Queries("namespace", "{namespace}")
+
+	// Resource-style API
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/admin/namespaces", namespaceServer.ListHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces", namespaceServer.CreateHandler).Methods("POST")
+	r.HandleFunc("/admin/namespaces/{namespace}", namespaceServer.NamespaceHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces/{namespace}/indexes", namespaceServer.IndexCreateHandler).Methods("POST")
+
+	// Optional Redis protocol frontend
+	if *respPort != "" {
+		respServer := resp.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(respServer.ListenAndServe(":" + *respPort))
+		}()
+		log.Printf("Serving RESP on port %s", *respPort)
+	}
+
+	// Optional memcached protocol frontend
+	if *memcachedPort != "" {
+		memcacheServer := memcache.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(memcacheServer.ListenAndServe(":" + *memcachedPort))
+		}()
+		log.Printf("Serving memcached protocol on port %s", *memcachedPort)
+	}
+
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -33,5 +91,6 @@ func main() {
 	}
 
 	log.Printf("Starting KV service on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	// gRPC is served on the same port
+	log.Fatal(rpc.ListenAndServe(":"+port, r, rpc.NewServer(handler.FrontendBackend(), nil)))
 }
@@ -3,13 +3,21 @@ package main
 import (
 	"flag"
 	"log"
-	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/antientropy"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/gossip"
+	"github.com/yourusername/distributed-kv-store/internal/hedge"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
 	"github.com/yourusername/distributed-kv-store/internal/leaderfollower"
+	"github.com/yourusername/distributed-kv-store/internal/memcache"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/resp"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
 )
 
 func main() {
@@ -19,6 +27,23 @@ func main() {
 	leaderAddr := flag.String("leader-addr", "", "Address of the leader node (e.g., localhost:8080)")
 	followerAddrsStr := flag.String("follower-addrs", "", "Comma-separated list of follower addresses (e.g., localhost:8081,localhost:8082)")
 	port := flag.String("port", "8080", "Port to listen on")
+	faultProfile := flag.String("fault-profile", fault.ProfileHomework, "Fault injection profile: 'homework' (original fixed delays) or 'none'")
+	faultConfig := flag.String("fault-config", "", "Path to a JSON fault injection config (overrides --fault-profile)")
+	antiEntropyInterval := flag.Duration("anti-entropy-interval", antientropy.DefaultInterval, "Time between anti-entropy rounds with a random peer (0 = disabled)")
+	antiEntropyMaxKeys := flag.Int("anti-entropy-max-keys", antientropy.DefaultMaxKeys, "Maximum keys pulled and pushed per anti-entropy round")
+	gossipInterval := flag.Duration("gossip-interval", gossip.DefaultProbeInterval, "Time between failure detector probes (0 = disabled, every peer considered alive)")
+	gossipProbeTimeout := flag.Duration("gossip-probe-timeout", gossip.DefaultProbeTimeout, "Time to wait for a direct probe ack before probing through other peers")
+	gossipSuspicionTimeout := flag.Duration("gossip-suspicion-timeout", gossip.DefaultSuspicionTimeout, "Time a suspect peer has to refute before it is declared dead")
+	streamReplication := flag.Bool("stream-replication", true, "Replicate to followers over one long-lived stream each (false = one HTTP request per write)")
+	streamMaxBatch := flag.Int("stream-max-batch", leaderfollower.DefaultStreamMaxBatch, "Maximum writes per replication stream batch")
+	streamMaxInFlight := flag.Int("stream-max-inflight", leaderfollower.DefaultStreamMaxInFlight, "Maximum unacknowledged batches per follower stream")
+	hedgedReads := flag.Bool("hedged-reads", false, "Read from the fastest nodes first with R=3 and send speculative reads to others when replies are slow")
+	hedgeDelay := flag.Duration("hedge-delay", 0, "Time to wait before a speculative read (0 = observed --hedge-percentile latency)")
+	hedgePercentile := flag.Float64("hedge-percentile", hedge.DefaultPercentile, "Node latency percentile used as the hedge delay")
+	maxValueSize := flag.Int64("max-value-size", kvstore.DefaultMaxValueSize, "Largest value accepted in bytes (0 = unlimited)")
+	respPort := flag.String("resp-port", "", "Port for the Redis protocol (RESP) frontend (empty = disabled)")
+	memcachedPort := flag.String("memcached-port", "", "Port for the memcached text protocol frontend (empty = disabled)")
+	transport := flag.String("transport", "http", "Transport for writes and reads between nodes: 'http' (JSON) or 'grpc'")
 	flag.Parse()
 
 	// Validate required flags
@@ -31,6 +56,9 @@ func main() {
 	if *leaderAddr == "" {
 		log.Fatal("--leader-addr is required")
 	}
+	if *transport != "http" && *transport != "grpc" {
+		log.Fatal("--transport must be 'http' or 'grpc'")
+	}
 
 	// Parse follower addresses
 	var followerAddrs []string
@@ -59,26 +87,123 @@ func main() {
 	// Set default replication parameters (can be changed via API)
 	// Default to W=5, R=1 for initial setup
 	config.SetReplicationParams(1, 5)
+	config.SetStreamParams(*streamReplication, *streamMaxBatch, *streamMaxInFlight)
+	config.SetHedging(*hedgedReads, *hedgeDelay, *hedgePercentile)
+
+	// Load injected delays and faults (adjustable later via /admin/faults)
+	faults, err := fault.Load(*faultProfile, *faultConfig)
+	if err != nil {
+		log.Fatalf("Failed to load fault injection config: %v", err)
+	}
+	config.SetFaults(faults)
+	if *transport == "grpc" {
+		config.SetGRPC(rpc.NewPool(faults))
+	}
+
+	var peers []string
+	for _, addr := range config.GetAllNodeAddrs() {
+		if addr != myAddr {
+			peers = append(peers, addr)
+		}
+	}
+
+	// The failure detector keeps a live view of the other nodes so that
+	// requests to dead nodes fail immediately
+	detector := gossip.NewDetector(myAddr, peers, faults, *gossipInterval, *gossipProbeTimeout, *gossipSuspicionTimeout)
+	config.SetDetector(detector)
+	detector.Start()
 
 	// Create KV store
 	store := kvstore.NewStore()
+	store.SetMaxValueSize(*maxValueSize)
 
 	// Create handler
 	handler := leaderfollower.NewHandler(store, config)
 
+	// Anti-entropy repairs keys this node missed (e.g. failed replication
+	// with W<5) by comparing Merkle trees with the other nodes
+	syncer := antientropy.NewSyncer(store, peers, faults, nil, *antiEntropyInterval, *antiEntropyMaxKeys)
+	syncer.Start()
+
+	// Namespaces, whose settings are kept in a replicated key
+	namespaces := namespace.NewRegistry(store, handler.FrontendBackend(), handler.CheckNamespace)
+	config.SetNamespaces(namespaces)
+	namespaceServer := namespace.NewServer(store, handler.FrontendBackend(), namespaces)
+	namespaces.Start()
+
 	// Setup router
 	r := mux.NewRouter()
 
 	// External API routes
 	r.HandleFunc("/set", handler.SetHandler).Methods("POST", "PUT")
 	r.HandleFunc("/get", handler.GetHandler).Methods("GET")
+	r.HandleFunc("/mget", handler.MGetHandler).Methods("POST")
+	r.HandleFunc("/mset", handler.MSetHandler).Methods("POST")
+	r.HandleFunc("/incr", handler.IncrHandler).Methods("POST")
+	r.HandleFunc("/decr", handler.DecrHandler).Methods("POST")
 	r.HandleFunc("/local_read", handler.LocalReadHandler).Methods("GET") // For testing
+	r.HandleFunc("/kv/{key:.+}", handler.PutValueHandler).Methods("PUT")
+	r.HandleFunc("/kv/{key:.+}", handler.GetValueHandler).Methods("GET")
 	r.HandleFunc("/health", handler.HealthHandler).Methods("GET")
+
+	// Resource-style API of namespaces, also reachable with ?namespace=
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE").Queries("namespace", "{namespace}")
+	r.HandleFunc("/ns/{namespace}/query", namespaceServer.QueryHandler).Methods("GET")
+	r.HandleFunc("/query", namespaceServer.QueryHandler).Methods("GET").Queries("namespace", "{namespace}")
+
+	// Resource-style API
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/admin/namespaces", namespaceServer.ListHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces", namespaceServer.CreateHandler).Methods("POST")
+	r.HandleFunc("/admin/namespaces/{namespace}", namespaceServer.NamespaceHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces/{namespace}/indexes", namespaceServer.IndexCreateHandler).Methods("POST")
+	r.HandleFunc("/admin/faults", faults.AdminHandler).Methods("GET", "POST")
+	r.HandleFunc("/admin/anti_entropy", syncer.StatusHandler).Methods("GET")
 	r.HandleFunc("/config", handler.ConfigHandler).Methods("GET", "POST")
+	r.HandleFunc("/cluster/status", handler.ClusterStatusHandler).Methods("GET")
+	r.HandleFunc("/cluster/gossip", detector.MembersHandler).Methods("GET")
 
 	// Internal API routes (for replication)
 	r.HandleFunc("/internal/replicate_write", handler.ReplicateWriteHandler).Methods("POST")
+	r.HandleFunc("/internal/anti_entropy/hashes", syncer.HashesHandler).Methods("POST")
+	r.HandleFunc("/internal/anti_entropy/keys", syncer.KeysHandler).Methods("POST")
+	r.HandleFunc("/internal/anti_entropy/push", syncer.PushHandler).Methods("POST")
+	r.HandleFunc("/internal/replicate_stream", handler.ReplicateStreamHandler).Methods("POST")
 	r.HandleFunc("/internal/read", handler.InternalReadHandler).Methods("GET")
+	r.HandleFunc("/internal/read_batch", handler.InternalReadBatchHandler).Methods("POST")
+	r.HandleFunc("/internal/replicate_config", handler.ReplicateConfigHandler).Methods("POST")
+	r.HandleFunc("/internal/config", handler.InternalConfigHandler).Methods("GET")
+	r.HandleFunc("/internal/gossip/ping", detector.PingHandler).Methods("POST")
+	r.HandleFunc("/internal/gossip/ping_req", detector.PingReqHandler).Methods("POST")
+
+	// Pick up the current cluster configuration from the other nodes
+	// (a restarted node would otherwise fall back to the defaults above)
+	go handler.SyncConfig(10, 2*time.Second)
+
+	// Optional Redis protocol frontend
+	if *respPort != "" {
+		respServer := resp.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(respServer.ListenAndServe(":" + *respPort))
+		}()
+		log.Printf("Serving RESP on port %s", *respPort)
+	}
+
+	// Optional memcached protocol frontend
+	if *memcachedPort != "" {
+		memcacheServer := memcache.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(memcacheServer.ListenAndServe(":" + *memcachedPort))
+		}()
+		log.Printf("Serving memcached protocol on port %s", *memcachedPort)
+	}
 
 	// Get port from environment or flag
 	listenPort := os.Getenv("PORT")
@@ -91,6 +216,7 @@ func main() {
 	if len(followerAddrs) > 0 {
 		log.Printf("Follower addresses: %v", followerAddrs)
 	}
-	log.Fatal(http.ListenAndServe(":"+listenPort, r))
+	// gRPC is served on the same port
+	log.Fatal(rpc.ListenAndServe(":"+listenPort, r, rpc.NewServer(handler.FrontendBackend(), handler.ReplicationService())))
 }
 
@@ -3,13 +3,21 @@ package main
 import (
 	"flag"
 	"log"
-	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/antientropy"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/gossip"
+	"github.com/yourusername/distributed-kv-store/internal/hedge"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
 	"github.com/yourusername/distributed-kv-store/internal/leaderless"
+	"github.com/yourusername/distributed-kv-store/internal/memcache"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/resp"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
 )
 
 func main() {
@@ -17,6 +25,31 @@ func main() {
 	nodeID := flag.String("node-id", "", "Unique identifier for this node (required)")
 	allNodeAddrsStr := flag.String("all-node-addrs", "", "Comma-separated list of all node addresses (required)")
 	port := flag.String("port", "8080", "Port to listen on")
+	readR := flag.Int("r", 1, "Read quorum size R")
+	writeW := flag.Int("w", 0, "Write quorum size W (0 = replication factor)")
+	replicationFactor := flag.Int("replication-factor", 0, "Number of nodes that replicate each key (0 = N)")
+	vnodes := flag.Int("vnodes", leaderless.DefaultVirtualNodes, "Virtual nodes (ring tokens) per node")
+	join := flag.Bool("join", false, "Join a running cluster: adopt its ring membership at startup (add this node with POST /cluster/members)")
+	rebalanceRate := flag.Int("rebalance-rate", leaderless.DefaultRebalanceRate, "Keys per second streamed to new owners when the ring membership changes (0 = unlimited)")
+	sloppyQuorum := flag.Bool("sloppy-quorum", false, "Count writes held as hints on healthy substitutes toward W when a node is down")
+	hintFile := flag.String("hint-file", "", "File where hints for unreachable nodes are persisted (default: hints-<node-id>.json)")
+	hintTTL := flag.Duration("hint-ttl", leaderless.DefaultHintTTL, "How long hints are kept before they are discarded")
+	maxHints := flag.Int("max-hints", leaderless.DefaultMaxHints, "Maximum number of hints kept (oldest discarded first)")
+	conflicts := flag.String("conflict-resolution", leaderless.ConflictLWW, "Conflict resolution: 'lww' (last-writer-wins) or 'vclock' (vector clocks with siblings)")
+	faultProfile := flag.String("fault-profile", fault.ProfileHomework, "Fault injection profile: 'homework' (original fixed delays) or 'none'")
+	faultConfig := flag.String("fault-config", "", "Path to a JSON fault injection config (overrides --fault-profile)")
+	antiEntropyInterval := flag.Duration("anti-entropy-interval", antientropy.DefaultInterval, "Time between anti-entropy rounds with a random peer (0 = disabled)")
+	antiEntropyMaxKeys := flag.Int("anti-entropy-max-keys", antientropy.DefaultMaxKeys, "Maximum keys pulled and pushed per anti-entropy round")
+	gossipInterval := flag.Duration("gossip-interval", gossip.DefaultProbeInterval, "Time between failure detector probes (0 = disabled, every peer considered alive)")
+	gossipProbeTimeout := flag.Duration("gossip-probe-timeout", gossip.DefaultProbeTimeout, "Time to wait for a direct probe ack before probing through other peers")
+	gossipSuspicionTimeout := flag.Duration("gossip-suspicion-timeout", gossip.DefaultSuspicionTimeout, "Time a suspect peer has to refute before it is declared dead")
+	hedgedReads := flag.Bool("hedged-reads", false, "Read from the fastest replicas first and send speculative reads to others when replies are slow")
+	hedgeDelay := flag.Duration("hedge-delay", 0, "Time to wait before a speculative read (0 = observed --hedge-percentile latency)")
+	hedgePercentile := flag.Float64("hedge-percentile", hedge.DefaultPercentile, "Replica latency percentile used as the hedge delay")
+	maxValueSize := flag.Int64("max-value-size", kvstore.DefaultMaxValueSize, "Largest value accepted in bytes (0 = unlimited)")
+	respPort := flag.String("resp-port", "", "Port for the Redis protocol (RESP) frontend (empty = disabled)")
+	memcachedPort := flag.String("memcached-port", "", "Port for the memcached text protocol frontend (empty = disabled)")
+	transport := flag.String("transport", "http", "Transport for writes and reads between nodes: 'http' (JSON) or 'grpc'")
 	flag.Parse()
 
 	// Validate required flags
@@ -26,6 +59,9 @@ func main() {
 	if *allNodeAddrsStr == "" {
 		log.Fatal("--all-node-addrs is required")
 	}
+	if *transport != "http" && *transport != "grpc" {
+		log.Fatal("--transport must be 'http' or 'grpc'")
+	}
 
 	// Parse all node addresses
 	allNodeAddrs := strings.Split(*allNodeAddrsStr, ",")
@@ -40,38 +76,168 @@ func main() {
 		myAddr = envAddr
 	}
 
-	// Verify this node's address is in the list
-	found := false
-	for _, addr := range allNodeAddrs {
-		if addr == myAddr || addr == "localhost:"+*port {
-			found = true
-			break
-		}
+	// Create node configuration
+	config := leaderless.NewConfig(*nodeID, myAddr, allNodeAddrs)
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *replicationFactor == 0 {
+		*replicationFactor = config.GetN()
+	}
+	if *replicationFactor < 1 || *replicationFactor > config.GetN() {
+		log.Fatalf("--replication-factor must be between 1 and N (%d)", config.GetN())
+	}
+	if *vnodes < 1 {
+		log.Fatal("--vnodes must be at least 1")
 	}
-	if !found {
-		log.Printf("Warning: node address %s not found in all-node-addrs list", myAddr)
+	config.SetPartitioning(*replicationFactor, *vnodes)
+	config.SetJoining(*join)
+	config.SetRebalanceRate(*rebalanceRate)
+	if *writeW == 0 {
+		*writeW = *replicationFactor
 	}
+	if *readR < 1 || *readR > *replicationFactor || *writeW < 1 || *writeW > *replicationFactor {
+		log.Fatalf("--r and --w must be between 1 and the replication factor (%d)", *replicationFactor)
+	}
+	config.SetReplicationParams(*readR, *writeW)
+	if *conflicts != leaderless.ConflictLWW && *conflicts != leaderless.ConflictVectorClock {
+		log.Fatalf("--conflict-resolution must be '%s' or '%s'", leaderless.ConflictLWW, leaderless.ConflictVectorClock)
+	}
+	config.SetConflictResolution(*conflicts)
+	if *hintFile == "" {
+		*hintFile = "hints-" + *nodeID + ".json"
+	}
+	config.SetHintedHandoff(*sloppyQuorum, *hintFile, *hintTTL, *maxHints)
+	config.SetHedging(*hedgedReads, *hedgeDelay, *hedgePercentile)
 
-	// Create node configuration
-	config := leaderless.NewConfig(*nodeID, myAddr, allNodeAddrs)
+	// Load injected delays and faults (adjustable later via /admin/faults)
+	faults, err := fault.Load(*faultProfile, *faultConfig)
+	if err != nil {
+		log.Fatalf("Failed to load fault injection config: %v", err)
+	}
+	config.SetFaults(faults)
+	if *transport == "grpc" {
+		config.SetGRPC(rpc.NewPool(faults))
+	}
+
+	// The failure detector keeps a live view of the other nodes so that
+	// requests to dead nodes fail immediately
+	detector := gossip.NewDetector(myAddr, config.GetOtherNodeAddrs(), faults, *gossipInterval, *gossipProbeTimeout, *gossipSuspicionTimeout)
+	config.SetDetector(detector)
+	detector.Start()
 
 	// Create KV store
 	store := kvstore.NewStore()
+	store.SetMaxValueSize(*maxValueSize)
 
 	// Create handler
 	handler := leaderless.NewHandler(store, config)
 
+	// Anti-entropy repairs keys this node missed (e.g. failed replication
+	// with W<N) by comparing Merkle trees with the other nodes
+	syncer := antientropy.NewSyncer(store, config.GetOtherNodeAddrs(), faults, handler.ApplyEntry, *antiEntropyInterval, *antiEntropyMaxKeys)
+	syncer.SetReplicaFilter(myAddr, handler.SharesKey)
+	config.SetMembershipObserver(func() {
+		syncer.SetPeers(config.GetOtherNodeAddrs())
+		detector.SetPeers(config.GetOtherNodeAddrs())
+	})
+	syncer.Start()
+
+	// Namespaces, whose settings are kept in a replicated key
+	namespaces := namespace.NewRegistry(store, handler.FrontendBackend(), handler.CheckNamespace)
+	config.SetNamespaces(namespaces)
+	namespaceServer := namespace.NewServer(store, handler.FrontendBackend(), namespaces)
+	// Nodes only index the keys they replicate
+	namespaceServer.SetPeers(config.GetOtherNodeAddrs)
+	namespaces.Start()
+
 	// Setup router
 	r := mux.NewRouter()
 
 	// External API routes
 	r.HandleFunc("/set", handler.SetHandler).Methods("POST", "PUT")
 	r.HandleFunc("/get", handler.GetHandler).Methods("GET")
+	r.HandleFunc("/mget", handler.MGetHandler).Methods("POST")
+	r.HandleFunc("/mset", handler.MSetHandler).Methods("POST")
+	r.HandleFunc("/kv/{key:.+}", handler.PutValueHandler).Methods("PUT")
+	r.HandleFunc("/kv/{key:.+}", handler.GetValueHandler).Methods("GET")
+	r.HandleFunc("/cas", handler.CASHandler).Methods("POST")
+	r.HandleFunc("/incr", handler.IncrHandler).Methods("POST")
+	r.HandleFunc("/decr", handler.DecrHandler).Methods("POST")
+	r.HandleFunc("/counter/incr", handler.CounterIncrHandler).Methods("POST")
+	r.HandleFunc("/counter/decr", handler.CounterDecrHandler).Methods("POST")
+	r.HandleFunc("/register/assign", handler.RegisterAssignHandler).Methods("POST")
+	r.HandleFunc("/set/add", handler.SetAddHandler).Methods("POST")
+	r.HandleFunc("/set/remove", handler.SetRemoveHandler).Methods("POST")
+	r.HandleFunc("/map/update", handler.MapUpdateHandler).Methods("POST")
 	r.HandleFunc("/local_read", handler.LocalReadHandler).Methods("GET") // For testing
+	r.HandleFunc("/config", handler.ConfigHandler).Methods("GET", "POST")
+	r.HandleFunc("/cluster/status", handler.ClusterStatusHandler).Methods("GET")
+	r.HandleFunc("/cluster/ring", handler.RingHandler).Methods("GET")
+	r.HandleFunc("/cluster/members", handler.MembersHandler).Methods("GET", "POST")
+	r.HandleFunc("/cluster/rebalance", handler.RebalanceHandler).Methods("GET")
+	r.HandleFunc("/cluster/gossip", detector.MembersHandler).Methods("GET")
 	r.HandleFunc("/health", handler.HealthHandler).Methods("GET")
 
+	// Resource-style API of namespaces, also reachable with ?namespace=
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/ns/{namespace}/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyGetHandler).Methods("GET").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyPutHandler).Methods("PUT").Queries("namespace", "{namespace}")
+	r.HandleFunc("/v1/keys/{key:.+}", namespaceServer.KeyDeleteHandler).Methods("DELETE").Queries("namespace", "{namespace}")
+	r.HandleFunc("/ns/{namespace}/query", namespaceServer.QueryHandler).Methods("GET")
+	r.HandleFunc("/query", namespaceServer.QueryHandler).Methods("GET").Queries("namespace", "{namespace}")
+
+	// Resource-style API
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyGetHandler).Methods("GET")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyPutHandler).Methods("PUT")
+	r.HandleFunc("/v1/keys/{key:.+}", handler.KeyDeleteHandler).Methods("DELETE")
+	r.HandleFunc("/admin/namespaces", namespaceServer.ListHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces", namespaceServer.CreateHandler).Methods("POST")
+	r.HandleFunc("/admin/namespaces/{namespace}", namespaceServer.NamespaceHandler).Methods("GET")
+	r.HandleFunc("/admin/namespaces/{namespace}/indexes", namespaceServer.IndexCreateHandler).Methods("POST")
+	r.HandleFunc("/admin/faults", faults.AdminHandler).Methods("GET", "POST")
+	r.HandleFunc("/admin/anti_entropy", syncer.StatusHandler).Methods("GET")
+	r.HandleFunc("/admin/rebalance", handler.RebalanceAdminHandler).Methods("GET", "POST")
+
 	// Internal API routes (for replication)
 	r.HandleFunc("/internal/replicate_write", handler.ReplicateWriteHandler).Methods("POST")
+	r.HandleFunc("/internal/replicate_batch", handler.ReplicateBatchHandler).Methods("POST")
+	r.HandleFunc("/internal/anti_entropy/hashes", syncer.HashesHandler).Methods("POST")
+	r.HandleFunc("/internal/anti_entropy/keys", syncer.KeysHandler).Methods("POST")
+	r.HandleFunc("/internal/anti_entropy/push", syncer.PushHandler).Methods("POST")
+	r.HandleFunc("/internal/read", handler.InternalReadHandler).Methods("GET")
+	r.HandleFunc("/internal/read_batch", handler.InternalReadBatchHandler).Methods("POST")
+	r.HandleFunc("/internal/paxos/prepare", handler.PaxosPrepareHandler).Methods("POST")
+	r.HandleFunc("/internal/paxos/propose", handler.PaxosProposeHandler).Methods("POST")
+	r.HandleFunc("/internal/paxos/commit", handler.PaxosCommitHandler).Methods("POST")
+	r.HandleFunc("/internal/replicate_config", handler.ReplicateConfigHandler).Methods("POST")
+	r.HandleFunc("/internal/config", handler.InternalConfigHandler).Methods("GET")
+	r.HandleFunc("/internal/membership", handler.InternalMembershipHandler).Methods("POST")
+	r.HandleFunc("/internal/gossip/ping", detector.PingHandler).Methods("POST")
+	r.HandleFunc("/internal/gossip/ping_req", detector.PingReqHandler).Methods("POST")
+	r.HandleFunc("/internal/rebalance/start", handler.InternalRebalanceStartHandler).Methods("POST")
+	r.HandleFunc("/internal/rebalance/status", handler.InternalRebalanceStatusHandler).Methods("GET")
+	r.HandleFunc("/internal/rebalance/stream", handler.RebalanceStreamHandler).Methods("POST")
+
+	// Optional Redis protocol frontend
+	if *respPort != "" {
+		respServer := resp.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(respServer.ListenAndServe(":" + *respPort))
+		}()
+		log.Printf("Serving RESP on port %s", *respPort)
+	}
+
+	// Optional memcached protocol frontend
+	if *memcachedPort != "" {
+		memcacheServer := memcache.NewServer(store, handler.FrontendBackend())
+		go func() {
+			log.Fatal(memcacheServer.ListenAndServe(":" + *memcachedPort))
+		}()
+		log.Printf("Serving memcached protocol on port %s", *memcachedPort)
+	}
 
 	// Get port from environment or flag
 	listenPort := os.Getenv("PORT")
@@ -82,7 +248,12 @@ func main() {
 	log.Printf("Starting Leaderless node: %s on port %s", *nodeID, listenPort)
 	log.Printf("All node addresses: %v", allNodeAddrs)
 	log.Printf("This node address: %s", myAddr)
-	log.Printf("Configuration: W=%d (N), R=1", config.GetN())
-	log.Fatal(http.ListenAndServe(":"+listenPort, r))
+	log.Printf("Configuration: N=%d, RF=%d, W=%d, R=%d, vnodes=%d, conflicts=%s, sloppy quorum=%v, hedged reads=%v", config.GetN(), *replicationFactor, *writeW, *readR, *vnodes, *conflicts, *sloppyQuorum, *hedgedReads)
+
+	// Pick up the current cluster configuration if other nodes are already running
+	go handler.SyncConfig(10, 2*time.Second)
+
+	// gRPC is served on the same port
+	log.Fatal(rpc.ListenAndServe(":"+listenPort, r, rpc.NewServer(handler.FrontendBackend(), handler.ReplicationService())))
 }
 
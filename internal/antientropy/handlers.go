@@ -0,0 +1,73 @@
+package antientropy
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HashesHandler returns the hashes of the requested Merkle tree nodes
+func (s *Syncer) HashesHandler(w http.ResponseWriter, r *http.Request) {
+	var req HashesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(HashesResponse{
+		Depth:  s.tree.Depth(),
+		Hashes: s.tree.Hashes(req.Nodes),
+	})
+}
+
+// KeysHandler returns the entries stored in the requested leaves
+func (s *Syncer) KeysHandler(w http.ResponseWriter, r *http.Request) {
+	var req KeysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(EntriesMessage{
+		Entries: s.entries(req.Leaves, req.Limit, req.From),
+	})
+}
+
+// PushHandler applies entries pushed by a peer, keeping the highest version
+func (s *Syncer) PushHandler(w http.ResponseWriter, r *http.Request) {
+	var req EntriesMessage
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	applied := 0
+	for _, entry := range req.Entries {
+		if !s.shared(req.From, entry.Key) {
+			continue
+		}
+		if s.apply(entry) {
+			applied++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"applied": applied,
+	})
+}
+
+// StatusHandler reports the anti-entropy progress of this node
+func (s *Syncer) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"interval": s.interval.String(),
+		"max_keys": s.maxKeys,
+		"stats":    s.GetStats(),
+	})
+}
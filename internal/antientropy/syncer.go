@@ -0,0 +1,387 @@
+package antientropy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Default anti-entropy settings
+const (
+	DefaultInterval = 30 * time.Second // Time between sync rounds
+	DefaultMaxKeys  = 1000             // Keys pulled and pushed per sync round
+)
+
+// Entry is a key and its versioned value(s) exchanged during a sync
+type Entry struct {
+	Key      string            `json:"key"`
+	Value    []byte            `json:"value"`
+	Version  int64             `json:"version"`
+	Siblings []kvstore.Sibling `json:"siblings,omitempty"`
+	CRDT     *kvstore.CRDT     `json:"crdt,omitempty"`
+	Deleted  bool              `json:"deleted,omitempty"` // A tombstone
+}
+
+// keyValue converts an entry to the store representation
+func (e Entry) keyValue() *kvstore.KeyValue {
+	return &kvstore.KeyValue{
+		Key:      e.Key,
+		Value:    e.Value,
+		Version:  e.Version,
+		Siblings: e.Siblings,
+		CRDT:     e.CRDT,
+		Deleted:  e.Deleted,
+	}
+}
+
+// HashesRequest asks a peer for the hashes of Merkle tree nodes
+type HashesRequest struct {
+	Nodes []int `json:"nodes"`
+}
+
+// HashesResponse returns Merkle tree node hashes keyed by node number
+type HashesResponse struct {
+	Depth  int            `json:"depth"`
+	Hashes map[int]uint64 `json:"hashes"`
+}
+
+// KeysRequest asks a peer for the entries in some leaves (key ranges)
+type KeysRequest struct {
+	Leaves []int  `json:"leaves"`
+	Limit  int    `json:"limit"`
+	From   string `json:"from,omitempty"` // Requesting node (used to filter shared keys)
+}
+
+// EntriesMessage carries entries in either direction
+type EntriesMessage struct {
+	Entries []Entry `json:"entries"`
+	From    string  `json:"from,omitempty"` // Pushing node (used to filter shared keys)
+}
+
+// Stats reports the progress of anti-entropy on a node
+type Stats struct {
+	Rounds        int64      `json:"rounds"`
+	KeysPulled    int64      `json:"keys_pulled"`
+	KeysPushed    int64      `json:"keys_pushed"`
+	LastSync      *time.Time `json:"last_sync,omitempty"`
+	LastPeer      string     `json:"last_peer,omitempty"`
+	LastDiffering int        `json:"last_differing_ranges"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Syncer periodically compares its Merkle tree with a random peer and
+// exchanges only the keys in differing key ranges, keeping the highest
+// version of each
+type Syncer struct {
+	store      *kvstore.Store
+	tree       *Tree
+	peers      []string
+	faults     *fault.Injector
+	apply      func(Entry) bool
+	interval   time.Duration
+	maxKeys    int
+	httpClient *http.Client
+	self       string                      // This node's address (sent with requests)
+	shares     func(peer, key string) bool // nil = every key is shared with every peer
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewSyncer creates a syncer for a store and registers its Merkle tree as
+// the store's observer (so it must be created before the store is used)
+// apply stores an entry received from a peer and returns true if it was
+// newer; nil applies entries directly to the store (last-writer-wins).
+// An interval of 0 or less disables the background process.
+func NewSyncer(store *kvstore.Store, peers []string, faults *fault.Injector, apply func(Entry) bool, interval time.Duration, maxKeys int) *Syncer {
+	if maxKeys < 1 {
+		maxKeys = DefaultMaxKeys
+	}
+	s := &Syncer{
+		store:    store,
+		tree:     NewTree(DefaultDepth),
+		peers:    append([]string{}, peers...),
+		faults:   faults,
+		apply:    apply,
+		interval: interval,
+		maxKeys:  maxKeys,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+	if s.apply == nil {
+		s.apply = s.applyToStore
+	}
+	store.SetObserver(s.tree.Observe)
+	return s
+}
+
+// SetReplicaFilter limits the keys exchanged with a peer to those both
+// nodes replicate (for partitioned clusters where each key lives on only
+// some nodes). self is this node's address; shares reports whether a key is
+// replicated on both this node and a peer.
+// Call it before Start.
+func (s *Syncer) SetReplicaFilter(self string, shares func(peer, key string) bool) {
+	s.self = self
+	s.shares = shares
+}
+
+// shared returns true if a key should be exchanged with a peer
+func (s *Syncer) shared(peer, key string) bool {
+	return s.shares == nil || peer == "" || s.shares(peer, key)
+}
+
+// SetPeers replaces the nodes this syncer compares with
+func (s *Syncer) SetPeers(peers []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.peers = append([]string{}, peers...)
+}
+
+// randomPeer returns a random peer, or "" if there are none
+func (s *Syncer) randomPeer() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.peers) == 0 {
+		return ""
+	}
+	return s.peers[rand.Intn(len(s.peers))]
+}
+
+// Start runs sync rounds in the background
+func (s *Syncer) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(s.interval)
+			peer := s.randomPeer()
+			if peer == "" {
+				continue
+			}
+			if err := s.SyncWith(peer); err != nil {
+				log.Printf("Anti-entropy with %s failed: %v", peer, err)
+			}
+		}
+	}()
+}
+
+// SyncWith runs one sync round with a peer
+func (s *Syncer) SyncWith(peer string) error {
+	differing, err := s.diff(peer)
+	if err == nil && len(differing) > 0 {
+		err = s.exchange(peer, differing)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	s.stats.Rounds++
+	s.stats.LastSync = &now
+	s.stats.LastPeer = peer
+	s.stats.LastDiffering = len(differing)
+	s.stats.LastError = ""
+	if err != nil {
+		s.stats.LastError = err.Error()
+	}
+	return err
+}
+
+// diff walks both trees from the root down and returns the leaves whose
+// hashes differ, asking the peer for one level of the tree at a time
+func (s *Syncer) diff(peer string) ([]int, error) {
+	var differing []int
+	level := []int{1}
+	for len(level) > 0 {
+		var response HashesResponse
+		if err := s.post(peer, "/internal/anti_entropy/hashes", HashesRequest{Nodes: level}, &response); err != nil {
+			return nil, err
+		}
+		if response.Depth != s.tree.Depth() {
+			return nil, fmt.Errorf("peer tree depth %d does not match %d", response.Depth, s.tree.Depth())
+		}
+
+		local := s.tree.Hashes(level)
+		var next []int
+		for _, node := range level {
+			if local[node] == response.Hashes[node] {
+				continue
+			}
+			if s.tree.IsLeaf(node) {
+				differing = append(differing, node)
+				continue
+			}
+			next = append(next, 2*node, 2*node+1)
+		}
+		level = next
+	}
+	return differing, nil
+}
+
+// exchange pulls the peer's entries in the differing leaves and pushes
+// back the local entries the peer is missing or has older versions of
+// At most maxKeys entries are pulled and pushed; the rest are left for the
+// next round.
+func (s *Syncer) exchange(peer string, leaves []int) error {
+	var remote EntriesMessage
+	if err := s.post(peer, "/internal/anti_entropy/keys", KeysRequest{Leaves: leaves, Limit: s.maxKeys, From: s.self}, &remote); err != nil {
+		return err
+	}
+
+	remoteEntries := make(map[string]Entry, len(remote.Entries))
+	pulled := 0
+	for _, entry := range remote.Entries {
+		remoteEntries[entry.Key] = entry
+		if !s.shared(peer, entry.Key) {
+			continue
+		}
+		if s.apply(entry) {
+			pulled++
+		}
+	}
+
+	var push []Entry
+	for _, entry := range s.entries(leaves, 0, peer) {
+		if len(push) == s.maxKeys {
+			break
+		}
+		if remoteEntry, ok := remoteEntries[entry.Key]; ok && !newer(entry, remoteEntry) {
+			continue
+		}
+		push = append(push, entry)
+	}
+	if len(push) > 0 {
+		if err := s.post(peer, "/internal/anti_entropy/push", EntriesMessage{Entries: push, From: s.self}, nil); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.stats.KeysPulled += int64(pulled)
+	s.stats.KeysPushed += int64(len(push))
+	s.mu.Unlock()
+
+	if pulled > 0 || len(push) > 0 {
+		log.Printf("Anti-entropy with %s: %d differing ranges, pulled %d keys, pushed %d keys", peer, len(leaves), pulled, len(push))
+	}
+	return nil
+}
+
+// newer returns true if a local entry should be pushed to a peer holding
+// the remote entry
+func newer(local, remote Entry) bool {
+	if local.Siblings != nil || remote.Siblings != nil || local.CRDT != nil || remote.CRDT != nil {
+		// Sibling sets and CRDT states are merged on the peer, so any
+		// difference is pushed
+		return entryHash(local.keyValue()) != entryHash(remote.keyValue())
+	}
+	return local.Version > remote.Version
+}
+
+// entries returns the local entries in the given leaves that are shared
+// with a peer (all entries if peer is empty)
+// A limit of 0 means no limit
+func (s *Syncer) entries(leaves []int, limit int, peer string) []Entry {
+	var entries []Entry
+	for _, key := range s.tree.Keys(leaves) {
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+		if !s.shared(peer, key) {
+			continue
+		}
+		kv, exists := s.store.Get(key)
+		if !exists {
+			continue
+		}
+		entries = append(entries, Entry{
+			Key:      kv.Key,
+			Value:    kv.Value,
+			Version:  kv.Version,
+			Siblings: kv.Siblings,
+			CRDT:     kv.CRDT,
+			Deleted:  kv.Deleted,
+		})
+	}
+	return entries
+}
+
+// applyToStore stores an entry, keeping the highest version
+func (s *Syncer) applyToStore(entry Entry) bool {
+	if entry.CRDT != nil {
+		merged, _ := s.store.MergeCRDT(entry.Key, entry.CRDT)
+		return merged
+	}
+	if entry.Siblings != nil {
+		added := false
+		for _, sibling := range entry.Siblings {
+			if ok, _ := s.store.MergeSibling(entry.Key, sibling); ok {
+				added = true
+			}
+		}
+		return added
+	}
+	if entry.Deleted {
+		applied, _ := s.store.DeleteWithVersion(entry.Key, entry.Version)
+		return applied
+	}
+	applied, _ := s.store.SetWithVersion(entry.Key, entry.Value, entry.Version)
+	return applied
+}
+
+// GetStats returns the anti-entropy progress of this node
+func (s *Syncer) GetStats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// post sends a JSON request to a peer through the fault injector and
+// decodes the response into out (if not nil)
+func (s *Syncer) post(peer string, path string, in interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("http://%s%s", peer, path)
+	resp, err := s.faults.Do(s.httpClient, peer, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
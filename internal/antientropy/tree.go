@@ -0,0 +1,176 @@
+package antientropy
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// DefaultDepth is the depth of the Merkle tree (2^depth key ranges)
+const DefaultDepth = 10
+
+// Tree is a Merkle tree over the key space of a store
+// Keys are hashed into 2^depth leaves (key ranges). A leaf's hash is the XOR
+// of the hashes of its entries, so it can be updated in O(1) on every write.
+// Inner node hashes are recomputed from the leaves when a snapshot is taken.
+//
+// Nodes are numbered heap-style: the root is 1, the children of node i are
+// 2i and 2i+1, and the leaves are 2^depth to 2^(depth+1)-1.
+type Tree struct {
+	mu     sync.Mutex
+	depth  int
+	leaves []leaf
+	nodes  []uint64 // Cached inner node hashes (nil = stale)
+}
+
+// leaf is one key range
+type leaf struct {
+	hash uint64
+	keys map[string]struct{}
+}
+
+// NewTree creates an empty tree
+func NewTree(depth int) *Tree {
+	if depth < 1 || depth > 20 {
+		depth = DefaultDepth
+	}
+	return &Tree{
+		depth:  depth,
+		leaves: make([]leaf, 1<<depth),
+	}
+}
+
+// Observe updates the tree for a change in the store
+// Register it with kvstore.Store.SetObserver.
+func (t *Tree) Observe(old, new *kvstore.KeyValue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l := &t.leaves[t.leafIndex(new.Key)]
+	if old != nil {
+		l.hash ^= entryHash(old)
+	}
+	l.hash ^= entryHash(new)
+	if l.keys == nil {
+		l.keys = make(map[string]struct{})
+	}
+	l.keys[new.Key] = struct{}{}
+	t.nodes = nil
+}
+
+// Depth returns the depth of the tree
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// IsLeaf returns true if the node number is a leaf
+func (t *Tree) IsLeaf(node int) bool {
+	return node >= 1<<t.depth
+}
+
+// Hashes returns the hashes of the given nodes
+// Unknown node numbers are omitted
+func (t *Tree) Hashes(nodes []int) map[int]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	all := t.snapshot()
+	hashes := make(map[int]uint64, len(nodes))
+	for _, node := range nodes {
+		if node >= 1 && node < len(all) {
+			hashes[node] = all[node]
+		}
+	}
+	return hashes
+}
+
+// Keys returns the sorted keys stored in the given leaves
+func (t *Tree) Keys(leaves []int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var keys []string
+	for _, node := range leaves {
+		index := node - 1<<t.depth
+		if index < 0 || index >= len(t.leaves) {
+			continue
+		}
+		for key := range t.leaves[index].keys {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// snapshot returns the hashes of all nodes, indexed by node number
+// (caller holds t.mu)
+func (t *Tree) snapshot() []uint64 {
+	if t.nodes != nil {
+		return t.nodes
+	}
+
+	first := 1 << t.depth
+	nodes := make([]uint64, 2*first)
+	for i, l := range t.leaves {
+		nodes[first+i] = l.hash
+	}
+	var buf [16]byte
+	for node := first - 1; node >= 1; node-- {
+		binary.LittleEndian.PutUint64(buf[:8], nodes[2*node])
+		binary.LittleEndian.PutUint64(buf[8:], nodes[2*node+1])
+		h := fnv.New64a()
+		h.Write(buf[:])
+		nodes[node] = h.Sum64()
+	}
+	t.nodes = nodes
+	return nodes
+}
+
+// leafIndex returns the leaf (key range) a key belongs to
+func (t *Tree) leafIndex(key string) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() >> (64 - t.depth))
+}
+
+// entryHash hashes one key and its versioned value(s)
+func entryHash(kv *kvstore.KeyValue) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	h.Write([]byte(kv.Key))
+	h.Write([]byte{0})
+
+	if kv.CRDT != nil {
+		h.Write(kv.CRDT.Encode())
+		return h.Sum64()
+	}
+
+	if kv.Siblings == nil {
+		binary.LittleEndian.PutUint64(buf[:], uint64(kv.Version))
+		h.Write(buf[:])
+		if kv.Deleted {
+			h.Write([]byte{1})
+		}
+		h.Write(kv.Value)
+		return h.Sum64()
+	}
+
+	// Sibling order differs between replicas, so combine them with XOR
+	base := h.Sum64()
+	combined := base
+	for _, sibling := range kv.Siblings {
+		sh := fnv.New64a()
+		binary.LittleEndian.PutUint64(buf[:], base)
+		sh.Write(buf[:])
+		sh.Write([]byte(sibling.Dot.Node))
+		binary.LittleEndian.PutUint64(buf[:], uint64(sibling.Dot.Counter))
+		sh.Write(buf[:])
+		sh.Write(sibling.Value)
+		combined ^= sh.Sum64()
+	}
+	return combined
+}
@@ -0,0 +1,105 @@
+package antientropy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestDiffFindsSingleKeyDivergence(t *testing.T) {
+	tests := []struct {
+		name    string
+		diverge func(local, peer *kvstore.Store) // Changes one side after both hold the same keys
+		wantKey string                           // Key in the only differing leaf, or "" for none
+	}{
+		{
+			name:    "identical",
+			diverge: func(local, peer *kvstore.Store) {},
+		},
+		{
+			name:    "newer value on the peer",
+			diverge: func(local, peer *kvstore.Store) { peer.SetWithVersion("key:7", []byte("new"), 2) },
+			wantKey: "key:7",
+		},
+		{
+			name:    "newer value locally",
+			diverge: func(local, peer *kvstore.Store) { local.SetWithVersion("key:999", []byte("new"), 3) },
+			wantKey: "key:999",
+		},
+		{
+			name:    "key only held locally",
+			diverge: func(local, peer *kvstore.Store) { local.SetWithVersion("extra", []byte("v"), 1) },
+			wantKey: "extra",
+		},
+		{
+			name:    "key only held by the peer",
+			diverge: func(local, peer *kvstore.Store) { peer.SetWithVersion("extra", []byte("v"), 1) },
+			wantKey: "extra",
+		},
+		{
+			name:    "deleted on the peer",
+			diverge: func(local, peer *kvstore.Store) { peer.DeleteWithVersion("key:42", 2) },
+			wantKey: "key:42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, localSyncer := newTestSyncer(t)
+			peer, peerSyncer := newTestSyncer(t)
+			for i := 0; i < 1000; i++ {
+				key := "key:" + strconv.Itoa(i)
+				local.SetWithVersion(key, []byte("v"+strconv.Itoa(i)), 1)
+				peer.SetWithVersion(key, []byte("v"+strconv.Itoa(i)), 1)
+			}
+			tt.diverge(local, peer)
+
+			server := httptest.NewServer(http.HandlerFunc(peerSyncer.HashesHandler))
+			defer server.Close()
+
+			leaves, err := localSyncer.diff(strings.TrimPrefix(server.URL, "http://"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantKey == "" {
+				if len(leaves) != 0 {
+					t.Fatalf("got differing leaves %v, want none", leaves)
+				}
+				return
+			}
+			want := 1<<localSyncer.tree.Depth() + localSyncer.tree.leafIndex(tt.wantKey)
+			if len(leaves) != 1 || leaves[0] != want {
+				t.Fatalf("got differing leaves %v, want [%d]", leaves, want)
+			}
+			keys := append(localSyncer.tree.Keys(leaves), peerSyncer.tree.Keys(leaves)...)
+			if !contains(keys, tt.wantKey) {
+				t.Fatalf("the differing leaf holds %v, not %s", keys, tt.wantKey)
+			}
+		})
+	}
+}
+
+// newTestSyncer creates a store observed by a syncer with no peers
+func newTestSyncer(t *testing.T) (*kvstore.Store, *Syncer) {
+	faults, err := fault.NewInjectorFromProfile("none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := kvstore.NewStore()
+	return store, NewSyncer(store, nil, faults, nil, 0, 0)
+}
+
+// contains returns true if keys holds key
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
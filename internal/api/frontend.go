@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// FrontendBackend serves the protocol frontends (RESP, memcached) from the
+// local store
+type FrontendBackend struct {
+	store *kvstore.Store
+}
+
+// FrontendBackend returns the backend of this service's protocol frontends
+func (h *Handler) FrontendBackend() *FrontendBackend {
+	return &FrontendBackend{store: h.store}
+}
+
+// Get reads a key, returning nil if it does not exist
+func (b *FrontendBackend) Get(key string) (*kvstore.KeyValue, error) {
+	kv, _ := b.store.LocalRead(key)
+	return kv, nil
+}
+
+// Set writes a key if its preconditions hold
+func (b *FrontendBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	return b.store.SetIf(key, value, preconditions.Condition())
+}
+
+// Delete deletes a key if its preconditions hold
+func (b *FrontendBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.store.DeleteIf(key, preconditions.Condition())
+	return err
+}
+
+// CheckNamespace rejects namespace settings a single node cannot apply
+func (h *Handler) CheckNamespace(settings namespace.Settings) error {
+	if settings.ReplicationFactor > 1 {
+		return errors.New("replication_factor must be 1 on a single node")
+	}
+	return nil
+}
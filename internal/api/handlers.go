@@ -2,10 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/batch"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/numeric"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
 )
 
 // Handler wraps the KV store and provides HTTP handlers
@@ -32,13 +38,16 @@ func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Key == "" {
-		http.Error(w, "key cannot be empty", http.StatusBadRequest)
+	if !rest.ValidKey(w, req.Key) {
 		return
 	}
 
-	version, err := h.store.Set(req.Key, req.Value)
+	version, err := h.store.Set(req.Key, []byte(req.Value))
 	if err != nil {
+		if errors.Is(err, kvstore.ErrValueTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -56,12 +65,11 @@ func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 // GetHandler handles GET requests to retrieve a value
 func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
-	if key == "" {
-		http.Error(w, "key parameter is required", http.StatusBadRequest)
+	if !rest.ValidKey(w, key) {
 		return
 	}
 
-	kv, exists := h.store.Get(key)
+	kv, exists := h.store.LocalRead(key)
 	if !exists {
 		http.Error(w, "key not found", http.StatusNotFound)
 		return
@@ -71,16 +79,210 @@ func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"key":     kv.Key,
-		"value":   kv.Value,
+		"value":   string(kv.Value),
 		"version": kv.Version,
 	})
 }
 
+// MGetHandler handles POST /mget, reading several keys at once
+func (h *Handler) MGetHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := batch.DecodeKeys(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]map[string]interface{}, len(keys))
+	for i, key := range keys {
+		kv, exists := h.store.LocalRead(key)
+		if !exists {
+			results[i] = batch.ErrorResult(key, http.StatusNotFound, "key not found")
+			continue
+		}
+		results[i] = batch.Result(http.StatusOK, map[string]interface{}{
+			"key":     kv.Key,
+			"value":   string(kv.Value),
+			"version": kv.Version,
+		})
+	}
+	batch.WriteResults(w, results)
+}
+
+// MSetHandler handles POST /mset, writing several keys at once
+// Each key is written independently; a failed key does not undo the others.
+func (h *Handler) MSetHandler(w http.ResponseWriter, r *http.Request) {
+	pairs, err := batch.DecodePairs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]map[string]interface{}, len(pairs))
+	for i, pair := range pairs {
+		version, err := h.store.Set(pair.Key, []byte(pair.Value))
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, kvstore.ErrValueTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			results[i] = batch.ErrorResult(pair.Key, status, err.Error())
+			continue
+		}
+		results[i] = batch.Result(http.StatusCreated, map[string]interface{}{
+			"key":     pair.Key,
+			"value":   pair.Value,
+			"version": version,
+		})
+	}
+	batch.WriteResults(w, results)
+}
+
+// IncrHandler handles POST /incr, adding a delta to an integer value
+func (h *Handler) IncrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, false)
+}
+
+// DecrHandler handles POST /decr, subtracting a delta from an integer value
+func (h *Handler) DecrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, true)
+}
+
+// increment reads, updates and writes the value atomically in the store
+func (h *Handler) increment(w http.ResponseWriter, r *http.Request, decr bool) {
+	op, err := numeric.Decode(r, decr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kv, err := h.store.Update(op.Key, op.Apply)
+	if err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	numeric.WriteResult(w, kv)
+}
+
+// KeyGetHandler handles GET /v1/keys/{key}
+// Honors If-None-Match (304 Not Modified) and If-Match (412)
+func (h *Handler) KeyGetHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, _ := h.store.LocalRead(key) // nil if it does not exist
+
+	if status := rest.ParsePreconditions(r).ReadStatus(kv); status != 0 {
+		rest.WriteStatus(w, status, kv)
+		return
+	}
+	if kv == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	rest.WriteValue(w, kv)
+}
+
+// KeyPutHandler handles PUT /v1/keys/{key}
+// With If-Match or If-None-Match the value is only written if the key's
+// current version satisfies them (412 otherwise)
+func (h *Handler) KeyPutHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	value, err := rest.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := h.store.SetIf(key, value, rest.ParsePreconditions(r).Condition())
+	if err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	rest.WriteStored(w, key, version)
+}
+
+// KeyDeleteHandler handles DELETE /v1/keys/{key}
+// Conditional like KeyPutHandler
+func (h *Handler) KeyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if _, err := h.store.DeleteIf(key, rest.ParsePreconditions(r).Condition()); err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PutValueHandler handles PUT /kv/{key} with the raw value as the body
+func (h *Handler) PutValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := h.store.Set(key, value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     key,
+		"size":    len(value),
+		"version": version,
+		"status":  "created",
+	})
+}
+
+// GetValueHandler handles GET /kv/{key}, returning the raw value
+func (h *Handler) GetValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, exists := h.store.LocalRead(key)
+	if !exists {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	blob.WriteValue(w, kv.Value, kv.Version)
+}
+
 // LocalReadHandler handles GET requests for local reads (testing only)
 func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
-	if key == "" {
-		http.Error(w, "key parameter is required", http.StatusBadRequest)
+	if !rest.ValidKey(w, key) {
 		return
 	}
 
@@ -94,7 +296,7 @@ func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"key":     kv.Key,
-		"value":   kv.Value,
+		"value":   string(kv.Value),
 		"version": kv.Version,
 	})
 }
@@ -0,0 +1,117 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// MaxKeys is the largest number of keys in one multi-get or multi-set
+const MaxKeys = 1000
+
+// Errors returned for invalid batch requests
+var (
+	ErrEmpty        = errors.New("at least one key is required")
+	ErrTooManyKeys  = fmt.Errorf("at most %d keys are allowed per request", MaxKeys)
+	ErrEmptyKey     = errors.New("keys cannot be empty")
+	ErrDuplicateKey = errors.New("each key can only be set once per request")
+)
+
+// Pair is a key and the value to write to it
+type Pair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// DecodeKeys returns the keys of a multi-get request body
+// ({"keys": ["a", "b"]})
+func DecodeKeys(r *http.Request) ([]string, error) {
+	var req struct {
+		Keys []string `json:"keys"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.New("Invalid request body")
+	}
+	if err := checkSize(len(req.Keys)); err != nil {
+		return nil, err
+	}
+	for _, key := range req.Keys {
+		if key == "" {
+			return nil, ErrEmptyKey
+		}
+		if err := kvstore.CheckKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return req.Keys, nil
+}
+
+// DecodePairs returns the pairs of a multi-set request body
+// ({"pairs": [{"key": "a", "value": "1"}]})
+// Keys must be distinct, as the writes of a batch are not ordered.
+func DecodePairs(r *http.Request) ([]Pair, error) {
+	var req struct {
+		Pairs []Pair `json:"pairs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.New("Invalid request body")
+	}
+	if err := checkSize(len(req.Pairs)); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(req.Pairs))
+	for _, pair := range req.Pairs {
+		if pair.Key == "" {
+			return nil, ErrEmptyKey
+		}
+		if err := kvstore.CheckKey(pair.Key); err != nil {
+			return nil, err
+		}
+		if seen[pair.Key] {
+			return nil, ErrDuplicateKey
+		}
+		seen[pair.Key] = true
+	}
+	return req.Pairs, nil
+}
+
+// checkSize validates the number of keys in a request
+func checkSize(n int) error {
+	if n == 0 {
+		return ErrEmpty
+	}
+	if n > MaxKeys {
+		return ErrTooManyKeys
+	}
+	return nil
+}
+
+// Result returns the result of a key that succeeded: the response of the
+// single-key request with its status
+func Result(status int, response map[string]interface{}) map[string]interface{} {
+	response["status"] = status
+	return response
+}
+
+// ErrorResult returns the result of a key that failed, with the status the
+// single-key request would have returned
+func ErrorResult(key string, status int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"key":    key,
+		"status": status,
+		"error":  message,
+	}
+}
+
+// WriteResults writes the results of a batch, one per key in request order
+// The request itself succeeds even if some keys failed.
+func WriteResults(w http.ResponseWriter, results []map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+	})
+}
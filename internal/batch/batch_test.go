@@ -0,0 +1,74 @@
+package batch
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	tooMany := make([]string, MaxKeys+1)
+	for i := range tooMany {
+		tooMany[i] = strconv.Quote("k" + strconv.Itoa(i))
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    int // Keys decoded
+		wantErr error
+	}{
+		{name: "keys", body: `{"keys": ["a", "b", "a"]}`, want: 3},
+		{name: "no keys", body: `{"keys": []}`, wantErr: ErrEmpty},
+		{name: "missing keys", body: `{}`, wantErr: ErrEmpty},
+		{name: "empty key", body: `{"keys": ["a", ""]}`, wantErr: ErrEmptyKey},
+		{name: "too many keys", body: `{"keys": [` + strings.Join(tooMany, ",") + `]}`, wantErr: ErrTooManyKeys},
+		{name: "invalid body", body: `{"keys": "a"}`, wantErr: errInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := DecodeKeys(httptest.NewRequest("POST", "/mget", strings.NewReader(tt.body)))
+			if !sameError(err, tt.wantErr) || len(keys) != tt.want {
+				t.Fatalf("got %d keys (%v), want %d (%v)", len(keys), err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodePairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int // Pairs decoded
+		wantErr error
+	}{
+		{name: "pairs", body: `{"pairs": [{"key": "a", "value": "1"}, {"key": "b", "value": ""}]}`, want: 2},
+		{name: "no pairs", body: `{"pairs": []}`, wantErr: ErrEmpty},
+		{name: "empty key", body: `{"pairs": [{"value": "1"}]}`, wantErr: ErrEmptyKey},
+		{name: "key set twice", body: `{"pairs": [{"key": "a", "value": "1"}, {"key": "a", "value": "2"}]}`, wantErr: ErrDuplicateKey},
+		{name: "invalid body", body: `not json`, wantErr: errInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, err := DecodePairs(httptest.NewRequest("POST", "/mset", strings.NewReader(tt.body)))
+			if !sameError(err, tt.wantErr) || len(pairs) != tt.want {
+				t.Fatalf("got %d pairs (%v), want %d (%v)", len(pairs), err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errInvalid stands for the error of a body that is not valid JSON
+var errInvalid = errors.New("Invalid request body")
+
+// sameError returns true if err is want, or has the same message
+func sameError(err, want error) bool {
+	if err == nil || want == nil {
+		return err == want
+	}
+	return errors.Is(err, want) || err.Error() == want.Error()
+}
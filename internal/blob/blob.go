@@ -0,0 +1,103 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ContentType is the media type of raw values
+const ContentType = "application/octet-stream"
+
+// StreamThreshold is the value size from which replication sends the raw
+// value as the request body instead of encoding it into JSON
+const StreamThreshold = 64 << 10 // 64 KiB
+
+// Headers carrying the metadata of a raw value
+const (
+	KeyHeader     = "X-KV-Key"
+	VersionHeader = "X-KV-Version"
+	ContextHeader = "X-KV-Context" // Causal context (Leaderless vector clock mode)
+)
+
+// ErrTooLarge is returned when a body exceeds the maximum value size
+var ErrTooLarge = errors.New("value exceeds the maximum value size")
+
+// IsRaw returns true if a request carries a raw value as its body
+func IsRaw(r *http.Request) bool {
+	return r.Header.Get("Content-Type") == ContentType
+}
+
+// ReadValue reads a raw value from a request body
+// Bodies larger than max bytes (0 = unlimited) are rejected with
+// ErrTooLarge without reading them whole.
+func ReadValue(w http.ResponseWriter, r *http.Request, max int64) ([]byte, error) {
+	if max > 0 && r.ContentLength > max {
+		return nil, ErrTooLarge
+	}
+	body := io.Reader(r.Body)
+	if max > 0 {
+		body = http.MaxBytesReader(w, r.Body, max)
+	}
+
+	var buf bytes.Buffer
+	if r.ContentLength > 0 {
+		buf.Grow(int(r.ContentLength))
+	}
+	if _, err := buf.ReadFrom(body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrTooLarge
+		}
+		return nil, fmt.Errorf("failed to read value: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Metadata returns the key and version of a raw value request
+func Metadata(r *http.Request) (string, int64, error) {
+	key := r.Header.Get(KeyHeader)
+	if key == "" {
+		return "", 0, fmt.Errorf("missing %s header", KeyHeader)
+	}
+	version, err := strconv.ParseInt(r.Header.Get(VersionHeader), 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid %s header: %w", VersionHeader, err)
+	}
+	return key, version, nil
+}
+
+// NewRequest creates a request that replicates a raw value
+// The body is streamed from value, so it is never copied or encoded.
+func NewRequest(url string, key string, version int64, value []byte) (*http.Request, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set(KeyHeader, key)
+	req.Header.Set(VersionHeader, strconv.FormatInt(version, 10))
+	return req, nil
+}
+
+// WriteValue writes a raw value with its version as the response
+func WriteValue(w http.ResponseWriter, value []byte, version int64) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(value)))
+	w.Header().Set(VersionHeader, strconv.FormatInt(version, 10))
+	w.WriteHeader(http.StatusOK)
+	w.Write(value)
+}
+
+// WriteSibling writes the only value of a key under causal versioning, with
+// the causal context a write replacing it must carry
+func WriteSibling(w http.ResponseWriter, value []byte, context string) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(value)))
+	w.Header().Set(ContextHeader, context)
+	w.WriteHeader(http.StatusOK)
+	w.Write(value)
+}
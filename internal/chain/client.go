@@ -0,0 +1,365 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/batch"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/numeric"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
+)
+
+// ReplicationClient handles communication between nodes
+type ReplicationClient struct {
+	httpClient *http.Client
+	faults     *fault.Injector
+	pool       *rpc.Pool // Writes and reads use gRPC when set
+}
+
+// NewReplicationClient creates a new replication client
+func NewReplicationClient(faults *fault.Injector, pool *rpc.Pool) *ReplicationClient {
+	return &ReplicationClient{
+		httpClient: &http.Client{
+			// A write waits for every downstream node, so allow more time
+			// than a single hop needs
+			Timeout: 30 * time.Second,
+		},
+		faults: faults,
+		pool:   pool,
+	}
+}
+
+// post sends a JSON request to a peer through the fault injector
+func (c *ReplicationClient) post(addr string, url string, jsonData []byte) (*http.Response, error) {
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// postRaw sends a raw value to a peer through the fault injector
+// The value is streamed as the request body rather than encoded into JSON.
+func (c *ReplicationClient) postRaw(addr string, url string, key string, value []byte, version int64) (*http.Response, error) {
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		return blob.NewRequest(url, key, version, value)
+	})
+}
+
+// get sends a GET request to a peer through the fault injector
+func (c *ReplicationClient) get(addr string, url string) (*http.Response, error) {
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		return req, nil
+	})
+}
+
+// ReplicateWriteRequest represents a write passed down the chain
+// Values of blob.StreamThreshold bytes or more are sent raw instead.
+type ReplicateWriteRequest struct {
+	Key     string `json:"key"`
+	Value   []byte `json:"value"`
+	Version int64  `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"` // A delete (tombstone) instead of a value
+}
+
+// ReplicateWriteResponse represents the acknowledgment passed back up the chain
+type ReplicateWriteResponse struct {
+	Success bool   `json:"success"`
+	Version int64  `json:"version"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReadResponse represents a read response
+type ReadResponse struct {
+	Key     string `json:"key"`
+	Value   []byte `json:"value"`
+	Version int64  `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"` // The key was deleted at Version
+	Exists  bool   `json:"exists"`
+}
+
+// ReplicateBatchRequest represents several writes passed down the chain
+// together (values are always encoded into JSON)
+type ReplicateBatchRequest struct {
+	Writes []ReplicateWriteRequest `json:"writes"`
+}
+
+// ReplicateBatchResponse holds one acknowledgment per write of a batch
+type ReplicateBatchResponse struct {
+	Responses []ReplicateWriteResponse `json:"responses"`
+}
+
+// ReadBatchRequest represents a read of several keys
+type ReadBatchRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// ReadBatchResponse holds one read response per key of a batch
+type ReadBatchResponse struct {
+	Responses []ReadResponse `json:"responses"`
+}
+
+// WriteResponse represents the response of a client write forwarded to the head
+type WriteResponse struct {
+	Key     string `json:"key"`
+	Size    int    `json:"size"`
+	Version int64  `json:"version"`
+	Status  string `json:"status"`
+}
+
+// ReplicateWrite sends a write to the successor node
+// The call returns once the tail has acknowledged the write
+func (c *ReplicationClient) ReplicateWrite(addr string, write ReplicateWriteRequest) (*ReplicateWriteResponse, error) {
+	if c.pool != nil {
+		return c.replicateWriteGRPC(addr, write)
+	}
+
+	url := fmt.Sprintf("http://%s/internal/replicate_write", addr)
+
+	var resp *http.Response
+	var err error
+	if len(write.Value) >= blob.StreamThreshold {
+		resp, err = c.postRaw(addr, url, write.Key, write.Value, write.Version)
+	} else {
+		jsonData, marshalErr := json.Marshal(write)
+		if marshalErr != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", marshalErr)
+		}
+		resp, err = c.post(addr, url, jsonData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &ReplicateWriteResponse{
+			Success: false,
+			Error:   string(body),
+		}, nil
+	}
+
+	var response ReplicateWriteResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// ReplicateBatch sends several writes to the successor node in one request
+// The call returns once the tail has acknowledged the writes, with one
+// response per write.
+func (c *ReplicationClient) ReplicateBatch(addr string, writes []ReplicateWriteRequest) ([]ReplicateWriteResponse, error) {
+	if c.pool != nil {
+		return c.replicateBatchGRPC(addr, writes)
+	}
+
+	url := fmt.Sprintf("http://%s/internal/replicate_batch", addr)
+	var response ReplicateBatchResponse
+	if err := c.postJSON(addr, url, ReplicateBatchRequest{Writes: writes}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Responses) != len(writes) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(writes), len(response.Responses))
+	}
+	return response.Responses, nil
+}
+
+// ReadBatch reads several keys from another node (normally the tail) in one
+// request, returning one response per key
+func (c *ReplicationClient) ReadBatch(addr string, keys []string) ([]ReadResponse, error) {
+	if c.pool != nil {
+		return c.readBatchGRPC(addr, keys)
+	}
+
+	url := fmt.Sprintf("http://%s/internal/read_batch", addr)
+	var response ReadBatchResponse
+	if err := c.postJSON(addr, url, ReadBatchRequest{Keys: keys}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Responses) != len(keys) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(keys), len(response.Responses))
+	}
+	return response.Responses, nil
+}
+
+// postJSON sends a JSON request to a peer and decodes its JSON response
+func (c *ReplicationClient) postJSON(addr string, url string, request interface{}, response interface{}) error {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.post(addr, url, jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// ForwardBatch forwards a client multi-set to the head of the chain
+// The caller copies the head's response back to the client.
+func (c *ReplicationClient) ForwardBatch(addr string, pairs []batch.Pair) (*http.Response, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{"pairs": pairs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.post(addr, fmt.Sprintf("http://%s/mset", addr), jsonData)
+}
+
+// ForwardIncrement forwards a client increment (or decrement, with a
+// negative delta) to the head of the chain
+// The caller copies the head's response back to the client.
+func (c *ReplicationClient) ForwardIncrement(addr string, op numeric.Op) (*http.Response, error) {
+	jsonData, err := json.Marshal(op)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.post(addr, fmt.Sprintf("http://%s/incr", addr), jsonData)
+}
+
+// ForwardWrite forwards a client write to the head of the chain
+// The value is sent raw to the head's /kv endpoint so binary values survive.
+func (c *ReplicationClient) ForwardWrite(addr string, key string, value []byte) (*WriteResponse, error) {
+	target := fmt.Sprintf("http://%s/kv/%s", addr, url.PathEscape(key))
+	resp, err := c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		req, err := http.NewRequest("PUT", target, bytes.NewReader(value))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", blob.ContentType)
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var response WriteResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// Forward sends a client request to another node (normally the head) with
+// value as a raw body (nil for none)
+// The request keeps its method, path and conditional headers; the caller
+// copies the response back to the client.
+func (c *ReplicationClient) Forward(addr string, r *http.Request, value []byte) (*http.Response, error) {
+	target := fmt.Sprintf("http://%s%s", addr, r.URL.RequestURI())
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		req, err := http.NewRequest(r.Method, target, bytes.NewReader(value))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if value != nil {
+			req.Header.Set("Content-Type", blob.ContentType)
+		}
+		for _, header := range rest.ConditionalHeaders {
+			if v := r.Header.Get(header); v != "" {
+				req.Header.Set(header, v)
+			}
+		}
+		return req, nil
+	})
+}
+
+// ForwardKey sends a write (or a delete, with a nil value) of a key to
+// another node's resource API (normally the head's)
+// Returns the version written (0 for a delete).
+func (c *ReplicationClient) ForwardKey(addr string, method string, key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	r, err := http.NewRequest(method, "/v1/keys/"+url.PathEscape(key), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	preconditions.SetHeaders(r.Header)
+
+	resp, err := c.Forward(addr, r, value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	return rest.ReadStored(resp)
+}
+
+// ReadFromNode reads a value from another node (normally the tail)
+func (c *ReplicationClient) ReadFromNode(addr string, key string) (*ReadResponse, error) {
+	if c.pool != nil {
+		return c.readGRPC(addr, key)
+	}
+
+	url := fmt.Sprintf("http://%s/internal/read?key=%s", addr, url.QueryEscape(key))
+
+	resp, err := c.get(addr, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response ReadResponse
+	if resp.StatusCode == http.StatusNotFound {
+		response.Exists = false
+		return &response, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	response.Exists = true
+	return &response, nil
+}
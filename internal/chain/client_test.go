@@ -0,0 +1,25 @@
+package chain
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadFromNodeEscapesKey(t *testing.T) {
+	key := "a&b=c d/é?"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ReadResponse{Key: r.URL.Query().Get("key"), Exists: true})
+	}))
+	defer server.Close()
+
+	response, err := NewReplicationClient(nil, nil).ReadFromNode(strings.TrimPrefix(server.URL, "http://"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Key != key {
+		t.Fatalf("got key %q, want %q", response.Key, key)
+	}
+}
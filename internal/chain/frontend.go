@@ -0,0 +1,75 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// FrontendBackend serves the protocol frontends (RESP, memcached) through the
+// chain: reads are served by the tail and writes are applied by the head
+type FrontendBackend struct {
+	config     *Config
+	replicator *ReplicationManager
+}
+
+// FrontendBackend returns the backend of this node's protocol frontends
+func (h *Handler) FrontendBackend() *FrontendBackend {
+	return &FrontendBackend{config: h.config, replicator: h.replicator}
+}
+
+// Get reads a key, returning nil if it does not exist
+func (b *FrontendBackend) Get(key string) (*kvstore.KeyValue, error) {
+	kv, err := b.replicator.Read(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	return kv, err
+}
+
+// Set writes a key if its preconditions hold
+func (b *FrontendBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	if value == nil {
+		value = []byte{}
+	}
+	return b.write(ReplicateWriteRequest{Key: key, Value: value}, preconditions)
+}
+
+// Delete deletes a key if its preconditions hold
+func (b *FrontendBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.write(ReplicateWriteRequest{Key: key, Deleted: true}, preconditions)
+	return err
+}
+
+// write applies a write on the head, or forwards it there
+func (b *FrontendBackend) write(write ReplicateWriteRequest, preconditions rest.Preconditions) (int64, error) {
+	if !b.config.IsHead() {
+		method := "PUT"
+		if write.Deleted {
+			method = "DELETE"
+		}
+		return b.replicator.client.ForwardKey(b.config.GetHeadAddr(), method, write.Key, write.Value, preconditions)
+	}
+
+	result, err := b.replicator.WriteIf(write, preconditions.Condition())
+	if err != nil {
+		return 0, err
+	}
+	return result.Version, nil
+}
+
+// CheckNamespace rejects namespace settings the chain cannot apply: every
+// node holds every key, and writes always go through the whole chain
+func (h *Handler) CheckNamespace(settings namespace.Settings) error {
+	n := len(h.config.GetChainAddrs())
+	if settings.ReplicationFactor != 0 && settings.ReplicationFactor != n {
+		return fmt.Errorf("replication_factor must be %d, every node replicates every key", n)
+	}
+	if settings.Consistency != "" && settings.Consistency != namespace.ConsistencyOne && settings.Consistency != namespace.ConsistencyAll {
+		return errors.New("consistency must be one (read from any node) or all (read from the tail)")
+	}
+	return nil
+}
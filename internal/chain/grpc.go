@@ -0,0 +1,164 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/rpc/kvpb"
+)
+
+// ReplicationService serves the internal API over gRPC, like
+// ReplicateWriteHandler, ReplicateBatchHandler, InternalReadHandler and
+// InternalReadBatchHandler
+type ReplicationService struct {
+	kvpb.UnimplementedReplicationServer
+	handler *Handler
+}
+
+// ReplicationService returns this node's gRPC internal API
+func (h *Handler) ReplicationService() *ReplicationService {
+	return &ReplicationService{handler: h}
+}
+
+// ReplicateWrite applies a write from the predecessor
+// Returns only after the write has reached the tail
+func (s *ReplicationService) ReplicateWrite(ctx context.Context, req *kvpb.ReplicateWriteRequest) (*kvpb.ReplicateWriteResponse, error) {
+	write := ReplicateWriteRequest{Key: req.Key, Value: req.Value, Version: req.Version, Deleted: req.Deleted}
+
+	// Injected receive delay (Node sleeps 100ms when receiving update before
+	// passing it on in the homework profile)
+	s.handler.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	if err := s.handler.replicator.ApplyWrite(write); err != nil {
+		return &kvpb.ReplicateWriteResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &kvpb.ReplicateWriteResponse{Success: true, Version: write.Version}, nil
+}
+
+// Read reads a key from this node's store
+func (s *ReplicationService) Read(ctx context.Context, req *kvpb.ReadRequest) (*kvpb.ReadResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	r := s.handler.internalRead(req.Key)
+	return &kvpb.ReadResponse{Exists: r.Exists, Key: r.Key, Value: r.Value, Version: r.Version, Deleted: r.Deleted}, nil
+}
+
+// ReplicateBatch applies a batch of writes from the predecessor
+// Returns only after the writes have reached the tail
+func (s *ReplicationService) ReplicateBatch(ctx context.Context, req *kvpb.ReplicateBatchRequest) (*kvpb.ReplicateBatchResponse, error) {
+	writes := make([]ReplicateWriteRequest, len(req.Writes))
+	for i, write := range req.Writes {
+		writes[i] = ReplicateWriteRequest{Key: write.Key, Value: write.Value, Version: write.Version, Deleted: write.Deleted}
+	}
+
+	resp := &kvpb.ReplicateBatchResponse{}
+	for _, response := range s.handler.applyBatch(writes) {
+		resp.Responses = append(resp.Responses, &kvpb.ReplicateWriteResponse{Success: response.Success, Version: response.Version, Error: response.Error})
+	}
+	return resp, nil
+}
+
+// ReadBatch reads several keys from this node's store
+func (s *ReplicationService) ReadBatch(ctx context.Context, req *kvpb.ReadBatchRequest) (*kvpb.ReadBatchResponse, error) {
+	resp := &kvpb.ReadBatchResponse{}
+	for _, key := range req.Keys {
+		r := s.handler.internalRead(key)
+		resp.Responses = append(resp.Responses, &kvpb.ReadResponse{Exists: r.Exists, Key: r.Key, Value: r.Value, Version: r.Version, Deleted: r.Deleted})
+	}
+	return resp, nil
+}
+
+// replicateWriteGRPC sends a write to the successor over gRPC
+func (c *ReplicationClient) replicateWriteGRPC(addr string, write ReplicateWriteRequest) (*ReplicateWriteResponse, error) {
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	// Same deadline as an HTTP request
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.ReplicateWrite(ctx, &kvpb.ReplicateWriteRequest{
+		Key:     write.Key,
+		Value:   write.Value,
+		Version: write.Version,
+		Deleted: write.Deleted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return &ReplicateWriteResponse{Success: resp.Success, Version: resp.Version, Error: resp.Error}, nil
+}
+
+// readGRPC reads a value from another node over gRPC
+func (c *ReplicationClient) readGRPC(addr string, key string) (*ReadResponse, error) {
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.Read(ctx, &kvpb.ReadRequest{Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return &ReadResponse{Key: resp.Key, Value: resp.Value, Version: resp.Version, Deleted: resp.Deleted, Exists: resp.Exists}, nil
+}
+
+// replicateBatchGRPC sends a batch of writes to the successor over gRPC
+func (c *ReplicationClient) replicateBatchGRPC(addr string, writes []ReplicateWriteRequest) ([]ReplicateWriteResponse, error) {
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	req := &kvpb.ReplicateBatchRequest{Writes: make([]*kvpb.ReplicateWriteRequest, len(writes))}
+	for i, write := range writes {
+		req.Writes[i] = &kvpb.ReplicateWriteRequest{Key: write.Key, Value: write.Value, Version: write.Version, Deleted: write.Deleted}
+	}
+	resp, err := client.ReplicateBatch(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if len(resp.Responses) != len(writes) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(writes), len(resp.Responses))
+	}
+
+	responses := make([]ReplicateWriteResponse, len(resp.Responses))
+	for i, response := range resp.Responses {
+		responses[i] = ReplicateWriteResponse{Success: response.Success, Version: response.Version, Error: response.Error}
+	}
+	return responses, nil
+}
+
+// readBatchGRPC reads several keys from another node over gRPC
+func (c *ReplicationClient) readBatchGRPC(addr string, keys []string) ([]ReadResponse, error) {
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.ReadBatch(ctx, &kvpb.ReadBatchRequest{Keys: keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if len(resp.Responses) != len(keys) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(keys), len(resp.Responses))
+	}
+
+	responses := make([]ReadResponse, len(resp.Responses))
+	for i, r := range resp.Responses {
+		responses[i] = ReadResponse{Key: r.Key, Value: r.Value, Version: r.Version, Deleted: r.Deleted, Exists: r.Exists}
+	}
+	return responses, nil
+}
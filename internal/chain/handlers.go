@@ -0,0 +1,560 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/batch"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/numeric"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// Handler provides HTTP handlers for Chain Replication database
+type Handler struct {
+	store      *kvstore.Store
+	config     *Config
+	replicator *ReplicationManager
+}
+
+// NewHandler creates a new Chain Replication handler
+func NewHandler(store *kvstore.Store, config *Config) *Handler {
+	replicator := NewReplicationManager(store, config)
+	return &Handler{
+		store:      store,
+		config:     config,
+		replicator: replicator,
+	}
+}
+
+// SetHandler handles write requests (executed by the head, forwarded by other nodes)
+func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !rest.ValidKey(w, req.Key) {
+		return
+	}
+
+	value := []byte(req.Value)
+	if err := h.store.CheckValueSize(len(value)); err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Write travels head -> ... -> tail and is acknowledged by the tail
+	result, err := h.replicator.Write(req.Key, value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     req.Key,
+		"value":   req.Value,
+		"version": result.Version,
+		"status":  "created",
+	})
+}
+
+// GetHandler handles read requests (served by the tail)
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     kv.Key,
+		"value":   string(kv.Value),
+		"version": kv.Version,
+	})
+}
+
+// MGetHandler handles POST /mget, reading several keys at once (served by
+// the tail in one request)
+func (h *Handler) MGetHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := batch.DecodeKeys(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values, err := h.replicator.ReadBatch(keys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	results := make([]map[string]interface{}, len(keys))
+	for i, kv := range values {
+		if kv == nil {
+			results[i] = batch.ErrorResult(keys[i], http.StatusNotFound, "key not found")
+			continue
+		}
+		results[i] = batch.Result(http.StatusOK, map[string]interface{}{
+			"key":     kv.Key,
+			"value":   string(kv.Value),
+			"version": kv.Version,
+		})
+	}
+	batch.WriteResults(w, results)
+}
+
+// MSetHandler handles POST /mset, writing several keys at once (executed by
+// the head, forwarded by other nodes)
+// The writes travel down the chain as one batch; each key succeeds or fails
+// on its own.
+func (h *Handler) MSetHandler(w http.ResponseWriter, r *http.Request) {
+	pairs, err := batch.DecodePairs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.config.IsHead() {
+		resp, err := h.replicator.client.ForwardBatch(h.config.GetHeadAddr(), pairs)
+		copyResponse(w, resp, err)
+		return
+	}
+
+	writes := make([]ReplicateWriteRequest, len(pairs))
+	for i, pair := range pairs {
+		writes[i] = ReplicateWriteRequest{Key: pair.Key, Value: []byte(pair.Value)}
+	}
+	writeResults, err := h.replicator.WriteBatch(writes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]map[string]interface{}, len(pairs))
+	for i, result := range writeResults {
+		if result.Error != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(result.Error, kvstore.ErrValueTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			results[i] = batch.ErrorResult(pairs[i].Key, status, result.Error.Error())
+			continue
+		}
+		results[i] = batch.Result(http.StatusCreated, map[string]interface{}{
+			"key":     pairs[i].Key,
+			"value":   pairs[i].Value,
+			"version": result.Version,
+		})
+	}
+	batch.WriteResults(w, results)
+}
+
+// PutValueHandler handles PUT /kv/{key} with the raw value as the body
+func (h *Handler) PutValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.replicator.Write(key, value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     key,
+		"size":    len(value),
+		"version": result.Version,
+		"status":  "created",
+	})
+}
+
+// GetValueHandler handles GET /kv/{key}, returning the raw value (served by
+// the tail)
+func (h *Handler) GetValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	blob.WriteValue(w, kv.Value, kv.Version)
+}
+
+// KeyGetHandler handles GET /v1/keys/{key} (served by the tail)
+// Honors If-None-Match (304 Not Modified) and If-Match (412)
+func (h *Handler) KeyGetHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	if status := rest.ParsePreconditions(r).ReadStatus(kv); status != 0 {
+		rest.WriteStatus(w, status, kv)
+		return
+	}
+	if kv == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	rest.WriteValue(w, kv)
+}
+
+// IncrHandler handles POST /incr, adding a delta to an integer value
+// (executed by the head, forwarded by other nodes)
+func (h *Handler) IncrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, false)
+}
+
+// DecrHandler handles POST /decr, subtracting a delta from an integer value
+// (executed by the head, forwarded by other nodes)
+func (h *Handler) DecrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, true)
+}
+
+// increment updates the value on the head, which reads and writes it
+// atomically
+func (h *Handler) increment(w http.ResponseWriter, r *http.Request, decr bool) {
+	op, err := numeric.Decode(r, decr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.config.IsHead() {
+		resp, err := h.replicator.client.ForwardIncrement(h.config.GetHeadAddr(), op)
+		copyResponse(w, resp, err)
+		return
+	}
+
+	kv, err := h.replicator.Update(op.Key, op.Apply)
+	if err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	numeric.WriteResult(w, kv)
+}
+
+// copyResponse copies the head's response to a forwarded request back to
+// the client
+func copyResponse(w http.ResponseWriter, resp *http.Response, err error) {
+	if err != nil {
+		http.Error(w, "failed to forward to head: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// KeyPutHandler handles PUT /v1/keys/{key} (executed by the head, forwarded
+// by other nodes)
+// With If-Match or If-None-Match the value is only written if the head's
+// current version satisfies them (412 otherwise)
+func (h *Handler) KeyPutHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	value, err := rest.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.forwardToHead(w, r, value) {
+		return
+	}
+
+	result, err := h.replicator.WriteIf(ReplicateWriteRequest{Key: key, Value: value}, rest.ParsePreconditions(r).Condition())
+	if err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	rest.WriteStored(w, key, result.Version)
+}
+
+// KeyDeleteHandler handles DELETE /v1/keys/{key} (executed by the head,
+// forwarded by other nodes)
+// Conditional like KeyPutHandler
+func (h *Handler) KeyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.forwardToHead(w, r, nil) {
+		return
+	}
+
+	write := ReplicateWriteRequest{Key: key, Deleted: true}
+	if _, err := h.replicator.WriteIf(write, rest.ParsePreconditions(r).Condition()); err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// forwardToHead proxies a resource request received by a node other than
+// the head to the head, sending value as the raw body, and copies the
+// head's response back
+// Returns false on the head.
+func (h *Handler) forwardToHead(w http.ResponseWriter, r *http.Request, value []byte) bool {
+	if h.config.IsHead() {
+		return false
+	}
+
+	resp, err := h.replicator.client.Forward(h.config.GetHeadAddr(), r, value)
+	if err != nil {
+		http.Error(w, "failed to forward to head: "+err.Error(), http.StatusServiceUnavailable)
+		return true
+	}
+	defer resp.Body.Close()
+
+	for _, header := range []string{"Content-Type", "ETag"} {
+		if v := resp.Header.Get(header); v != "" {
+			w.Header().Set(header, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+	return true
+}
+
+// LocalReadHandler handles local reads (for testing)
+func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, exists := h.store.LocalRead(key)
+	if !exists {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     kv.Key,
+		"value":   string(kv.Value),
+		"version": kv.Version,
+	})
+}
+
+// ReplicateWriteHandler handles internal write requests from the predecessor
+// Responds only after the write has reached the tail
+func (h *Handler) ReplicateWriteHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReplicateWriteRequest
+
+	if blob.IsRaw(r) {
+		// Large values arrive raw, with the metadata in headers
+		key, version, err := blob.Metadata(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req = ReplicateWriteRequest{Key: key, Value: value, Version: version}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Injected receive delay (Node sleeps 100ms when receiving update before
+	// passing it on in the homework profile)
+	h.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	if err := h.replicator.ApplyWrite(req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(ReplicateWriteResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReplicateWriteResponse{
+		Success: true,
+		Version: req.Version,
+	})
+}
+
+// ReplicateBatchHandler handles internal batches of writes from the
+// predecessor
+// Responds only after the writes have reached the tail, with one
+// acknowledgment per write
+func (h *Handler) ReplicateBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReplicateBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReplicateBatchResponse{
+		Responses: h.applyBatch(req.Writes),
+	})
+}
+
+// applyBatch applies a batch of writes from the predecessor
+func (h *Handler) applyBatch(writes []ReplicateWriteRequest) []ReplicateWriteResponse {
+	// Injected receive delay, once per batch like a single write
+	h.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	responses := make([]ReplicateWriteResponse, len(writes))
+	for i, err := range h.replicator.ApplyBatch(writes) {
+		if err != nil {
+			responses[i] = ReplicateWriteResponse{Success: false, Error: err.Error()}
+			continue
+		}
+		responses[i] = ReplicateWriteResponse{Success: true, Version: writes[i].Version}
+	}
+	return responses
+}
+
+// InternalReadHandler handles internal read requests from other nodes
+func (h *Handler) InternalReadHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "key parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	response := h.internalRead(key)
+	status := http.StatusOK
+	if !response.Exists {
+		status = http.StatusNotFound
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// internalRead reads a key from this node's store for another node
+func (h *Handler) internalRead(key string) ReadResponse {
+	kv, exists := h.store.Get(key)
+	if !exists {
+		return ReadResponse{Exists: false}
+	}
+	return ReadResponse{
+		Key:     kv.Key,
+		Value:   kv.Value,
+		Version: kv.Version,
+		Deleted: kv.Deleted,
+		Exists:  true,
+	}
+}
+
+// InternalReadBatchHandler handles internal reads of several keys from
+// other nodes
+func (h *Handler) InternalReadBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReadBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	responses := make([]ReadResponse, len(req.Keys))
+	for i, key := range req.Keys {
+		responses[i] = h.internalRead(key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReadBatchResponse{Responses: responses})
+}
+
+// ConfigHandler returns the chain layout as seen by this node
+func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"node_id":  h.config.NodeID,
+		"role":     h.config.GetRole(),
+		"position": h.config.GetPosition(),
+		"n":        h.config.N,
+		"chain":    h.config.GetChainAddrs(),
+		"head":     h.config.GetHeadAddr(),
+		"tail":     h.config.GetTailAddr(),
+	})
+}
+
+// HealthHandler provides a health check endpoint
+func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "healthy",
+		"mode":    "chain",
+		"role":    h.config.GetRole(),
+		"node_id": h.config.NodeID,
+		"time":    time.Now().UTC().Format(time.RFC3339),
+	})
+}
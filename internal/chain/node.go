@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"sync"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
+)
+
+// Config holds the configuration for the Chain Replication cluster
+type Config struct {
+	mu         sync.RWMutex
+	NodeID     string          // Unique identifier for this node
+	MyAddr     string          // Address of this node
+	ChainAddrs []string        // All node addresses in chain order (head first, tail last)
+	N          int             // Total number of nodes in the chain
+	Faults     *fault.Injector // Injected delays and faults (nil = none)
+	GRPC       *rpc.Pool       // gRPC transport for writes and reads between nodes (nil = HTTP JSON)
+
+	Namespaces *namespace.Registry // Settings of namespaced keys (nil = none)
+}
+
+// NewConfig creates a new chain configuration
+func NewConfig(nodeID string, myAddr string, chainAddrs []string) *Config {
+	return &Config{
+		NodeID:     nodeID,
+		MyAddr:     myAddr,
+		ChainAddrs: chainAddrs,
+		N:          len(chainAddrs),
+	}
+}
+
+// GetMyAddr returns the address of this node
+func (c *Config) GetMyAddr() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.MyAddr
+}
+
+// GetChainAddrs returns the addresses of all nodes in chain order
+func (c *Config) GetChainAddrs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string{}, c.ChainAddrs...)
+}
+
+// GetPosition returns this node's index in the chain, or -1 if it is not part of it
+func (c *Config) GetPosition() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i, addr := range c.ChainAddrs {
+		if addr == c.MyAddr {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetHeadAddr returns the address of the head of the chain
+func (c *Config) GetHeadAddr() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ChainAddrs[0]
+}
+
+// GetTailAddr returns the address of the tail of the chain
+func (c *Config) GetTailAddr() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ChainAddrs[len(c.ChainAddrs)-1]
+}
+
+// GetSuccessorAddr returns the address of the next node in the chain
+// Returns an empty string if this node is the tail
+func (c *Config) GetSuccessorAddr() string {
+	pos := c.GetPosition()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if pos < 0 || pos+1 >= len(c.ChainAddrs) {
+		return ""
+	}
+	return c.ChainAddrs[pos+1]
+}
+
+// IsHead returns true if this node is the head of the chain
+func (c *Config) IsHead() bool {
+	return c.GetPosition() == 0
+}
+
+// IsTail returns true if this node is the tail of the chain
+func (c *Config) IsTail() bool {
+	return c.GetPosition() == c.N-1
+}
+
+// GetRole returns a human readable role for this node
+func (c *Config) GetRole() string {
+	switch {
+	case c.IsHead() && c.IsTail():
+		return "head+tail"
+	case c.IsHead():
+		return "head"
+	case c.IsTail():
+		return "tail"
+	default:
+		return "middle"
+	}
+}
+
+// ReadsLocally returns true if this node serves reads of a key from its
+// own store: the tail always does, any node does for keys of a namespace
+// with consistency "one"
+func (c *Config) ReadsLocally(key string) bool {
+	if c.IsTail() {
+		return true
+	}
+	c.mu.RLock()
+	namespaces := c.Namespaces
+	c.mu.RUnlock()
+
+	settings, ok := namespaces.Lookup(key)
+	return ok && settings.Consistency == namespace.ConsistencyOne
+}
+
+// SetFaults sets the fault injector used by this node
+func (c *Config) SetFaults(faults *fault.Injector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Faults = faults
+}
+
+// GetFaults returns the fault injector used by this node
+func (c *Config) GetFaults() *fault.Injector {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Faults
+}
+
+// SetGRPC makes writes and reads between nodes use gRPC connections from pool
+func (c *Config) SetGRPC(pool *rpc.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.GRPC = pool
+}
+
+// GetGRPC returns the gRPC connection pool, or nil when nodes talk HTTP JSON
+func (c *Config) GetGRPC() *rpc.Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.GRPC
+}
+
+// SetNamespaces sets the namespace registry used by this node
+func (c *Config) SetNamespaces(namespaces *namespace.Registry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Namespaces = namespaces
+}
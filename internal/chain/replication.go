@@ -0,0 +1,312 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// ReplicationManager handles replication along the chain
+type ReplicationManager struct {
+	store  *kvstore.Store
+	config *Config
+	client *ReplicationClient
+	mu     sync.Mutex // Keeps the versions of a batch consecutive on the head
+	seq    *sequencer // Applies writes from the predecessor in version order
+}
+
+// NewReplicationManager creates a new replication manager
+func NewReplicationManager(store *kvstore.Store, config *Config) *ReplicationManager {
+	return &ReplicationManager{
+		store:  store,
+		config: config,
+		client: NewReplicationClient(config.GetFaults(), config.GetGRPC()),
+		seq:    newSequencer(store),
+	}
+}
+
+// ErrKeyNotFound is returned by reads of a key that does not exist (or
+// whose most recent version is a delete)
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrNotHead is returned by conditional writes and deletes on a node other
+// than the head
+var ErrNotHead = errors.New("only the head applies conditional writes and deletes")
+
+// WriteResult represents the result of a write operation
+type WriteResult struct {
+	Version int64
+	Success bool
+	Error   error
+}
+
+// Write performs a client write
+// Writes enter at the head, which assigns the version and passes the write
+// down the chain. The write is acknowledged once the tail has applied it.
+// Any other node forwards the write to the head.
+func (rm *ReplicationManager) Write(key string, value []byte) (*WriteResult, error) {
+	if !rm.config.IsHead() {
+		response, err := rm.client.ForwardWrite(rm.config.GetHeadAddr(), key, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to forward write to head: %w", err)
+		}
+		return &WriteResult{Version: response.Version, Success: true}, nil
+	}
+
+	return rm.WriteIf(ReplicateWriteRequest{Key: key, Value: value}, nil)
+}
+
+// WriteIf performs a write (a value or a delete) on the head if condition
+// (when not nil) accepts the head's current value
+// The head orders all writes, so the check is atomic with the write. Other
+// nodes return ErrNotHead and must forward the client request instead.
+func (rm *ReplicationManager) WriteIf(write ReplicateWriteRequest, condition kvstore.Condition) (*WriteResult, error) {
+	if !rm.config.IsHead() {
+		return nil, ErrNotHead
+	}
+
+	// The lock covers only versioning and the local apply: the write is
+	// passed on concurrently with others, and every node downstream applies
+	// them in version order
+	rm.mu.Lock()
+	var err error
+	if write.Deleted {
+		write.Version, err = rm.store.DeleteIf(write.Key, condition)
+	} else {
+		write.Version, err = rm.store.SetIf(write.Key, write.Value, condition)
+	}
+	rm.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rm.forwardToSuccessor(write); err != nil {
+		return nil, err
+	}
+
+	return &WriteResult{Version: write.Version, Success: true}, nil
+}
+
+// Update writes the value update computes from the head's current value of
+// a key, like WriteIf
+// The head orders all writes, so the read and the write are atomic; the new
+// value is passed down the chain like any other write.
+func (rm *ReplicationManager) Update(key string, update kvstore.UpdateFunc) (*kvstore.KeyValue, error) {
+	if !rm.config.IsHead() {
+		return nil, ErrNotHead
+	}
+
+	rm.mu.Lock()
+	kv, err := rm.store.Update(key, update)
+	rm.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rm.forwardToSuccessor(ReplicateWriteRequest{Key: key, Value: kv.Value, Version: kv.Version}); err != nil {
+		return nil, err
+	}
+
+	return kv, nil
+}
+
+// ApplyWrite applies a write received from the predecessor and passes it on
+// Returns once every downstream node (up to the tail) has applied the write
+func (rm *ReplicationManager) ApplyWrite(write ReplicateWriteRequest) error {
+	var err error
+	rm.seq.apply(write.Version, write.Version, func() {
+		if write.Deleted {
+			_, err = rm.store.DeleteWithVersion(write.Key, write.Version)
+		} else {
+			_, err = rm.store.SetWithVersion(write.Key, write.Value, write.Version)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return rm.forwardToSuccessor(write)
+}
+
+// forwardToSuccessor sends a write to the next node in the chain
+// The tail has no successor and acknowledges immediately
+func (rm *ReplicationManager) forwardToSuccessor(write ReplicateWriteRequest) error {
+	successorAddr := rm.config.GetSuccessorAddr()
+	if successorAddr == "" {
+		return nil
+	}
+
+	response, err := rm.client.ReplicateWrite(successorAddr, write)
+	if err != nil {
+		return fmt.Errorf("failed to replicate to %s: %w", successorAddr, err)
+	}
+	if !response.Success {
+		return fmt.Errorf("replication to %s failed: %s", successorAddr, response.Error)
+	}
+
+	return nil
+}
+
+// WriteBatch performs several client writes on the head
+// The writes are applied in order and passed down the chain as one batch, so
+// each hop costs one message rather than one per write. Returns the result
+// of each write; writes the head rejects are not passed on.
+func (rm *ReplicationManager) WriteBatch(writes []ReplicateWriteRequest) ([]WriteResult, error) {
+	if !rm.config.IsHead() {
+		return nil, ErrNotHead
+	}
+
+	rm.mu.Lock()
+	results := make([]WriteResult, len(writes))
+	var accepted []ReplicateWriteRequest
+	var indexes []int
+	for i, write := range writes {
+		var err error
+		if write.Deleted {
+			write.Version, err = rm.store.DeleteIf(write.Key, nil)
+		} else {
+			write.Version, err = rm.store.SetIf(write.Key, write.Value, nil)
+		}
+		if err != nil {
+			results[i] = WriteResult{Error: err}
+			continue
+		}
+		results[i] = WriteResult{Version: write.Version}
+		accepted = append(accepted, write)
+		indexes = append(indexes, i)
+	}
+	rm.mu.Unlock()
+
+	for j, err := range rm.forwardBatchToSuccessor(accepted) {
+		if err != nil {
+			results[indexes[j]].Error = err
+			continue
+		}
+		results[indexes[j]].Success = true
+	}
+	return results, nil
+}
+
+// ApplyBatch applies a batch of writes received from the predecessor and
+// passes the ones it applied on as one batch
+// Returns the error of each write (nil once every downstream node has
+// applied it)
+func (rm *ReplicationManager) ApplyBatch(writes []ReplicateWriteRequest) []error {
+	errs := make([]error, len(writes))
+	if len(writes) == 0 {
+		return errs
+	}
+
+	// The head versions the writes of a batch consecutively
+	var accepted []ReplicateWriteRequest
+	var indexes []int
+	rm.seq.apply(writes[0].Version, writes[len(writes)-1].Version, func() {
+		for i, write := range writes {
+			var err error
+			if write.Deleted {
+				_, err = rm.store.DeleteWithVersion(write.Key, write.Version)
+			} else {
+				_, err = rm.store.SetWithVersion(write.Key, write.Value, write.Version)
+			}
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			accepted = append(accepted, write)
+			indexes = append(indexes, i)
+		}
+	})
+
+	for j, err := range rm.forwardBatchToSuccessor(accepted) {
+		errs[indexes[j]] = err
+	}
+	return errs
+}
+
+// forwardBatchToSuccessor sends writes to the next node in the chain in one
+// request
+// Returns the error of each write; the tail has no successor and
+// acknowledges immediately.
+func (rm *ReplicationManager) forwardBatchToSuccessor(writes []ReplicateWriteRequest) []error {
+	errs := make([]error, len(writes))
+	successorAddr := rm.config.GetSuccessorAddr()
+	if successorAddr == "" || len(writes) == 0 {
+		return errs
+	}
+
+	responses, err := rm.client.ReplicateBatch(successorAddr, writes)
+	if err != nil {
+		err = fmt.Errorf("failed to replicate to %s: %w", successorAddr, err)
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	for i, response := range responses {
+		if !response.Success {
+			errs[i] = fmt.Errorf("replication to %s failed: %s", successorAddr, response.Error)
+		}
+	}
+	return errs
+}
+
+// Read performs a client read
+// Reads are served by the tail, which only holds fully replicated writes.
+// Any other node fetches the value from the tail, unless the key's namespace
+// has consistency "one". A key whose most recent version is a delete is not
+// found.
+func (rm *ReplicationManager) Read(key string) (*kvstore.KeyValue, error) {
+	if rm.config.ReadsLocally(key) {
+		kv, exists := rm.store.Get(key)
+		if !exists || kv.Deleted {
+			return nil, ErrKeyNotFound
+		}
+		return kv, nil
+	}
+
+	response, err := rm.client.ReadFromNode(rm.config.GetTailAddr(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from tail: %w", err)
+	}
+	if !response.Exists || response.Deleted {
+		return nil, ErrKeyNotFound
+	}
+
+	return &kvstore.KeyValue{
+		Key:     response.Key,
+		Value:   response.Value,
+		Version: response.Version,
+	}, nil
+}
+
+// ReadBatch performs a client read of several keys
+// The tail reads them locally; any other node fetches them from the tail in
+// one request. Keys that are not found are nil.
+func (rm *ReplicationManager) ReadBatch(keys []string) ([]*kvstore.KeyValue, error) {
+	values := make([]*kvstore.KeyValue, len(keys))
+	if rm.config.IsTail() {
+		for i, key := range keys {
+			if kv, exists := rm.store.Get(key); exists && !kv.Deleted {
+				values[i] = kv
+			}
+		}
+		return values, nil
+	}
+
+	responses, err := rm.client.ReadBatch(rm.config.GetTailAddr(), keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from tail: %w", err)
+	}
+	for i, response := range responses {
+		if response.Exists && !response.Deleted {
+			values[i] = &kvstore.KeyValue{
+				Key:     response.Key,
+				Value:   response.Value,
+				Version: response.Version,
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,78 @@
+package chain
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// sequenceGapTimeout is how long a write waits for the writes versioned
+// before it. A write the head failed to pass on never arrives, so the gap
+// it leaves is skipped after this long.
+const sequenceGapTimeout = 5 * time.Second
+
+// sequencer makes a node apply the writes it receives from its predecessor
+// in version order
+// The head versions writes with consecutive numbers from the store's global
+// counter and passes them on concurrently, so a write is next once every
+// version before it has been applied.
+type sequencer struct {
+	mu         sync.Mutex
+	store      *kvstore.Store
+	gapTimeout time.Duration
+	applied    int64         // Highest version applied through the sequencer
+	changed    chan struct{} // Closed and replaced whenever a write is applied
+}
+
+// newSequencer creates a sequencer for the writes applied to store
+func newSequencer(store *kvstore.Store) *sequencer {
+	return &sequencer{
+		store:      store,
+		gapTimeout: sequenceGapTimeout,
+		changed:    make(chan struct{}),
+	}
+}
+
+// apply runs fn, the local apply of writes with versions first to last,
+// once every earlier version has been applied
+// A node that has not applied anything yet takes any write as next.
+func (s *sequencer) apply(first int64, last int64, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := time.Now().Add(s.gapTimeout)
+	for {
+		current := s.applied
+		if version := s.store.GetVersion(); version > current {
+			current = version
+		}
+		if current == 0 || first <= current+1 {
+			break
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			log.Printf("Versions %d to %d never arrived; applying version %d", current+1, first-1, first)
+			break
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		timer := time.NewTimer(remaining)
+		select {
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+		s.mu.Lock()
+	}
+
+	fn()
+	// Writes the store rejected must not hold up the ones after them
+	if last > s.applied {
+		s.applied = last
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
@@ -0,0 +1,68 @@
+package chain
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestSequencerAppliesInVersionOrder(t *testing.T) {
+	store := kvstore.NewStore()
+	s := newSequencer(store)
+	s.apply(1, 1, func() { store.SetWithVersion("k", []byte("1"), 1) })
+
+	// Versions 2 to 5 arrive in reverse order
+	var mu sync.Mutex
+	var order []int64
+	var wg sync.WaitGroup
+	for version := int64(5); version >= 2; version-- {
+		wg.Add(1)
+		go func(version int64) {
+			defer wg.Done()
+			s.apply(version, version, func() {
+				mu.Lock()
+				order = append(order, version)
+				mu.Unlock()
+				store.SetWithVersion("k", []byte("v"), version)
+			})
+		}(version)
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+
+	if len(order) != 4 {
+		t.Fatalf("got order %v, want 2 to 5", order)
+	}
+	for i, version := range order {
+		if version != int64(i+2) {
+			t.Fatalf("got order %v, want 2 to 5", order)
+		}
+	}
+}
+
+func TestSequencerSkipsGap(t *testing.T) {
+	store := kvstore.NewStore()
+	s := newSequencer(store)
+	s.gapTimeout = 50 * time.Millisecond
+
+	// A node that has not applied anything takes any version as next
+	s.apply(10, 11, func() { store.SetWithVersion("k", []byte("v"), 11) })
+
+	// Version 12 never arrives
+	start := time.Now()
+	applied := false
+	s.apply(13, 13, func() { applied = true })
+	if !applied || time.Since(start) < s.gapTimeout {
+		t.Fatalf("got applied %v after %v, want applied after %v", applied, time.Since(start), s.gapTimeout)
+	}
+
+	// A write the store rejects still lets the next one in
+	s.apply(14, 14, func() {})
+	start = time.Now()
+	s.apply(15, 15, func() {})
+	if time.Since(start) >= s.gapTimeout {
+		t.Fatal("version 15 waited for version 14")
+	}
+}
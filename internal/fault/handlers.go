@@ -0,0 +1,57 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler reads or replaces the fault injection configuration
+// GET returns the current configuration. POST replaces it; the body is a
+// full Config, or {"profile": "<name>"} to load a named profile.
+func (i *Injector) AdminHandler(w http.ResponseWriter, r *http.Request) {
+	if i == nil {
+		http.Error(w, "fault injection is not enabled", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(i.GetConfig())
+		return
+	}
+
+	if r.Method == "POST" {
+		var req struct {
+			Config
+			Profile string `json:"profile"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		config := req.Config
+		if req.Profile != "" {
+			profileConfig, err := ProfileConfig(req.Profile)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			config = profileConfig
+		}
+
+		if err := i.SetConfig(config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(i.GetConfig())
+		return
+	}
+
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,189 @@
+package frontend
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// Read-modify-write retry settings
+const (
+	updateAttempts = 10                   // Reads of a key written concurrently before giving up
+	updateBackoff  = 5 * time.Millisecond // Upper bound of the random wait before a retry, per attempt
+)
+
+// Errors returned by backends and updates
+var (
+	// ErrReadOnly is returned by backends that do not accept writes on this
+	// node (Leader-Follower followers)
+	ErrReadOnly = errors.New("writes are only accepted by the leader")
+	// ErrCausal is returned by backends whose keys are versioned with
+	// vector clocks (Leaderless vclock conflict resolution)
+	ErrCausal = errors.New("the protocol frontends need last-writer-wins conflict resolution")
+	// ErrContention is returned when an update kept losing to concurrent
+	// writes of the key
+	ErrContention = errors.New("key is being written concurrently, try again")
+)
+
+// Backend is the key-value API the protocol frontends (RESP, memcached)
+// expose, implemented by each replication mode on top of its
+// ReplicationManager so that they get the same consistency as the HTTP API
+// A failed precondition returns rest.ErrPreconditionFailed.
+type Backend interface {
+	// Get reads a key, returning nil if it does not exist
+	Get(key string) (*kvstore.KeyValue, error)
+	// Set writes a key and returns the version written
+	Set(key string, value []byte, preconditions rest.Preconditions) (int64, error)
+	// Delete deletes a key
+	Delete(key string, preconditions rest.Preconditions) error
+}
+
+// Public wraps a backend for a frontend serving clients, rejecting
+// reserved keys (kvstore.CheckKey) before they reach the replication mode
+// The namespace layer uses the unwrapped backend to store its keys.
+func Public(backend Backend) Backend {
+	return publicBackend{backend}
+}
+
+// publicBackend is a Backend that only accepts client keys
+type publicBackend struct {
+	backend Backend
+}
+
+func (b publicBackend) Get(key string) (*kvstore.KeyValue, error) {
+	if err := kvstore.CheckKey(key); err != nil {
+		return nil, err
+	}
+	return b.backend.Get(key)
+}
+
+func (b publicBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	if err := kvstore.CheckKey(key); err != nil {
+		return 0, err
+	}
+	return b.backend.Set(key, value, preconditions)
+}
+
+func (b publicBackend) Delete(key string, preconditions rest.Preconditions) error {
+	if err := kvstore.CheckKey(key); err != nil {
+		return err
+	}
+	return b.backend.Delete(key, preconditions)
+}
+
+// Update replaces a key's value with update(current), where current is nil
+// if the key does not exist
+// The new value is written only if the key was not changed since it was
+// read, retrying otherwise, so concurrent updates are not lost. Returns the
+// value read and the value written; errors from update are returned as is.
+func Update(backend Backend, key string, update func(current *kvstore.KeyValue) ([]byte, error)) (*kvstore.KeyValue, *kvstore.KeyValue, error) {
+	for attempt := 1; attempt <= updateAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(rand.Int63n(int64(updateBackoff) * int64(attempt))))
+		}
+
+		current, err := backend.Get(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := update(current)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		preconditions := rest.Preconditions{IfNoneMatch: "*"}
+		if current != nil {
+			preconditions = rest.Preconditions{IfMatch: rest.ETag(current.Version)}
+		}
+		version, err := backend.Set(key, value, preconditions)
+		if errors.Is(err, rest.ErrPreconditionFailed) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return current, &kvstore.KeyValue{Key: key, Value: value, Version: version}, nil
+	}
+	return nil, nil, ErrContention
+}
+
+// Expiries deletes keys once their timeout passes
+// A timeout deletes the key only while the version it was set on is
+// current, so any later write of the key (through any node or API) clears
+// it. Timeouts are kept in memory by the node that received them.
+type Expiries struct {
+	backend Backend
+	mu      sync.Mutex
+	keys    map[string]*expiry
+}
+
+// expiry is a pending timeout of a key
+type expiry struct {
+	version int64
+	timer   *time.Timer
+}
+
+// NewExpiries creates the timeouts of keys in a backend
+func NewExpiries(backend Backend) *Expiries {
+	return &Expiries{
+		backend: backend,
+		keys:    make(map[string]*expiry),
+	}
+}
+
+// Set gives a key a timeout from now, replacing any earlier one
+// version is the key's current version; ttl <= 0 only clears the timeout.
+func (e *Expiries) Set(key string, version int64, ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if x, ok := e.keys[key]; ok {
+		x.timer.Stop()
+		delete(e.keys, key)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	x := &expiry{version: version}
+	x.timer = time.AfterFunc(ttl, func() { e.expire(key, x) })
+	e.keys[key] = x
+}
+
+// Clear removes a key's timeout
+func (e *Expiries) Clear(key string) {
+	e.Set(key, 0, 0)
+}
+
+// Move keeps a key's timeout across a write that should not clear it (an
+// increment)
+func (e *Expiries) Move(key string, from, to int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if x, ok := e.keys[key]; ok && x.version == from {
+		x.version = to
+	}
+}
+
+// expire deletes a key whose timeout passed, unless it was written since
+func (e *Expiries) expire(key string, x *expiry) {
+	e.mu.Lock()
+	if e.keys[key] != x {
+		e.mu.Unlock()
+		return
+	}
+	delete(e.keys, key)
+	version := x.version
+	e.mu.Unlock()
+
+	err := e.backend.Delete(key, rest.Preconditions{IfMatch: rest.ETag(version)})
+	if err != nil && !errors.Is(err, rest.ErrPreconditionFailed) {
+		log.Printf("Failed to expire key %s: %v", key, err)
+	}
+}
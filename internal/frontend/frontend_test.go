@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// storeBackend is a Backend writing straight to a store
+type storeBackend struct {
+	store *kvstore.Store
+}
+
+func (b storeBackend) Get(key string) (*kvstore.KeyValue, error) {
+	kv, _ := b.store.LocalRead(key)
+	return kv, nil
+}
+
+func (b storeBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	return b.store.SetIf(key, value, preconditions.Condition())
+}
+
+func (b storeBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.store.DeleteIf(key, preconditions.Condition())
+	return err
+}
+
+func TestPublicRejectsReservedKeys(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr error
+	}{
+		{key: "user:1"},
+		{key: "a\x00b"},
+		{key: "", wantErr: kvstore.ErrEmptyKey},
+		{key: "\x00namespaces", wantErr: kvstore.ErrReservedKey},
+		{key: "\x00team\x00user:1", wantErr: kvstore.ErrReservedKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			store := kvstore.NewStore()
+			internal := storeBackend{store}
+			public := Public(internal)
+
+			_, err := public.Set(tt.key, []byte("v"), rest.Preconditions{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Set: got %v, want %v", err, tt.wantErr)
+			}
+			if _, err := public.Get(tt.key); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get: got %v, want %v", err, tt.wantErr)
+			}
+			if err := public.Delete(tt.key, rest.Preconditions{}); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != kvstore.ErrReservedKey {
+				return
+			}
+
+			// The namespace layer still writes reserved keys through the
+			// unwrapped backend
+			if _, err := internal.Set(tt.key, []byte("v"), rest.Preconditions{}); err != nil {
+				t.Fatalf("internal Set: %v", err)
+			}
+			if _, err := public.Get(tt.key); !errors.Is(err, kvstore.ErrReservedKey) {
+				t.Fatalf("Get after internal Set: got %v, want %v", err, kvstore.ErrReservedKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package gossip
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+)
+
+// Default failure detector settings
+const (
+	DefaultProbeInterval    = 1 * time.Second        // Time between probes of successive peers
+	DefaultProbeTimeout     = 300 * time.Millisecond // Time to wait for a direct ack
+	DefaultSuspicionTimeout = 5 * time.Second        // Time a suspect has to refute before it is declared dead
+	indirectProbes          = 3                      // Peers asked to probe a target that missed a direct ack
+	maxPiggyback            = 16                     // Updates carried per message
+)
+
+// State is the state of a member as seen by this node
+type State string
+
+const (
+	StateAlive   State = "alive"
+	StateSuspect State = "suspect"
+	StateDead    State = "dead"
+)
+
+// ErrDead is returned for requests to a peer the detector considers dead
+var ErrDead = errors.New("gossip: peer is dead")
+
+// Member is a peer as seen by this node
+type Member struct {
+	Addr        string    `json:"addr"`
+	State       State     `json:"state"`
+	Incarnation uint64    `json:"incarnation"`
+	LastChange  time.Time `json:"last_change"`
+}
+
+// Update is membership news piggybacked on protocol messages
+type Update struct {
+	Addr        string `json:"addr"`
+	State       State  `json:"state"`
+	Incarnation uint64 `json:"incarnation"`
+}
+
+// Ping is a direct probe
+type Ping struct {
+	From    string   `json:"from"`
+	Updates []Update `json:"updates,omitempty"`
+}
+
+// PingReq asks a peer to probe a target on this node's behalf
+type PingReq struct {
+	From    string   `json:"from"`
+	Target  string   `json:"target"`
+	Updates []Update `json:"updates,omitempty"`
+}
+
+// Ack answers a Ping or PingReq
+// For a PingReq, Acked reports whether the target answered.
+type Ack struct {
+	From    string   `json:"from"`
+	Acked   bool     `json:"acked"`
+	Updates []Update `json:"updates,omitempty"`
+}
+
+// broadcast is an update waiting to be piggybacked
+type broadcast struct {
+	update    Update
+	transmits int
+}
+
+// Detector is a SWIM-style membership and failure detector
+// Every probe interval it pings the next peer (round-robin). A peer that
+// does not ack in time is probed indirectly through other peers; if that
+// fails too it becomes suspect, and a suspect that does not refute the
+// suspicion (by gossiping a higher incarnation) within the suspicion
+// timeout is declared dead. State changes are disseminated by piggybacking
+// them on pings and acks.
+type Detector struct {
+	self             string
+	faults           *fault.Injector
+	interval         time.Duration
+	probeTimeout     time.Duration
+	suspicionTimeout time.Duration
+	probeClient      *http.Client
+	indirectClient   *http.Client
+
+	mu          sync.Mutex
+	incarnation uint64
+	members     map[string]*Member
+	order       []string // Probe order, reshuffled after every pass
+	next        int
+	broadcasts  map[string]*broadcast // Keyed by member address
+}
+
+// NewDetector creates a failure detector for this node (self) and its peers
+// An interval of 0 or less disables probing; every peer then stays alive.
+// A nil *Detector is valid and considers every peer alive.
+func NewDetector(self string, peers []string, faults *fault.Injector, interval, probeTimeout, suspicionTimeout time.Duration) *Detector {
+	if probeTimeout <= 0 || (interval > 0 && probeTimeout >= interval) {
+		probeTimeout = interval / 3
+	}
+	if suspicionTimeout <= 0 {
+		suspicionTimeout = DefaultSuspicionTimeout
+	}
+	d := &Detector{
+		self:             self,
+		faults:           faults,
+		interval:         interval,
+		probeTimeout:     probeTimeout,
+		suspicionTimeout: suspicionTimeout,
+		probeClient:      &http.Client{Timeout: probeTimeout},
+		// An indirect probe waits for the helper's own direct probe
+		indirectClient: &http.Client{Timeout: interval - probeTimeout},
+		members:        make(map[string]*Member),
+		broadcasts:     make(map[string]*broadcast),
+	}
+	now := time.Now().UTC()
+	for _, addr := range peers {
+		if addr != self {
+			d.members[addr] = &Member{Addr: addr, State: StateAlive, LastChange: now}
+		}
+	}
+	return d
+}
+
+// Start runs the protocol in the background
+func (d *Detector) Start() {
+	if d == nil || d.interval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(d.interval)
+			if target := d.nextTarget(); target != "" {
+				d.probe(target)
+			}
+			d.expireSuspects()
+		}
+	}()
+}
+
+// IsAlive returns false only for peers considered dead
+// Suspects are still contacted: they may just be slow.
+func (d *Detector) IsAlive(addr string) bool {
+	return d.State(addr) != StateDead
+}
+
+// Check returns ErrDead for a peer considered dead
+func (d *Detector) Check(addr string) error {
+	if !d.IsAlive(addr) {
+		return fmt.Errorf("%w: %s", ErrDead, addr)
+	}
+	return nil
+}
+
+// SetPeers adds peers that are not yet members (as alive) and forgets
+// members that are no longer peers
+func (d *Detector) SetPeers(peers []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keep := make(map[string]bool, len(peers))
+	now := time.Now().UTC()
+	for _, addr := range peers {
+		if addr == d.self {
+			continue
+		}
+		keep[addr] = true
+		if _, ok := d.members[addr]; !ok {
+			d.members[addr] = &Member{Addr: addr, State: StateAlive, LastChange: now}
+		}
+	}
+	for addr := range d.members {
+		if !keep[addr] {
+			delete(d.members, addr)
+			delete(d.broadcasts, addr)
+		}
+	}
+}
+
+// State returns the state of a peer (alive for unknown peers)
+func (d *Detector) State(addr string) State {
+	if d == nil {
+		return StateAlive
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if m, ok := d.members[addr]; ok {
+		return m.State
+	}
+	return StateAlive
+}
+
+// Members returns this node's view of its peers, sorted by address
+func (d *Detector) Members() []Member {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	members := make([]Member, 0, len(d.members))
+	for _, m := range d.members {
+		members = append(members, *m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Addr < members[j].Addr
+	})
+	return members
+}
+
+// Incarnation returns this node's incarnation number
+func (d *Detector) Incarnation() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.incarnation
+}
+
+// nextTarget returns the next peer to probe
+// Every peer (dead ones included, so that they can rejoin) is probed once
+// per pass, in an order reshuffled after every pass.
+func (d *Detector) nextTarget() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.next >= len(d.order) {
+		d.order = d.order[:0]
+		for addr := range d.members {
+			d.order = append(d.order, addr)
+		}
+		rand.Shuffle(len(d.order), func(i, j int) {
+			d.order[i], d.order[j] = d.order[j], d.order[i]
+		})
+		d.next = 0
+	}
+	if len(d.order) == 0 {
+		return ""
+	}
+	target := d.order[d.next]
+	d.next++
+	return target
+}
+
+// probe pings a target directly, then indirectly, and suspects it if
+// neither gets an ack
+func (d *Detector) probe(target string) {
+	if d.ping(d.probeClient, target) {
+		return
+	}
+
+	helpers := d.helpers(target)
+	acked := make(chan bool, len(helpers))
+	for _, helper := range helpers {
+		go func(helper string) {
+			var ack Ack
+			err := d.post(d.indirectClient, helper, "/internal/gossip/ping_req", PingReq{
+				From:    d.self,
+				Target:  target,
+				Updates: d.piggyback(helper),
+			}, &ack)
+			acked <- err == nil && ack.Acked
+		}(helper)
+	}
+	for range helpers {
+		if <-acked {
+			return
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if m, ok := d.members[target]; ok && m.State == StateAlive {
+		d.apply(Update{Addr: target, State: StateSuspect, Incarnation: m.Incarnation})
+	}
+}
+
+// ping sends a direct probe and returns true if the target acked
+func (d *Detector) ping(client *http.Client, target string) bool {
+	var ack Ack
+	err := d.post(client, target, "/internal/gossip/ping", Ping{
+		From:    d.self,
+		Updates: d.piggyback(target),
+	}, &ack)
+	return err == nil
+}
+
+// helpers picks up to indirectProbes live peers other than the target
+func (d *Detector) helpers(target string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var candidates []string
+	for addr, m := range d.members {
+		if addr != target && m.State == StateAlive {
+			candidates = append(candidates, addr)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > indirectProbes {
+		candidates = candidates[:indirectProbes]
+	}
+	return candidates
+}
+
+// expireSuspects declares suspects dead once the suspicion timeout passes
+func (d *Detector) expireSuspects() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, m := range d.members {
+		if m.State == StateSuspect && time.Since(m.LastChange) >= d.suspicionTimeout {
+			d.apply(Update{Addr: m.Addr, State: StateDead, Incarnation: m.Incarnation})
+		}
+	}
+}
+
+// Receive applies updates piggybacked on a message
+func (d *Detector) Receive(updates []Update) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, update := range updates {
+		d.apply(update)
+	}
+}
+
+// apply merges one update into the local view and queues it for
+// dissemination if it changed anything (caller holds d.mu)
+// Higher incarnations win; at the same incarnation dead overrides suspect,
+// which overrides alive. News that this node is suspect or dead is refuted
+// by gossiping alive with a higher incarnation.
+func (d *Detector) apply(update Update) {
+	if update.Addr == d.self {
+		if update.State != StateAlive && update.Incarnation >= d.incarnation {
+			d.incarnation = update.Incarnation + 1
+			d.queue(Update{Addr: d.self, State: StateAlive, Incarnation: d.incarnation})
+			log.Printf("Gossip: refuting %s with incarnation %d", update.State, d.incarnation)
+		}
+		return
+	}
+
+	m, known := d.members[update.Addr]
+	if known && !supersedes(update, m) {
+		return
+	}
+	if !known {
+		m = &Member{Addr: update.Addr}
+		d.members[update.Addr] = m
+	}
+	if m.State != update.State {
+		log.Printf("Gossip: %s is %s (incarnation %d)", update.Addr, update.State, update.Incarnation)
+	}
+	m.State = update.State
+	m.Incarnation = update.Incarnation
+	m.LastChange = time.Now().UTC()
+	d.queue(update)
+}
+
+// supersedes returns true if an update is newer than a member's state
+func supersedes(update Update, m *Member) bool {
+	if update.Incarnation != m.Incarnation {
+		return update.Incarnation > m.Incarnation
+	}
+	return rank(update.State) > rank(m.State)
+}
+
+// rank orders states at the same incarnation
+func rank(state State) int {
+	switch state {
+	case StateSuspect:
+		return 1
+	case StateDead:
+		return 2
+	}
+	return 0
+}
+
+// queue schedules an update for dissemination (caller holds d.mu)
+func (d *Detector) queue(update Update) {
+	d.broadcasts[update.Addr] = &broadcast{update: update}
+}
+
+// piggyback returns the updates to carry on a message to a peer: the
+// peer's own state if it is not alive (so that it can refute), then the
+// least transmitted updates
+// Each update is sent about 3*log2(N) times before it is dropped.
+func (d *Detector) piggyback(to string) []Update {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var updates []Update
+	if m, ok := d.members[to]; ok && m.State != StateAlive {
+		updates = append(updates, Update{Addr: to, State: m.State, Incarnation: m.Incarnation})
+	}
+
+	pending := make([]*broadcast, 0, len(d.broadcasts))
+	for _, b := range d.broadcasts {
+		pending = append(pending, b)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].transmits < pending[j].transmits
+	})
+
+	limit := 3 * int(math.Ceil(math.Log2(float64(len(d.members)+2))))
+	for _, b := range pending {
+		if len(updates) == maxPiggyback {
+			break
+		}
+		updates = append(updates, b.update)
+		b.transmits++
+		if b.transmits >= limit {
+			delete(d.broadcasts, b.update.Addr)
+		}
+	}
+	return updates
+}
+
+// post sends a protocol message to a peer through the fault injector and
+// decodes the ack into out
+func (d *Detector) post(client *http.Client, peer string, path string, in interface{}, out *Ack) error {
+	jsonData, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("http://%s%s", peer, path)
+	resp, err := d.faults.Do(client, peer, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	d.Receive(out.Updates)
+	return nil
+}
@@ -0,0 +1,207 @@
+package gossip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+)
+
+func TestApplyUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		member     Member // State of peer b before the update
+		update     Update
+		wantState  State
+		wantInc    uint64
+		wantQueued bool
+	}{
+		{
+			name:      "higher incarnation wins",
+			member:    Member{State: StateSuspect, Incarnation: 1},
+			update:    Update{State: StateAlive, Incarnation: 2},
+			wantState: StateAlive, wantInc: 2, wantQueued: true,
+		},
+		{
+			name:      "lower incarnation is ignored",
+			member:    Member{State: StateAlive, Incarnation: 3},
+			update:    Update{State: StateDead, Incarnation: 2},
+			wantState: StateAlive, wantInc: 3,
+		},
+		{
+			name:      "suspect overrides alive at the same incarnation",
+			member:    Member{State: StateAlive, Incarnation: 1},
+			update:    Update{State: StateSuspect, Incarnation: 1},
+			wantState: StateSuspect, wantInc: 1, wantQueued: true,
+		},
+		{
+			name:      "dead overrides suspect at the same incarnation",
+			member:    Member{State: StateSuspect, Incarnation: 1},
+			update:    Update{State: StateDead, Incarnation: 1},
+			wantState: StateDead, wantInc: 1, wantQueued: true,
+		},
+		{
+			name:      "alive does not override suspect at the same incarnation",
+			member:    Member{State: StateSuspect, Incarnation: 1},
+			update:    Update{State: StateAlive, Incarnation: 1},
+			wantState: StateSuspect, wantInc: 1,
+		},
+		{
+			name:      "same state is not gossiped again",
+			member:    Member{State: StateDead, Incarnation: 1},
+			update:    Update{State: StateDead, Incarnation: 1},
+			wantState: StateDead, wantInc: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDetector(t, "a", []string{"a", "b"}, 0)
+			tt.member.Addr = "b"
+			*d.members["b"] = tt.member
+			tt.update.Addr = "b"
+
+			d.Receive([]Update{tt.update})
+			m := d.members["b"]
+			if m.State != tt.wantState || m.Incarnation != tt.wantInc {
+				t.Fatalf("got %s at incarnation %d, want %s at %d", m.State, m.Incarnation, tt.wantState, tt.wantInc)
+			}
+			if _, queued := d.broadcasts["b"]; queued != tt.wantQueued {
+				t.Fatalf("got queued %v, want %v", queued, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestRefuteSuspicion(t *testing.T) {
+	tests := []struct {
+		name        string
+		incarnation uint64 // This node's incarnation before the update
+		update      Update
+		want        uint64 // 0 when the update is not refuted
+	}{
+		{name: "suspect at the current incarnation", update: Update{State: StateSuspect}, want: 1},
+		{name: "dead at a later incarnation", incarnation: 1, update: Update{State: StateDead, Incarnation: 3}, want: 4},
+		{name: "suspect at an older incarnation", incarnation: 5, update: Update{State: StateSuspect, Incarnation: 2}},
+		{name: "alive", update: Update{State: StateAlive, Incarnation: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDetector(t, "a", []string{"a", "b"}, 0)
+			d.incarnation = tt.incarnation
+			tt.update.Addr = "a"
+
+			d.Receive([]Update{tt.update})
+			if tt.want == 0 {
+				if d.Incarnation() != tt.incarnation || len(d.broadcasts) != 0 {
+					t.Fatalf("got incarnation %d and %d updates queued, want %d and none", d.Incarnation(), len(d.broadcasts), tt.incarnation)
+				}
+				return
+			}
+			b, queued := d.broadcasts["a"]
+			if d.Incarnation() != tt.want || !queued || b.update.State != StateAlive || b.update.Incarnation != tt.want {
+				t.Fatalf("got incarnation %d and queued %+v, want alive at %d", d.Incarnation(), b, tt.want)
+			}
+			if _, ok := d.members["a"]; ok {
+				t.Fatal("this node became its own member")
+			}
+		})
+	}
+}
+
+func TestPiggyback(t *testing.T) {
+	d := newTestDetector(t, "a", []string{"a", "b", "c"}, 0)
+	d.Receive([]Update{{Addr: "b", State: StateSuspect}})
+
+	// b hears of its own suspicion first, on every message, so that it can refute
+	limit := 6 // 3*log2(2+2) for two peers
+	for i := 0; i < limit+2; i++ {
+		updates := d.piggyback("b")
+		if len(updates) == 0 || updates[0] != (Update{Addr: "b", State: StateSuspect}) {
+			t.Fatalf("message %d carries %v, want b's suspicion first", i, updates)
+		}
+	}
+
+	// Other peers hear of it until it has been sent limit times
+	d2 := newTestDetector(t, "a", []string{"a", "b", "c"}, 0)
+	d2.Receive([]Update{{Addr: "b", State: StateSuspect}})
+	for i := 0; i < limit; i++ {
+		if updates := d2.piggyback("c"); len(updates) != 1 {
+			t.Fatalf("message %d carries %v, want b's suspicion", i, updates)
+		}
+	}
+	if updates := d2.piggyback("c"); len(updates) != 0 {
+		t.Fatalf("got %v after %d transmits, want nothing", updates, limit)
+	}
+}
+
+func TestProbeSuspectsThenDeclaresDead(t *testing.T) {
+	// A live helper that probes on this node's behalf; 127.0.0.1:1 is unreachable
+	helper := newTestDetector(t, "", nil, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/ping_req") {
+			helper.PingReqHandler(w, r)
+			return
+		}
+		helper.PingHandler(w, r)
+	}))
+	defer server.Close()
+	live := strings.TrimPrefix(server.URL, "http://")
+	helper.self = live
+	helper.probeClient.Timeout = time.Second
+
+	const down = "127.0.0.1:1"
+	d := newTestDetector(t, "a", []string{"a", live, down}, 50*time.Millisecond)
+	d.probeClient.Timeout = time.Second
+	d.indirectClient.Timeout = 2 * time.Second
+
+	d.probe(live)
+	if got := d.State(live); got != StateAlive {
+		t.Fatalf("live peer is %s after a probe", got)
+	}
+
+	d.probe(down)
+	if got := d.State(down); got != StateSuspect {
+		t.Fatalf("unreachable peer is %s after a probe, want %s", got, StateSuspect)
+	}
+	if !d.IsAlive(down) {
+		t.Fatal("suspect is not contacted")
+	}
+
+	// The suspicion reaches the helper on the next message
+	d.probe(live)
+	if got := helper.State(down); got != StateSuspect {
+		t.Fatalf("helper sees the unreachable peer as %s, want %s", got, StateSuspect)
+	}
+
+	// Suspects are declared dead only after the suspicion timeout
+	d.expireSuspects()
+	if got := d.State(down); got != StateSuspect {
+		t.Fatalf("got %s before the suspicion timeout, want %s", got, StateSuspect)
+	}
+	time.Sleep(60 * time.Millisecond)
+	d.expireSuspects()
+	if d.IsAlive(down) || d.Check(down) == nil {
+		t.Fatalf("got %s after the suspicion timeout, want %s", d.State(down), StateDead)
+	}
+}
+
+func TestNilDetector(t *testing.T) {
+	var d *Detector
+	if !d.IsAlive("b") || d.Check("b") != nil || d.Members() != nil {
+		t.Fatal("a nil detector must consider every peer alive")
+	}
+}
+
+// newTestDetector creates a detector that does not probe on its own
+func newTestDetector(t *testing.T, self string, peers []string, suspicionTimeout time.Duration) *Detector {
+	faults, err := fault.NewInjectorFromProfile("none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDetector(self, peers, faults, 0, 0, suspicionTimeout)
+}
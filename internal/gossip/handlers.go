@@ -0,0 +1,57 @@
+package gossip
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// PingHandler answers a direct probe
+func (d *Detector) PingHandler(w http.ResponseWriter, r *http.Request) {
+	var req Ping
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	d.Receive(req.Updates)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Ack{
+		From:    d.self,
+		Acked:   true,
+		Updates: d.piggyback(req.From),
+	})
+}
+
+// PingReqHandler probes a target on behalf of another node
+func (d *Detector) PingReqHandler(w http.ResponseWriter, r *http.Request) {
+	var req PingReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	d.Receive(req.Updates)
+
+	acked := d.ping(d.probeClient, req.Target)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Ack{
+		From:    d.self,
+		Acked:   acked,
+		Updates: d.piggyback(req.From),
+	})
+}
+
+// MembersHandler reports this node's view of its peers
+func (d *Detector) MembersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"self":              d.self,
+		"incarnation":       d.Incarnation(),
+		"probe_interval":    d.interval.String(),
+		"suspicion_timeout": d.suspicionTimeout.String(),
+		"members":           d.Members(),
+	})
+}
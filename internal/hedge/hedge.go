@@ -0,0 +1,145 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Default hedging settings
+const (
+	DefaultPercentile = 95                    // Hedge after the observed p95 latency
+	DefaultDelay      = 20 * time.Millisecond // Hedge delay until enough latencies are observed
+)
+
+// Request sends one request to a peer
+// It must return promptly once ctx is cancelled.
+type Request func(ctx context.Context, peer string) (interface{}, error)
+
+// Reply is a successful response from a peer
+type Reply struct {
+	Peer  string
+	Value interface{}
+}
+
+// Hedger sends requests to the fastest peers first and speculatively to
+// more peers when replies are slow
+type Hedger struct {
+	tracker    *Tracker
+	delay      time.Duration // Fixed hedge delay (0 = the observed percentile)
+	percentile float64
+}
+
+// NewHedger creates a hedger
+// A delay of 0 hedges after the given percentile of the observed latencies.
+func NewHedger(delay time.Duration, percentile float64) *Hedger {
+	if percentile <= 0 || percentile > 100 {
+		percentile = DefaultPercentile
+	}
+	return &Hedger{
+		tracker:    NewTracker(),
+		delay:      delay,
+		percentile: percentile,
+	}
+}
+
+// Delay returns how long to wait for replies from some peers before
+// sending a speculative request
+func (h *Hedger) Delay(peers []string) time.Duration {
+	if h.delay > 0 {
+		return h.delay
+	}
+	if delay, ok := h.tracker.Percentile(peers, h.percentile); ok {
+		return delay
+	}
+	return DefaultDelay
+}
+
+// Do sends a request to the `needed` fastest peers and returns the first
+// `needed` successful replies, cancelling the requests still in flight
+// Whenever the hedge delay passes without enough replies, one more peer is
+// tried; a failed request is replaced by the next peer right away. Returns
+// fewer replies if the peers run out.
+func (h *Hedger) Do(peers []string, needed int, request Request) []Reply {
+	if needed <= 0 {
+		return nil
+	}
+	ranked := h.tracker.Rank(peers)
+	delay := h.Delay(ranked[:min(needed, len(ranked))])
+
+	type result struct {
+		peer    string
+		value   interface{}
+		err     error
+		latency time.Duration
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan result, len(ranked))
+
+	next := 0
+	send := func() {
+		peer := ranked[next]
+		next++
+		go func() {
+			start := time.Now()
+			value, err := request(ctx, peer)
+			results <- result{peer: peer, value: value, err: err, latency: time.Since(start)}
+		}()
+	}
+	for next < len(ranked) && next < needed {
+		send()
+	}
+	inFlight := next
+	hedged := 0
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var replies []Reply
+	for len(replies) < needed && inFlight > 0 {
+		select {
+		case r := <-results:
+			inFlight--
+			if r.err == nil {
+				h.tracker.Record(r.peer, r.latency)
+				replies = append(replies, Reply{Peer: r.peer, Value: r.value})
+				continue
+			}
+			if next < len(ranked) {
+				send()
+				inFlight++
+			}
+		case <-timer.C:
+			if next < len(ranked) {
+				send()
+				inFlight++
+				hedged++
+				timer.Reset(delay)
+			}
+		}
+	}
+	h.tracker.count(next, hedged, inFlight)
+
+	// Cancel the stragglers; the time they took before being cancelled is
+	// only a lower bound on their latency
+	cancel()
+	go func(pending int) {
+		for i := 0; i < pending; i++ {
+			r := <-results
+			switch {
+			case r.err == nil:
+				h.tracker.Record(r.peer, r.latency)
+			case errors.Is(r.err, context.Canceled):
+				h.tracker.RecordLowerBound(r.peer, r.latency)
+			}
+		}
+	}(inFlight)
+
+	return replies
+}
+
+// Stats returns the observed latencies and hedging counters
+func (h *Hedger) Stats() Stats {
+	return h.tracker.Stats()
+}
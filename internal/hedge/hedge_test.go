@@ -0,0 +1,71 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHedgerDo(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	slow := 10 * time.Second
+
+	tests := []struct {
+		name         string
+		latency      map[string]time.Duration // Response time per peer (fast when unset)
+		failing      map[string]bool
+		needed       int
+		want         []string // Peers that replied
+		wantHedged   int64
+		wantRequests int64
+	}{
+		{name: "fast peers", needed: 2, want: []string{"a", "b"}, wantRequests: 2},
+		{name: "slow peer is hedged", latency: map[string]time.Duration{"a": slow}, needed: 2, want: []string{"b", "c"}, wantHedged: 1, wantRequests: 3},
+		{name: "failed peer is replaced", failing: map[string]bool{"a": true}, needed: 2, want: []string{"b", "c"}, wantRequests: 3},
+		{name: "peers run out", failing: map[string]bool{"a": true, "b": true}, needed: 2, want: []string{"c"}, wantRequests: 3},
+		{name: "nothing needed", needed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHedger(delay, 0)
+			start := time.Now()
+			replies := h.Do([]string{"a", "b", "c"}, tt.needed, func(ctx context.Context, peer string) (interface{}, error) {
+				if tt.failing[peer] {
+					return nil, errors.New("unavailable")
+				}
+				select {
+				case <-time.After(tt.latency[peer]):
+					return peer, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			})
+			if elapsed := time.Since(start); elapsed > slow/2 {
+				t.Fatalf("took %v, slow peers were waited for", elapsed)
+			}
+
+			var got []string
+			for _, reply := range replies {
+				if reply.Value != reply.Peer {
+					t.Fatalf("got %v from %s", reply.Value, reply.Peer)
+				}
+				got = append(got, reply.Peer)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got replies from %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got replies from %v, want %v", got, tt.want)
+				}
+			}
+			if stats := h.Stats(); stats.Hedged != tt.wantHedged || stats.Requests != tt.wantRequests {
+				t.Fatalf("got %d requests and %d hedged, want %d and %d", stats.Requests, stats.Hedged, tt.wantRequests, tt.wantHedged)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package hedge
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Latency tracking settings
+const (
+	windowSize = 128 // Latest samples kept per peer
+	minSamples = 5   // Peers with fewer samples are tried first (so they get measured)
+)
+
+// PeerStats summarizes the observed latencies of one peer
+type PeerStats struct {
+	Samples int     `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+}
+
+// Stats reports the latency tracker and hedging counters
+type Stats struct {
+	Peers     map[string]PeerStats `json:"peers"`
+	Requests  int64                `json:"requests"`  // Requests sent
+	Hedged    int64                `json:"hedged"`    // Speculative requests sent after the hedge delay
+	Cancelled int64                `json:"cancelled"` // Requests cancelled once enough replies arrived
+}
+
+// Tracker records the response latency of each peer over a sliding window
+// A request cancelled after d only shows that the peer is slower than d, so
+// it is recorded only if it raises the peer's estimate (RecordLowerBound).
+type Tracker struct {
+	mu        sync.Mutex
+	samples   map[string]*window
+	requests  int64
+	hedged    int64
+	cancelled int64
+}
+
+// window is a ring buffer of latency samples
+type window struct {
+	values []time.Duration
+	next   int
+}
+
+// NewTracker creates an empty latency tracker
+func NewTracker() *Tracker {
+	return &Tracker{samples: make(map[string]*window)}
+}
+
+// Record adds a latency sample for a peer
+func (t *Tracker) Record(peer string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.samples[peer]
+	if !ok {
+		w = &window{}
+		t.samples[peer] = w
+	}
+	if len(w.values) < windowSize {
+		w.values = append(w.values, latency)
+		return
+	}
+	w.values[w.next] = latency
+	w.next = (w.next + 1) % windowSize
+}
+
+// RecordLowerBound records a request cancelled after latency, which would
+// have taken at least that long
+// The sample is kept only if it is above the peer's median latency:
+// recording a shorter one would make the peer look faster than it is.
+// Peers without samples are left unmeasured, so Rank still tries them first.
+func (t *Tracker) RecordLowerBound(peer string, latency time.Duration) {
+	t.mu.Lock()
+	w, ok := t.samples[peer]
+	raises := ok && len(w.values) > 0 && latency > percentile(w.values, 50)
+	t.mu.Unlock()
+
+	if raises {
+		t.Record(peer, latency)
+	}
+}
+
+// Rank orders peers from fastest to slowest by median latency
+// Peers with too few samples come first, in their original order.
+func (t *Tracker) Rank(peers []string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ranked := append([]string{}, peers...)
+	median := make(map[string]time.Duration, len(peers))
+	for _, peer := range peers {
+		if w, ok := t.samples[peer]; ok && len(w.values) >= minSamples {
+			median[peer] = percentile(w.values, 50)
+		} else {
+			median[peer] = -1
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return median[ranked[i]] < median[ranked[j]]
+	})
+	return ranked
+}
+
+// Percentile returns the p-th percentile latency over the samples of the
+// given peers, or false if there are too few samples
+func (t *Tracker) Percentile(peers []string, p float64) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var values []time.Duration
+	for _, peer := range peers {
+		if w, ok := t.samples[peer]; ok {
+			values = append(values, w.values...)
+		}
+	}
+	if len(values) < minSamples {
+		return 0, false
+	}
+	return percentile(values, p), true
+}
+
+// count adds to the request counters
+func (t *Tracker) count(requests, hedged, cancelled int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests += int64(requests)
+	t.hedged += int64(hedged)
+	t.cancelled += int64(cancelled)
+}
+
+// Stats returns the observed latencies and hedging counters
+func (t *Tracker) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := Stats{
+		Peers:     make(map[string]PeerStats, len(t.samples)),
+		Requests:  t.requests,
+		Hedged:    t.hedged,
+		Cancelled: t.cancelled,
+	}
+	for peer, w := range t.samples {
+		stats.Peers[peer] = PeerStats{
+			Samples: len(w.values),
+			P50Ms:   milliseconds(percentile(w.values, 50)),
+			P95Ms:   milliseconds(percentile(w.values, 95)),
+			P99Ms:   milliseconds(percentile(w.values, 99)),
+		}
+	}
+	return stats
+}
+
+// percentile returns the p-th percentile (nearest rank) of some samples
+func percentile(values []time.Duration, p float64) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(p/100*float64(len(sorted))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
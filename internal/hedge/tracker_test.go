@@ -0,0 +1,140 @@
+package hedge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples map[string][]time.Duration
+		want    []string
+	}{
+		{name: "no samples keeps the order", want: []string{"a", "b", "c"}},
+		{
+			name: "fastest median first",
+			samples: map[string][]time.Duration{
+				"a": {30, 30, 30, 30, 30},
+				"b": {10, 10, 10, 10, 10},
+				"c": {20, 20, 20, 20, 20},
+			},
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name: "median ignores outliers",
+			samples: map[string][]time.Duration{
+				"a": {1, 1, 1, 1000, 1000},
+				"b": {1000, 1000, 1000, 1, 1},
+				"c": {500, 500, 500, 500, 500},
+			},
+			want: []string{"a", "c", "b"},
+		},
+		{
+			name: "unmeasured peers come first",
+			samples: map[string][]time.Duration{
+				"a": {10, 10, 10, 10, 10},
+				"b": {1, 1},
+				"c": {5, 5, 5, 5, 5},
+			},
+			want: []string{"b", "c", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker()
+			for peer, samples := range tt.samples {
+				for _, sample := range samples {
+					tracker.Record(peer, sample)
+				}
+			}
+			got := tracker.Rank([]string{"a", "b", "c"})
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples map[string][]time.Duration
+		peers   []string
+		p       float64
+		want    time.Duration
+		wantOK  bool
+	}{
+		{name: "too few samples", samples: map[string][]time.Duration{"a": {1, 2, 3, 4}}, peers: []string{"a"}, p: 50},
+		{name: "median", samples: map[string][]time.Duration{"a": {5, 1, 4, 2, 3}}, peers: []string{"a"}, p: 50, want: 3, wantOK: true},
+		{name: "p95 is the slowest of few samples", samples: map[string][]time.Duration{"a": {5, 1, 4, 2, 3}}, peers: []string{"a"}, p: 95, want: 5, wantOK: true},
+		{
+			name:    "samples of the given peers only",
+			samples: map[string][]time.Duration{"a": {1, 2, 3}, "b": {4, 5}, "c": {100, 100, 100}},
+			peers:   []string{"a", "b"},
+			p:       100,
+			want:    5,
+			wantOK:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker()
+			for peer, samples := range tt.samples {
+				for _, sample := range samples {
+					tracker.Record(peer, sample)
+				}
+			}
+			got, ok := tracker.Percentile(tt.peers, tt.p)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRecordKeepsLatestSamples(t *testing.T) {
+	tracker := NewTracker()
+	for i := 0; i < windowSize; i++ {
+		tracker.Record("a", time.Second)
+	}
+	for i := 0; i < windowSize; i++ {
+		tracker.Record("a", time.Millisecond)
+	}
+	stats := tracker.Stats().Peers["a"]
+	if stats.Samples != windowSize || stats.P99Ms != 1 {
+		t.Fatalf("got %+v, want %d samples of 1ms", stats, windowSize)
+	}
+}
+
+func TestRecordLowerBound(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     []time.Duration
+		latency     time.Duration
+		wantSamples int
+	}{
+		{name: "no samples", latency: time.Second, wantSamples: 0},
+		{name: "below the median", samples: []time.Duration{10, 20, 30}, latency: 5, wantSamples: 3},
+		{name: "at the median", samples: []time.Duration{10, 20, 30}, latency: 20, wantSamples: 3},
+		{name: "above the median", samples: []time.Duration{10, 20, 30}, latency: 25, wantSamples: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker()
+			for _, sample := range tt.samples {
+				tracker.Record("a", sample)
+			}
+			tracker.RecordLowerBound("a", tt.latency)
+
+			if got := tracker.Stats().Peers["a"].Samples; got != tt.wantSamples {
+				t.Fatalf("got %d samples, want %d", got, tt.wantSamples)
+			}
+		})
+	}
+}
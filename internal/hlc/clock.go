@@ -0,0 +1,108 @@
+package hlc
+
+import (
+	"sync"
+	"time"
+)
+
+// A timestamp packs a hybrid logical clock reading and the id of the node
+// that issued it into a single int64, so that timestamps can be used
+// anywhere a plain version number is expected and compared with <, > and ==:
+//
+//	| 41 bits physical time (ms since Unix epoch) | 12 bits logical | 10 bits node id |
+//
+// Timestamps from different nodes never collide, and a timestamp issued
+// after a node has seen another timestamp is always greater than it.
+const (
+	nodeBits    = 10
+	logicalBits = 12
+
+	// MaxNodeID is the largest node id that fits in a timestamp
+	MaxNodeID  = 1<<nodeBits - 1
+	maxLogical = 1<<logicalBits - 1
+)
+
+// Clock is a hybrid logical clock
+type Clock struct {
+	mu       sync.Mutex
+	nodeID   int64
+	physical int64 // Highest physical time seen (ms)
+	logical  int64 // Logical counter within physical
+	now      func() int64
+}
+
+// NewClock creates a clock for the given node id (0 to MaxNodeID)
+func NewClock(nodeID int) *Clock {
+	return &Clock{
+		nodeID: int64(nodeID) & MaxNodeID,
+		now:    func() int64 { return time.Now().UnixMilli() },
+	}
+}
+
+// Now returns a new timestamp for a local event (e.g. a write)
+// Every call returns a timestamp greater than all timestamps previously
+// returned or observed by this clock.
+func (c *Clock) Now() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pt := c.now(); pt > c.physical {
+		c.physical = pt
+		c.logical = 0
+	} else {
+		c.tick()
+	}
+	return c.timestamp()
+}
+
+// Update advances the clock past a timestamp received from another node
+func (c *Clock) Update(remote int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	remotePhysical, remoteLogical := Physical(remote), Logical(remote)
+	pt := c.now()
+
+	switch {
+	case pt > c.physical && pt > remotePhysical:
+		c.physical = pt
+		c.logical = 0
+	case remotePhysical > c.physical:
+		c.physical = remotePhysical
+		c.logical = remoteLogical
+		c.tick()
+	case remotePhysical == c.physical && remoteLogical >= c.logical:
+		c.logical = remoteLogical
+		c.tick()
+	}
+}
+
+// tick increments the logical counter, borrowing from the physical time
+// if the counter overflows
+func (c *Clock) tick() {
+	c.logical++
+	if c.logical > maxLogical {
+		c.physical++
+		c.logical = 0
+	}
+}
+
+// timestamp packs the current clock reading
+func (c *Clock) timestamp() int64 {
+	return c.physical<<(logicalBits+nodeBits) | c.logical<<nodeBits | c.nodeID
+}
+
+// Physical returns the physical time (ms since Unix epoch) of a timestamp
+func Physical(ts int64) int64 {
+	return ts >> (logicalBits + nodeBits)
+}
+
+// Logical returns the logical counter of a timestamp
+func Logical(ts int64) int64 {
+	return (ts >> nodeBits) & maxLogical
+}
+
+// NodeID returns the id of the node that issued a timestamp
+func NodeID(ts int64) int {
+	return int(ts & MaxNodeID)
+}
@@ -0,0 +1,331 @@
+package kvstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// CRDT types
+const (
+	TypeGCounter    = "gcounter"    // Grow-only counter
+	TypePNCounter   = "pncounter"   // Counter that can be incremented and decremented
+	TypeLWWRegister = "lwwregister" // Single value, last writer wins
+	TypeORSet       = "orset"       // Set where a concurrent add wins over a remove
+	TypeMap         = "map"         // Named fields, each holding one of the types above
+)
+
+// CRDT operations
+const (
+	OpIncrement = "incr"   // Counters
+	OpDecrement = "decr"   // PN-counters
+	OpAssign    = "assign" // Registers
+	OpAdd       = "add"    // Sets
+	OpRemove    = "remove" // Sets
+)
+
+// Errors returned by CRDT updates
+var (
+	ErrCRDTKey   = &KVError{Message: "key holds a CRDT value"}
+	ErrPlainKey  = &KVError{Message: "key holds a plain value"}
+	ErrCRDTType  = &KVError{Message: "unknown CRDT type"}
+	ErrCRDTOp    = &KVError{Message: "operation not supported by the key's CRDT type"}
+	ErrCRDTField = &KVError{Message: "map updates need a field"}
+)
+
+// CRDT is a conflict-free replicated value
+// Replicas holding different states of the same value always converge by
+// merging them, in any order and any number of times. Only the fields of
+// the value's type are set.
+type CRDT struct {
+	Type      string           `json:"type"`
+	P         map[string]int64 `json:"p,omitempty"`         // Counters: increments per actor
+	N         map[string]int64 `json:"n,omitempty"`         // PN-counters: decrements per actor
+	Value     string           `json:"value,omitempty"`     // Registers
+	Timestamp int64            `json:"timestamp,omitempty"` // Registers: HLC timestamp of the value
+	Elements  map[string][]Dot `json:"elements,omitempty"`  // Sets: the adds behind each element
+	Clock     VectorClock      `json:"clock,omitempty"`     // Sets: every add seen
+	Fields    map[string]*CRDT `json:"fields,omitempty"`    // Maps
+}
+
+// Operation is a client update to a CRDT value
+// For maps, Field names the field the operation applies to; the field is
+// created with the type the operation implies.
+type Operation struct {
+	Op      string `json:"op"`
+	Amount  int64  `json:"amount,omitempty"`  // incr, decr
+	Value   string `json:"value,omitempty"`   // assign
+	Element string `json:"element,omitempty"` // add, remove
+	Field   string `json:"field,omitempty"`   // Maps only
+}
+
+// NewCRDT creates an empty value of the given type
+func NewCRDT(typ string) (*CRDT, error) {
+	switch typ {
+	case TypeGCounter, TypePNCounter, TypeLWWRegister, TypeORSet, TypeMap:
+		return &CRDT{Type: typ}, nil
+	default:
+		return nil, ErrCRDTType
+	}
+}
+
+// Apply performs an operation on the value
+// actor identifies the replica making the update (each actor only ever
+// changes its own part of a counter or set) and timestamp orders register
+// writes.
+func (c *CRDT) Apply(op Operation, actor string, timestamp int64) error {
+	if c.Type == TypeMap {
+		if op.Field == "" {
+			return ErrCRDTField
+		}
+		typ, err := fieldType(op.Op)
+		if err != nil {
+			return err
+		}
+		if c.Fields == nil {
+			c.Fields = make(map[string]*CRDT)
+		}
+		field, ok := c.Fields[op.Field]
+		if !ok {
+			field = &CRDT{Type: typ}
+			c.Fields[op.Field] = field
+		}
+		op.Field = ""
+		return field.Apply(op, actor, timestamp)
+	}
+
+	switch {
+	case op.Op == OpIncrement && (c.Type == TypeGCounter || c.Type == TypePNCounter):
+		if op.Amount < 0 {
+			if c.Type == TypeGCounter {
+				return fmt.Errorf("%w: a gcounter cannot be decremented", ErrCRDTOp)
+			}
+			return c.Apply(Operation{Op: OpDecrement, Amount: -op.Amount}, actor, timestamp)
+		}
+		if c.P == nil {
+			c.P = make(map[string]int64)
+		}
+		c.P[actor] += op.Amount
+	case op.Op == OpDecrement && c.Type == TypePNCounter:
+		if op.Amount < 0 {
+			return c.Apply(Operation{Op: OpIncrement, Amount: -op.Amount}, actor, timestamp)
+		}
+		if c.N == nil {
+			c.N = make(map[string]int64)
+		}
+		c.N[actor] += op.Amount
+	case op.Op == OpAssign && c.Type == TypeLWWRegister:
+		if timestamp > c.Timestamp {
+			c.Value = op.Value
+			c.Timestamp = timestamp
+		}
+	case op.Op == OpAdd && c.Type == TypeORSet:
+		// The new add replaces the adds seen so far; they are all covered
+		// by the clock, so replicas that still hold them drop them on merge
+		if c.Clock == nil {
+			c.Clock = make(VectorClock)
+		}
+		if c.Elements == nil {
+			c.Elements = make(map[string][]Dot)
+		}
+		c.Clock[actor]++
+		c.Elements[op.Element] = []Dot{{Node: actor, Counter: c.Clock[actor]}}
+	case op.Op == OpRemove && c.Type == TypeORSet:
+		// Only the adds seen here are removed; a concurrent add survives
+		delete(c.Elements, op.Element)
+	default:
+		return fmt.Errorf("%w: %s on %s", ErrCRDTOp, op.Op, c.Type)
+	}
+	return nil
+}
+
+// fieldType returns the type of the map field an operation creates
+func fieldType(op string) (string, error) {
+	switch op {
+	case OpIncrement, OpDecrement:
+		return TypePNCounter, nil
+	case OpAssign:
+		return TypeLWWRegister, nil
+	case OpAdd, OpRemove:
+		return TypeORSet, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrCRDTOp, op)
+	}
+}
+
+// Merge joins another state of the value into this one
+// If the two states were created with different types (a key or map field
+// concurrently created as both), the type that sorts first wins on every
+// replica.
+func (c *CRDT) Merge(other *CRDT) {
+	if other == nil {
+		return
+	}
+	if other.Type != c.Type {
+		if other.Type < c.Type {
+			*c = *other.Clone()
+		}
+		return
+	}
+
+	switch c.Type {
+	case TypeGCounter, TypePNCounter:
+		c.P = mergeMax(c.P, other.P)
+		c.N = mergeMax(c.N, other.N)
+	case TypeLWWRegister:
+		if other.Timestamp > c.Timestamp || (other.Timestamp == c.Timestamp && other.Value > c.Value) {
+			c.Value = other.Value
+			c.Timestamp = other.Timestamp
+		}
+	case TypeORSet:
+		// An add survives if both sides hold it, or if the side without it
+		// has not seen it (so it was not removed there)
+		elements := make(map[string][]Dot)
+		for element, dots := range c.Elements {
+			for _, dot := range dots {
+				if hasDot(other.Elements[element], dot) || !other.Clock.Covers(dot) {
+					elements[element] = append(elements[element], dot)
+				}
+			}
+		}
+		for element, dots := range other.Elements {
+			for _, dot := range dots {
+				if !hasDot(c.Elements[element], dot) && !c.Clock.Covers(dot) {
+					elements[element] = append(elements[element], dot)
+				}
+			}
+		}
+		for _, dots := range elements {
+			sortDots(dots)
+		}
+		if len(elements) == 0 {
+			elements = nil
+		}
+		c.Elements = elements
+		c.Clock = c.Clock.Merge(other.Clock)
+	case TypeMap:
+		for name, field := range other.Fields {
+			if c.Fields == nil {
+				c.Fields = make(map[string]*CRDT)
+			}
+			if existing, ok := c.Fields[name]; ok {
+				existing.Merge(field)
+			} else {
+				c.Fields[name] = field.Clone()
+			}
+		}
+	}
+}
+
+// mergeMax returns the per-actor maximum of two counter maps
+func mergeMax(a, b map[string]int64) map[string]int64 {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	merged := make(map[string]int64, len(a))
+	for actor, count := range a {
+		merged[actor] = count
+	}
+	for actor, count := range b {
+		if count > merged[actor] {
+			merged[actor] = count
+		}
+	}
+	return merged
+}
+
+// hasDot returns true if dots contains dot
+func hasDot(dots []Dot, dot Dot) bool {
+	for _, d := range dots {
+		if d == dot {
+			return true
+		}
+	}
+	return false
+}
+
+// sortDots orders dots so equal sets encode identically
+func sortDots(dots []Dot) {
+	sort.Slice(dots, func(i, j int) bool {
+		if dots[i].Node != dots[j].Node {
+			return dots[i].Node < dots[j].Node
+		}
+		return dots[i].Counter < dots[j].Counter
+	})
+}
+
+// Result returns the value as seen by clients: a number for counters, a
+// string for registers, a sorted list for sets and an object for maps
+func (c *CRDT) Result() interface{} {
+	switch c.Type {
+	case TypeGCounter, TypePNCounter:
+		var total int64
+		for _, count := range c.P {
+			total += count
+		}
+		for _, count := range c.N {
+			total -= count
+		}
+		return total
+	case TypeLWWRegister:
+		return c.Value
+	case TypeORSet:
+		elements := make([]string, 0, len(c.Elements))
+		for element := range c.Elements {
+			elements = append(elements, element)
+		}
+		sort.Strings(elements)
+		return elements
+	case TypeMap:
+		fields := make(map[string]interface{}, len(c.Fields))
+		for name, field := range c.Fields {
+			fields[name] = field.Result()
+		}
+		return fields
+	default:
+		return nil
+	}
+}
+
+// Clone returns a deep copy of the value
+func (c *CRDT) Clone() *CRDT {
+	if c == nil {
+		return nil
+	}
+	clone := &CRDT{
+		Type:      c.Type,
+		P:         mergeMax(c.P, nil),
+		N:         mergeMax(c.N, nil),
+		Value:     c.Value,
+		Timestamp: c.Timestamp,
+	}
+	if c.Elements != nil {
+		clone.Elements = make(map[string][]Dot, len(c.Elements))
+		for element, dots := range c.Elements {
+			clone.Elements[element] = append([]Dot{}, dots...)
+		}
+	}
+	if c.Clock != nil {
+		clone.Clock = c.Clock.Copy()
+	}
+	if c.Fields != nil {
+		clone.Fields = make(map[string]*CRDT, len(c.Fields))
+		for name, field := range c.Fields {
+			clone.Fields[name] = field.Clone()
+		}
+	}
+	return clone
+}
+
+// Encode returns the canonical encoding of the value: equal states always
+// encode to the same bytes
+func (c *CRDT) Encode() []byte {
+	data, _ := json.Marshal(c)
+	return data
+}
+
+// Equal returns true if two states are the same
+func (c *CRDT) Equal(other *CRDT) bool {
+	return bytes.Equal(c.Encode(), other.Encode())
+}
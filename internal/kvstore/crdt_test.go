@@ -0,0 +1,139 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCRDTMerge(t *testing.T) {
+	// Replicas a and b update a common base concurrently; operation i of a
+	// replica is timestamped with its start timestamp + i
+	tests := []struct {
+		name     string
+		typ      string
+		base     []Operation
+		a, b     []Operation
+		aTS, bTS int64
+		want     string // Result after merging, as JSON
+	}{
+		{
+			name: "gcounter",
+			typ:  TypeGCounter,
+			base: []Operation{{Op: OpIncrement, Amount: 1}},
+			a:    []Operation{{Op: OpIncrement, Amount: 2}},
+			b:    []Operation{{Op: OpIncrement, Amount: 3}, {Op: OpIncrement, Amount: 1}},
+			want: `7`,
+		},
+		{
+			name: "pncounter",
+			typ:  TypePNCounter,
+			base: []Operation{{Op: OpIncrement, Amount: 5}},
+			a:    []Operation{{Op: OpDecrement, Amount: 2}},
+			b:    []Operation{{Op: OpIncrement, Amount: 1}, {Op: OpIncrement, Amount: -4}},
+			want: `0`,
+		},
+		{
+			name: "register, later write wins",
+			typ:  TypeLWWRegister,
+			a:    []Operation{{Op: OpAssign, Value: "y"}},
+			b:    []Operation{{Op: OpAssign, Value: "x"}},
+			aTS:  20,
+			bTS:  10,
+			want: `"y"`,
+		},
+		{
+			name: "register, equal timestamps",
+			typ:  TypeLWWRegister,
+			a:    []Operation{{Op: OpAssign, Value: "x"}},
+			b:    []Operation{{Op: OpAssign, Value: "y"}},
+			aTS:  10,
+			bTS:  10,
+			want: `"y"`,
+		},
+		{
+			name: "set, concurrent add wins over remove",
+			typ:  TypeORSet,
+			base: []Operation{{Op: OpAdd, Element: "e"}},
+			a:    []Operation{{Op: OpRemove, Element: "e"}},
+			b:    []Operation{{Op: OpAdd, Element: "e"}},
+			want: `["e"]`,
+		},
+		{
+			name: "set, remove of a seen add",
+			typ:  TypeORSet,
+			base: []Operation{{Op: OpAdd, Element: "e"}, {Op: OpAdd, Element: "f"}},
+			a:    []Operation{{Op: OpRemove, Element: "e"}, {Op: OpAdd, Element: "g"}},
+			b:    []Operation{{Op: OpAdd, Element: "h"}},
+			want: `["f","g","h"]`,
+		},
+		{
+			name: "map",
+			typ:  TypeMap,
+			base: []Operation{{Op: OpIncrement, Amount: 1, Field: "n"}},
+			a:    []Operation{{Op: OpIncrement, Amount: 1, Field: "n"}, {Op: OpAdd, Element: "x", Field: "s"}},
+			b:    []Operation{{Op: OpIncrement, Amount: 2, Field: "n"}, {Op: OpAssign, Value: "v", Field: "r"}},
+			want: `{"n":4,"r":"v","s":["x"]}`,
+		},
+		{
+			name: "map, field created with two types",
+			typ:  TypeMap,
+			a:    []Operation{{Op: OpIncrement, Amount: 1, Field: "f"}},
+			b:    []Operation{{Op: OpAssign, Value: "v", Field: "f"}},
+			bTS:  10,
+			want: `{"f":"v"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := applyAll(t, mustCRDT(t, tt.typ), "base", 1, tt.base)
+			a := applyAll(t, base.Clone(), "a", tt.aTS, tt.a)
+			b := applyAll(t, base.Clone(), "b", tt.bTS, tt.b)
+
+			ab := merged(a, b)
+			ba := merged(b, a)
+			if !ab.Equal(ba) {
+				t.Fatalf("merge is not commutative:\n%s\n%s", ab.Encode(), ba.Encode())
+			}
+			if result, _ := json.Marshal(ab.Result()); string(result) != tt.want {
+				t.Fatalf("got %s, want %s", result, tt.want)
+			}
+
+			// Merging states already included changes nothing
+			for _, state := range []*CRDT{ab, a, b, base} {
+				if again := merged(ab, state); !again.Equal(ab) {
+					t.Fatalf("merge is not idempotent:\n%s\n%s", again.Encode(), ab.Encode())
+				}
+			}
+			if again := merged(a, ab); !again.Equal(ab) {
+				t.Fatalf("merging the merged state into a gives\n%s, want\n%s", again.Encode(), ab.Encode())
+			}
+		})
+	}
+}
+
+// mustCRDT creates an empty value of a type
+func mustCRDT(t *testing.T, typ string) *CRDT {
+	c, err := NewCRDT(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// applyAll applies operations as one actor, timestamped from timestamp on
+func applyAll(t *testing.T, c *CRDT, actor string, timestamp int64, ops []Operation) *CRDT {
+	for i, op := range ops {
+		if err := c.Apply(op, actor, timestamp+int64(i)); err != nil {
+			t.Fatalf("%s %+v: %v", actor, op, err)
+		}
+	}
+	return c
+}
+
+// merged returns the merge of b into a copy of a
+func merged(a, b *CRDT) *CRDT {
+	c := a.Clone()
+	c.Merge(b)
+	return c
+}
@@ -0,0 +1,165 @@
+package kvstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Index finds keys by a field of their JSON values
+// Only keys starting with Prefix are indexed. Path is a dot-separated field
+// path ("user.id"). A key whose value is not a JSON object, or whose field
+// is missing or not a string, number or boolean, is not indexed.
+type Index struct {
+	Name   string
+	Prefix string
+	Path   string
+	Offset int // Bytes stored in front of the JSON values (a header of the namespace layer)
+}
+
+// index is an Index and its entries
+type index struct {
+	Index
+	fields []string
+	keys   map[string]map[string]bool // Field value -> keys
+}
+
+// Index errors
+var (
+	ErrIndexNotFound = &KVError{Message: "index not found"}
+	ErrIndexConflict = &KVError{Message: "an index with this name is already defined differently"}
+	ErrInvalidIndex  = &KVError{Message: "an index needs a name and a path"}
+)
+
+// AddIndex defines an index and builds it from the keys already stored
+// Adding an index that is already defined the same way does nothing.
+func (s *Store) AddIndex(def Index) error {
+	if def.Name == "" || def.Path == "" {
+		return ErrInvalidIndex
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.indexes[def.Name]; ok {
+		if existing.Index != def {
+			return ErrIndexConflict
+		}
+		return nil
+	}
+
+	idx := &index{
+		Index:  def,
+		fields: strings.Split(def.Path, "."),
+		keys:   make(map[string]map[string]bool),
+	}
+	for _, kv := range s.data {
+		idx.add(kv)
+	}
+	s.indexes[def.Name] = idx
+	return nil
+}
+
+// Query returns the keys whose indexed field has a value, sorted by key
+// The field's value is compared as written in the JSON value (strings
+// without quotes).
+func (s *Store) Query(name string, value string) ([]*KeyValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.indexes[name]
+	if !ok {
+		return nil, ErrIndexNotFound
+	}
+
+	matches := make([]*KeyValue, 0, len(idx.keys[value]))
+	for key := range idx.keys[value] {
+		matches = append(matches, s.data[key].copy())
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Key < matches[j].Key })
+	return matches, nil
+}
+
+// IndexValue returns the value of a field of a JSON value, as an index on
+// path stores it; ok is false if the value would not be indexed
+func IndexValue(value []byte, path string) (string, bool) {
+	return fieldValue(value, strings.Split(path, "."))
+}
+
+// update moves a key's entry from its old value to its new one (old is nil
+// for new keys)
+func (idx *index) update(old, new *KeyValue) {
+	if old != nil {
+		idx.remove(old)
+	}
+	idx.add(new)
+}
+
+// add indexes a key's value
+func (idx *index) add(kv *KeyValue) {
+	value, ok := idx.value(kv)
+	if !ok {
+		return
+	}
+	if idx.keys[value] == nil {
+		idx.keys[value] = make(map[string]bool)
+	}
+	idx.keys[value][kv.Key] = true
+}
+
+// remove drops a key's entry for its value
+func (idx *index) remove(kv *KeyValue) {
+	value, ok := idx.value(kv)
+	if !ok {
+		return
+	}
+	delete(idx.keys[value], kv.Key)
+	if len(idx.keys[value]) == 0 {
+		delete(idx.keys, value)
+	}
+}
+
+// value returns the value a key is indexed under, if any
+// Tombstones, sibling sets and CRDTs are not indexed.
+func (idx *index) value(kv *KeyValue) (string, bool) {
+	if kv.Deleted || kv.CRDT != nil || kv.Siblings != nil || !strings.HasPrefix(kv.Key, idx.Prefix) || len(kv.Value) < idx.Offset {
+		return "", false
+	}
+	return fieldValue(kv.Value[idx.Offset:], idx.fields)
+}
+
+// fieldValue returns the string form of a scalar field of a JSON object
+func fieldValue(value []byte, fields []string) (string, bool) {
+	if trimmed := bytes.TrimSpace(value); len(trimmed) == 0 || trimmed[0] != '{' {
+		return "", false // Not an object, so not worth decoding
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return "", false
+	}
+
+	for _, field := range fields {
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if doc, ok = object[field]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := doc.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
@@ -0,0 +1,182 @@
+package kvstore
+
+import "testing"
+
+func TestIndexMaintenance(t *testing.T) {
+	def := Index{Name: "by_city", Prefix: "user:", Path: "address.city"}
+
+	tests := []struct {
+		name   string
+		writes func(s *Store)
+		want   map[string][]string // Keys found per city
+	}{
+		{
+			name:   "local write",
+			writes: func(s *Store) { s.Set("user:1", []byte(`{"address": {"city": "Paris"}}`)) },
+			want:   map[string][]string{"Paris": {"user:1"}},
+		},
+		{
+			name: "field changed",
+			writes: func(s *Store) {
+				s.Set("user:1", []byte(`{"address": {"city": "Paris"}}`))
+				s.Set("user:1", []byte(`{"address": {"city": "Lyon"}}`))
+			},
+			want: map[string][]string{"Paris": nil, "Lyon": {"user:1"}},
+		},
+		{
+			name: "local delete",
+			writes: func(s *Store) {
+				s.Set("user:1", []byte(`{"address": {"city": "Paris"}}`))
+				s.Set("user:2", []byte(`{"address": {"city": "Paris"}}`))
+				s.Delete("user:1")
+			},
+			want: map[string][]string{"Paris": {"user:2"}},
+		},
+		{
+			name: "replicated write",
+			writes: func(s *Store) {
+				s.SetWithVersion("user:1", []byte(`{"address": {"city": "Paris"}}`), 5)
+				s.SetWithVersion("user:1", []byte(`{"address": {"city": "Lyon"}}`), 7)
+			},
+			want: map[string][]string{"Paris": nil, "Lyon": {"user:1"}},
+		},
+		{
+			name: "older replicated write",
+			writes: func(s *Store) {
+				s.SetWithVersion("user:1", []byte(`{"address": {"city": "Paris"}}`), 7)
+				s.SetWithVersion("user:1", []byte(`{"address": {"city": "Lyon"}}`), 5)
+			},
+			want: map[string][]string{"Paris": {"user:1"}, "Lyon": nil},
+		},
+		{
+			name: "replicated delete",
+			writes: func(s *Store) {
+				s.SetWithVersion("user:1", []byte(`{"address": {"city": "Paris"}}`), 5)
+				s.DeleteWithVersion("user:1", 6)
+			},
+			want: map[string][]string{"Paris": nil},
+		},
+		{
+			name: "older replicated delete",
+			writes: func(s *Store) {
+				s.SetWithVersion("user:1", []byte(`{"address": {"city": "Paris"}}`), 6)
+				s.DeleteWithVersion("user:1", 5)
+			},
+			want: map[string][]string{"Paris": {"user:1"}},
+		},
+		{
+			name: "write after a delete",
+			writes: func(s *Store) {
+				s.Set("user:1", []byte(`{"address": {"city": "Paris"}}`))
+				s.Delete("user:1")
+				s.Set("user:1", []byte(`{"address": {"city": "Paris"}}`))
+			},
+			want: map[string][]string{"Paris": {"user:1"}},
+		},
+		{
+			name: "value no longer indexed",
+			writes: func(s *Store) {
+				s.Set("user:1", []byte(`{"address": {"city": "Paris"}}`))
+				s.Set("user:1", []byte(`not json`))
+			},
+			want: map[string][]string{"Paris": nil},
+		},
+		{
+			name:   "key outside the prefix",
+			writes: func(s *Store) { s.Set("order:1", []byte(`{"address": {"city": "Paris"}}`)) },
+			want:   map[string][]string{"Paris": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The index is kept up to date whether it is defined before or
+			// after the writes
+			for _, defineFirst := range []bool{true, false} {
+				s := NewStore()
+				if defineFirst {
+					if err := s.AddIndex(def); err != nil {
+						t.Fatal(err)
+					}
+				}
+				tt.writes(s)
+				if !defineFirst {
+					if err := s.AddIndex(def); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				for city, want := range tt.want {
+					matches, err := s.Query(def.Name, city)
+					if err != nil {
+						t.Fatal(err)
+					}
+					var got []string
+					for _, kv := range matches {
+						got = append(got, kv.Key)
+					}
+					if !equalStrings(got, want) {
+						t.Fatalf("index defined first %v: %s has %v, want %v", defineFirst, city, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAddIndex(t *testing.T) {
+	s := NewStore()
+	def := Index{Name: "by_id", Path: "id"}
+
+	tests := []struct {
+		name    string
+		def     Index
+		wantErr error
+	}{
+		{name: "new index", def: def},
+		{name: "same definition again", def: def},
+		{name: "other definition, same name", def: Index{Name: "by_id", Path: "user.id"}, wantErr: ErrIndexConflict},
+		{name: "no name", def: Index{Path: "id"}, wantErr: ErrInvalidIndex},
+		{name: "no path", def: Index{Name: "by_name"}, wantErr: ErrInvalidIndex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AddIndex(tt.def); err != tt.wantErr {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := s.Query("missing", "1"); err != ErrIndexNotFound {
+		t.Fatalf("got %v, want %v", err, ErrIndexNotFound)
+	}
+}
+
+func TestIndexValue(t *testing.T) {
+	tests := []struct {
+		value  string
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{value: `{"id": "a1"}`, path: "id", want: "a1", wantOK: true},
+		{value: `{"id": 12.50}`, path: "id", want: "12.50", wantOK: true},
+		{value: `{"active": true}`, path: "active", want: "true", wantOK: true},
+		{value: ` {"user": {"id": 7}}`, path: "user.id", want: "7", wantOK: true},
+		{value: `{"user": {"id": [7]}}`, path: "user.id"},
+		{value: `{"user": "7"}`, path: "user.id"},
+		{value: `{"id": null}`, path: "id"},
+		{value: `{}`, path: "id"},
+		{value: `["id"]`, path: "id"},
+		{value: `{"id": `, path: "id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := IndexValue([]byte(tt.value), tt.path)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("got %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,79 +1,379 @@
 package kvstore
 
 import (
+	"sort"
+	"strings"
 	"sync"
 )
 
+// DefaultMaxValueSize is the default limit on the size of a value
+const DefaultMaxValueSize = 16 << 20 // 16 MiB
+
+// ReservedPrefix starts the keys nodes store for their own use (namespaces
+// and their registry); clients cannot read or write them directly
+const ReservedPrefix = "\x00"
+
 // Store is an in-memory key-value store with versioning
 type Store struct {
-	mu      sync.RWMutex
-	data    map[string]*KeyValue
-	version int64 // Global version counter
+	mu           sync.RWMutex
+	data         map[string]*KeyValue
+	version      int64                    // Global version counter
+	observer     func(old, new *KeyValue) // Called on every change (old is nil for new keys)
+	maxValueSize int64                    // Largest value accepted in bytes (0 = unlimited)
+	indexes      map[string]*index        // Secondary indexes by name, updated on every change
 }
 
 // KeyValue represents a key-value pair with version
+// Keys written with causal versioning (SetCausal/MergeSibling) carry their
+// concurrent values in Siblings instead of Value and Version, and CRDT keys
+// (UpdateCRDT/MergeCRDT) carry their state in CRDT
+// A deleted key is kept as a tombstone (Deleted set, no value) so the delete
+// is ordered against other writes by its version like any other write.
 type KeyValue struct {
-	Key     string
-	Value   string
-	Version int64
+	Key      string
+	Value    []byte // Arbitrary bytes; never modified in place once stored
+	Version  int64
+	Siblings []Sibling
+	CRDT     *CRDT
+	Deleted  bool
 }
 
+// Condition checks the current value of a key before a conditional write
+// current is nil if the key does not exist or was deleted. A non-nil error
+// rejects the write and is returned to the caller.
+type Condition func(current *KeyValue) error
+
+// UpdateFunc computes a key's new value from its current one (nil if the
+// key does not exist or was deleted). A non-nil error rejects the write and
+// is returned to the caller.
+type UpdateFunc func(current *KeyValue) ([]byte, error)
+
 // NewStore creates a new in-memory key-value store
 func NewStore() *Store {
 	return &Store{
-		data:    make(map[string]*KeyValue),
-		version: 0,
+		data:         make(map[string]*KeyValue),
+		indexes:      make(map[string]*index),
+		version:      0,
+		maxValueSize: DefaultMaxValueSize,
 	}
 }
 
+// SetMaxValueSize sets the largest value accepted in bytes (0 = unlimited)
+func (s *Store) SetMaxValueSize(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxValueSize = size
+}
+
+// GetMaxValueSize returns the largest value accepted in bytes
+func (s *Store) GetMaxValueSize() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxValueSize
+}
+
+// CheckValueSize returns ErrValueTooLarge if a value of size bytes would be
+// rejected, so nodes can refuse a write before forwarding it
+func (s *Store) CheckValueSize(size int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkSize(size)
+}
+
+// checkSize returns ErrValueTooLarge if a value exceeds the maximum size
+// (caller holds s.mu)
+func (s *Store) checkSize(size int) error {
+	if s.maxValueSize > 0 && int64(size) > s.maxValueSize {
+		return ErrValueTooLarge
+	}
+	return nil
+}
+
 // Set stores a value under the given key
-// Returns the version number and an error if key is empty
-func (s *Store) Set(key, value string) (int64, error) {
+// Returns the version number and an error if key is empty or the value is
+// too large
+func (s *Store) Set(key string, value []byte) (int64, error) {
+	return s.SetIf(key, value, nil)
+}
+
+// SetIf stores a value under the given key if condition (when not nil)
+// accepts the current value; the check and the write are atomic
+func (s *Store) SetIf(key string, value []byte, condition Condition) (int64, error) {
+	return s.write(&KeyValue{Key: key, Value: value}, condition)
+}
+
+// Update stores the value update computes from the key's current value; the
+// read and the write are atomic
+// Returns the key's new value and version.
+func (s *Store) Update(key string, update UpdateFunc) (*KeyValue, error) {
 	if key == "" {
-		return 0, ErrEmptyKey
+		return nil, ErrEmptyKey
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	value, err := update(s.current(key))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSize(len(value)); err != nil {
+		return nil, err
+	}
+
 	s.version++
-	kv := &KeyValue{
-		Key:     key,
-		Value:   value,
-		Version: s.version,
+	kv := &KeyValue{Key: key, Value: value, Version: s.version}
+	s.put(kv)
+
+	return kv.copy(), nil
+}
+
+// Delete deletes a key, leaving a tombstone with a new version
+func (s *Store) Delete(key string) (int64, error) {
+	return s.DeleteIf(key, nil)
+}
+
+// DeleteIf deletes a key if condition (when not nil) accepts the current
+// value; the check and the delete are atomic
+func (s *Store) DeleteIf(key string, condition Condition) (int64, error) {
+	return s.write(&KeyValue{Key: key, Deleted: true}, condition)
+}
+
+// write stores a value or tombstone under the next version
+func (s *Store) write(kv *KeyValue, condition Condition) (int64, error) {
+	if kv.Key == "" {
+		return 0, ErrEmptyKey
 	}
-	s.data[key] = kv
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkSize(len(kv.Value)); err != nil {
+		return 0, err
+	}
+	if condition != nil {
+		if err := condition(s.current(kv.Key)); err != nil {
+			return 0, err
+		}
+	}
+
+	s.version++
+	kv.Version = s.version
+	s.put(kv)
 
 	return s.version, nil
 }
 
+// current returns a copy of a key's value for a Condition, or nil if the
+// key does not exist or was deleted (caller holds s.mu)
+func (s *Store) current(key string) *KeyValue {
+	kv, exists := s.data[key]
+	if !exists || kv.Deleted {
+		return nil
+	}
+	return kv.copy()
+}
+
 // SetWithVersion stores a value with a specific version (used for replication)
-// Updates the global version counter if the provided version is higher
-func (s *Store) SetWithVersion(key, value string, version int64) error {
+// Applies last-writer-wins: the value is only stored if its version is
+// newer than the stored one, so replicas converge regardless of the order
+// in which they receive writes. Updates the global version counter if the
+// provided version is higher.
+// Returns true if the value was stored, false if it was older
+func (s *Store) SetWithVersion(key string, value []byte, version int64) (bool, error) {
+	return s.writeWithVersion(&KeyValue{Key: key, Value: value, Version: version})
+}
+
+// DeleteWithVersion applies a replicated delete with a specific version
+// The tombstone replaces the value under the same last-writer-wins rule as
+// SetWithVersion.
+// Returns true if the tombstone was stored, false if it was older
+func (s *Store) DeleteWithVersion(key string, version int64) (bool, error) {
+	return s.writeWithVersion(&KeyValue{Key: key, Version: version, Deleted: true})
+}
+
+// writeWithVersion stores a value or tombstone with a specific version
+func (s *Store) writeWithVersion(kv *KeyValue) (bool, error) {
+	key, value, version := kv.Key, kv.Value, kv.Version
 	if key == "" {
-		return ErrEmptyKey
+		return false, ErrEmptyKey
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.checkSize(len(value)); err != nil {
+		return false, err
+	}
+
 	// Update global version if this version is higher
 	if version > s.version {
 		s.version = version
 	}
 
-	kv := &KeyValue{
-		Key:     key,
+	// An equal version is the same write delivered again
+	if existing, exists := s.data[key]; exists && existing.Version >= version {
+		return false, nil
+	} else if exists && existing.CRDT != nil {
+		return false, ErrCRDTKey
+	}
+
+	s.put(kv)
+
+	return true, nil
+}
+
+// SetCausal stores a value written by a client under causal versioning
+// context is the causal context the client read (empty for a blind write)
+// and actor identifies the coordinating node, and must be new every time the
+// node starts with an empty store so that it never reuses a dot. Every
+// sibling covered by the context is replaced; siblings the client had not
+// seen are kept.
+// Returns the new sibling (to be replicated) and the resulting sibling set
+func (s *Store) SetCausal(key string, value []byte, context VectorClock, actor string) (Sibling, []Sibling, error) {
+	if key == "" {
+		return Sibling{}, nil, ErrEmptyKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkSize(len(value)); err != nil {
+		return Sibling{}, nil, err
+	}
+
+	var siblings []Sibling
+	if kv, exists := s.data[key]; exists {
+		if kv.CRDT != nil {
+			return Sibling{}, nil, ErrCRDTKey
+		}
+		siblings = kv.Siblings
+	}
+
+	// The new dot must be unique, so it follows every write this node has
+	// coordinated for the key
+	counter := context[actor]
+	if stored := CausalContext(siblings)[actor]; stored > counter {
+		counter = stored
+	}
+	sibling := Sibling{
 		Value:   value,
-		Version: version,
+		Dot:     Dot{Node: actor, Counter: counter + 1},
+		Context: context.Copy(),
 	}
-	s.data[key] = kv
 
-	return nil
+	siblings, _ = MergeSiblings(siblings, sibling)
+	s.put(&KeyValue{Key: key, Siblings: siblings})
+
+	return sibling, copySiblings(siblings), nil
+}
+
+// MergeSibling stores a sibling replicated from another node
+// Returns true if the sibling was added, false if it was already known or
+// superseded
+func (s *Store) MergeSibling(key string, sibling Sibling) (bool, error) {
+	if key == "" {
+		return false, ErrEmptyKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var siblings []Sibling
+	if kv, exists := s.data[key]; exists {
+		siblings = kv.Siblings
+	}
+
+	siblings, added := MergeSiblings(siblings, sibling)
+	if added {
+		s.put(&KeyValue{Key: key, Siblings: siblings})
+	}
+	return added, nil
+}
+
+// UpdateCRDT applies a client update to a CRDT key, creating the key with
+// the given type if it does not exist
+// Returns the resulting state (to be replicated)
+func (s *Store) UpdateCRDT(key string, typ string, update func(*CRDT) error) (*CRDT, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var state *CRDT
+	if kv, exists := s.data[key]; exists && !kv.Deleted {
+		if kv.CRDT == nil {
+			return nil, ErrPlainKey
+		}
+		state = kv.CRDT.Clone()
+	} else {
+		var err error
+		if state, err = NewCRDT(typ); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := update(state); err != nil {
+		return nil, err
+	}
+	s.put(&KeyValue{Key: key, CRDT: state})
+
+	return state.Clone(), nil
+}
+
+// MergeCRDT merges a CRDT state replicated from another node into a key
+// A CRDT state replaces a plain value.
+// Returns true if the local state changed
+func (s *Store) MergeCRDT(key string, state *CRDT) (bool, error) {
+	if key == "" {
+		return false, ErrEmptyKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := state.Clone()
+	if kv, exists := s.data[key]; exists && kv.CRDT != nil {
+		merged = kv.CRDT.Clone()
+		merged.Merge(state)
+		if merged.Equal(kv.CRDT) {
+			return false, nil
+		}
+	}
+	s.put(&KeyValue{Key: key, CRDT: merged})
+	return true, nil
+}
+
+// put stores a KeyValue, updates the indexes and notifies the observer
+// (caller holds s.mu)
+func (s *Store) put(kv *KeyValue) {
+	old := s.data[kv.Key]
+	s.data[kv.Key] = kv
+	for _, idx := range s.indexes {
+		idx.update(old, kv)
+	}
+	if s.observer != nil {
+		s.observer(old, kv)
+	}
+}
+
+// SetObserver registers a function called on every change to the store
+// It runs while the store is locked and must not call back into the store.
+// Must be set before the store is used.
+func (s *Store) SetObserver(observer func(old, new *KeyValue)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observer = observer
 }
 
 // Get retrieves the value for the given key
 // Returns the KeyValue and a boolean indicating if the key exists
+// Deleted keys are returned as tombstones, so replicas can order a delete
+// against other versions; client reads must treat them as missing.
+// The copy shares the value bytes with the store, so they must not be
+// modified.
 func (s *Store) Get(key string) (*KeyValue, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -84,17 +384,56 @@ func (s *Store) Get(key string) (*KeyValue, bool) {
 	}
 
 	// Return a copy to avoid race conditions
+	return kv.copy(), true
+}
+
+// copy returns a copy of a KeyValue that shares only the value bytes
+func (kv *KeyValue) copy() *KeyValue {
 	return &KeyValue{
-		Key:     kv.Key,
-		Value:   kv.Value,
-		Version: kv.Version,
-	}, true
+		Key:      kv.Key,
+		Value:    kv.Value,
+		Version:  kv.Version,
+		Siblings: copySiblings(kv.Siblings),
+		CRDT:     kv.CRDT.Clone(),
+		Deleted:  kv.Deleted,
+	}
+}
+
+// Keys returns every key in the store, sorted
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // LocalRead returns the local value without any coordination
-// Used for testing inconsistency windows
+// Used for testing inconsistency windows; deleted keys do not exist
 func (s *Store) LocalRead(key string) (*KeyValue, bool) {
-	return s.Get(key)
+	kv, exists := s.Get(key)
+	if !exists || kv.Deleted {
+		return nil, false
+	}
+	return kv, true
+}
+
+// CheckKey returns the error for a key a client cannot use: an empty key,
+// or one starting with ReservedPrefix
+// The store accepts reserved keys, so every frontend checks the keys it
+// gets from clients.
+func CheckKey(key string) error {
+	if key == "" {
+		return ErrEmptyKey
+	}
+	if strings.HasPrefix(key, ReservedPrefix) {
+		return ErrReservedKey
+	}
+	return nil
 }
 
 // GetVersion returns the current global version counter
@@ -106,7 +445,9 @@ func (s *Store) GetVersion() int64 {
 
 // Errors
 var (
-	ErrEmptyKey = &KVError{Message: "key cannot be empty"}
+	ErrEmptyKey      = &KVError{Message: "key cannot be empty"}
+	ErrReservedKey   = &KVError{Message: "keys starting with a NUL byte are reserved"}
+	ErrValueTooLarge = &KVError{Message: "value exceeds the maximum value size"}
 )
 
 type KVError struct {
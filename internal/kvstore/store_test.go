@@ -0,0 +1,129 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConditionalWrite(t *testing.T) {
+	errRejected := errors.New("rejected")
+	exists := func(current *KeyValue) error {
+		if current == nil {
+			return errRejected
+		}
+		return nil
+	}
+	missing := func(current *KeyValue) error {
+		if current != nil {
+			return errRejected
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name      string
+		setup     func(s *Store)
+		write     func(s *Store) (int64, error)
+		wantErr   error
+		wantValue string // Value read afterwards, "" if the key does not exist
+	}{
+		{
+			name:      "create if missing",
+			write:     func(s *Store) (int64, error) { return s.SetIf("k", []byte("v"), missing) },
+			wantValue: "v",
+		},
+		{
+			name:      "create if missing, key exists",
+			setup:     func(s *Store) { s.Set("k", []byte("old")) },
+			write:     func(s *Store) (int64, error) { return s.SetIf("k", []byte("v"), missing) },
+			wantErr:   errRejected,
+			wantValue: "old",
+		},
+		{
+			name:      "create if missing, key deleted",
+			setup:     func(s *Store) { s.Set("k", []byte("old")); s.Delete("k") },
+			write:     func(s *Store) (int64, error) { return s.SetIf("k", []byte("v"), missing) },
+			wantValue: "v",
+		},
+		{
+			name:    "update if exists, key deleted",
+			setup:   func(s *Store) { s.Set("k", []byte("old")); s.Delete("k") },
+			write:   func(s *Store) (int64, error) { return s.SetIf("k", []byte("v"), exists) },
+			wantErr: errRejected,
+		},
+		{
+			name:  "delete if exists",
+			setup: func(s *Store) { s.Set("k", []byte("old")) },
+			write: func(s *Store) (int64, error) { return s.DeleteIf("k", exists) },
+		},
+		{
+			name:      "delete if missing, key exists",
+			setup:     func(s *Store) { s.Set("k", []byte("old")) },
+			write:     func(s *Store) (int64, error) { return s.DeleteIf("k", missing) },
+			wantErr:   errRejected,
+			wantValue: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore()
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			before := s.GetVersion()
+			version, err := tt.write(s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && version != before+1 {
+				t.Fatalf("got version %d, want %d", version, before+1)
+			}
+			if err != nil && s.GetVersion() != before {
+				t.Fatal("a rejected write used a version")
+			}
+			got := ""
+			if kv, ok := s.LocalRead("k"); ok {
+				got = string(kv.Value)
+			}
+			if got != tt.wantValue {
+				t.Fatalf("got %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestTombstoneOrdering(t *testing.T) {
+	tests := []struct {
+		name        string
+		setVersion  int64
+		delVersion  int64
+		wantDeleted bool
+	}{
+		{name: "delete after the write", setVersion: 1, delVersion: 2, wantDeleted: true},
+		{name: "delete before the write", setVersion: 2, delVersion: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every delivery order converges on the same state
+			for _, deleteFirst := range []bool{false, true} {
+				s := NewStore()
+				if deleteFirst {
+					s.DeleteWithVersion("k", tt.delVersion)
+					s.SetWithVersion("k", []byte("v"), tt.setVersion)
+				} else {
+					s.SetWithVersion("k", []byte("v"), tt.setVersion)
+					s.DeleteWithVersion("k", tt.delVersion)
+				}
+				kv, ok := s.Get("k")
+				if !ok || kv.Deleted != tt.wantDeleted {
+					t.Fatalf("delete first %v: got %+v, want deleted %v", deleteFirst, kv, tt.wantDeleted)
+				}
+				if _, ok := s.LocalRead("k"); ok == tt.wantDeleted {
+					t.Fatalf("delete first %v: a tombstone must read as missing", deleteFirst)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package kvstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// VectorClock maps a node id to the number of writes it has coordinated
+// that are part of a causal history
+type VectorClock map[string]int64
+
+// Dot identifies a single write: the coordinating node and its counter
+type Dot struct {
+	Node    string `json:"node"`
+	Counter int64  `json:"counter"`
+}
+
+// Copy returns a copy of the clock
+func (vc VectorClock) Copy() VectorClock {
+	clock := make(VectorClock, len(vc))
+	for node, counter := range vc {
+		clock[node] = counter
+	}
+	return clock
+}
+
+// Merge returns the pairwise maximum of two clocks
+func (vc VectorClock) Merge(other VectorClock) VectorClock {
+	clock := vc.Copy()
+	for node, counter := range other {
+		if counter > clock[node] {
+			clock[node] = counter
+		}
+	}
+	return clock
+}
+
+// Covers returns true if the write identified by dot is part of the history
+func (vc VectorClock) Covers(dot Dot) bool {
+	return vc[dot.Node] >= dot.Counter
+}
+
+// Sibling is one of several concurrent values of a key
+// Dot identifies the write that produced the value and Context is the
+// causal history the client had seen when it made the write. A sibling
+// supersedes every sibling whose dot its context covers. Value holds
+// arbitrary bytes and, like KeyValue.Value, is never modified in place.
+type Sibling struct {
+	Value   []byte      `json:"value"`
+	Dot     Dot         `json:"dot"`
+	Context VectorClock `json:"context"`
+}
+
+// Clock returns the full vector clock of the sibling (its context plus its dot)
+func (s Sibling) Clock() VectorClock {
+	return s.Context.Merge(VectorClock{s.Dot.Node: s.Dot.Counter})
+}
+
+// EncodeContext encodes a causal context into an opaque string for clients
+func EncodeContext(vc VectorClock) string {
+	if len(vc) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(vc)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeContext decodes a causal context returned by EncodeContext
+// An empty string is the empty context
+func DecodeContext(context string) (VectorClock, error) {
+	if context == "" {
+		return VectorClock{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(context)
+	if err != nil {
+		return nil, fmt.Errorf("invalid context: %w", err)
+	}
+	var vc VectorClock
+	if err := json.Unmarshal(data, &vc); err != nil {
+		return nil, fmt.Errorf("invalid context: %w", err)
+	}
+	if vc == nil {
+		vc = VectorClock{}
+	}
+	return vc, nil
+}
+
+// CausalContext returns the merged clock of a set of siblings
+// A write that supplies this context supersedes all of them
+func CausalContext(siblings []Sibling) VectorClock {
+	context := VectorClock{}
+	for _, sibling := range siblings {
+		context = context.Merge(sibling.Clock())
+	}
+	return context
+}
+
+// MergeSiblings adds a sibling to a set, dropping every sibling it
+// supersedes. The sibling is not added if it is already in the set or an
+// existing sibling supersedes it.
+// Returns the new set and whether the sibling was added
+func MergeSiblings(siblings []Sibling, sibling Sibling) ([]Sibling, bool) {
+	merged := make([]Sibling, 0, len(siblings)+1)
+	for _, existing := range siblings {
+		if existing.Dot == sibling.Dot || existing.Context.Covers(sibling.Dot) {
+			return siblings, false
+		}
+		if !sibling.Context.Covers(existing.Dot) {
+			merged = append(merged, existing)
+		}
+	}
+	return append(merged, sibling), true
+}
+
+// ReconcileSiblings combines sibling sets from several replicas, keeping
+// only the siblings that no other sibling supersedes
+func ReconcileSiblings(sets ...[]Sibling) []Sibling {
+	var reconciled []Sibling
+	for _, set := range sets {
+		for _, sibling := range set {
+			reconciled, _ = MergeSiblings(reconciled, sibling)
+		}
+	}
+	return reconciled
+}
+
+// copySiblings returns a copy of a sibling set that shares only the value
+// bytes
+func copySiblings(siblings []Sibling) []Sibling {
+	if siblings == nil {
+		return nil
+	}
+	copied := make([]Sibling, len(siblings))
+	for i, sibling := range siblings {
+		copied[i] = Sibling{Value: sibling.Value, Dot: sibling.Dot, Context: sibling.Context.Copy()}
+	}
+	return copied
+}
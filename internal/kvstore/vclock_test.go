@@ -0,0 +1,120 @@
+package kvstore
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestMergeSiblings(t *testing.T) {
+	// x is the write a:1, made without context
+	x := Sibling{Value: []byte("x"), Dot: Dot{Node: "a", Counter: 1}, Context: VectorClock{}}
+
+	tests := []struct {
+		name      string
+		existing  Sibling // x unless set
+		sibling   Sibling
+		wantAdded bool
+		want      []string // Values after the merge
+	}{
+		{
+			name:      "after: the context covers the existing write",
+			sibling:   Sibling{Value: []byte("y"), Dot: Dot{Node: "b", Counter: 1}, Context: VectorClock{"a": 1}},
+			wantAdded: true,
+			want:      []string{"y"},
+		},
+		{
+			name:     "before: the existing write's context covers it",
+			existing: Sibling{Value: []byte("z"), Dot: Dot{Node: "a", Counter: 3}, Context: VectorClock{"a": 2}},
+			sibling:  x,
+			want:     []string{"z"},
+		},
+		{
+			name:    "same write delivered again",
+			sibling: x,
+			want:    []string{"x"},
+		},
+		{
+			name:      "concurrent: neither covers the other",
+			sibling:   Sibling{Value: []byte("y"), Dot: Dot{Node: "b", Counter: 1}, Context: VectorClock{}},
+			wantAdded: true,
+			want:      []string{"x", "y"},
+		},
+		{
+			name:      "concurrent: a context from another history",
+			sibling:   Sibling{Value: []byte("y"), Dot: Dot{Node: "b", Counter: 2}, Context: VectorClock{"b": 1}},
+			wantAdded: true,
+			want:      []string{"x", "y"},
+		},
+		{
+			name:      "after: same node, later counter",
+			sibling:   Sibling{Value: []byte("y"), Dot: Dot{Node: "a", Counter: 2}, Context: VectorClock{"a": 1}},
+			wantAdded: true,
+			want:      []string{"y"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := x
+			if tt.existing.Value != nil {
+				existing = tt.existing
+			}
+			merged, added := MergeSiblings([]Sibling{existing}, tt.sibling)
+			if added != tt.wantAdded {
+				t.Fatalf("got added %v, want %v", added, tt.wantAdded)
+			}
+			if got := siblingValues(merged); !equalStrings(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileSiblings(t *testing.T) {
+	x := Sibling{Value: []byte("x"), Dot: Dot{Node: "a", Counter: 1}, Context: VectorClock{}}
+	y := Sibling{Value: []byte("y"), Dot: Dot{Node: "b", Counter: 1}, Context: VectorClock{}}
+	z := Sibling{Value: []byte("z"), Dot: Dot{Node: "a", Counter: 2}, Context: CausalContext([]Sibling{x, y})}
+
+	tests := []struct {
+		name string
+		sets [][]Sibling
+		want []string
+	}{
+		{name: "concurrent writes on two replicas", sets: [][]Sibling{{x}, {y}}, want: []string{"x", "y"}},
+		{name: "same write on two replicas", sets: [][]Sibling{{x}, {x}}, want: []string{"x"}},
+		{name: "newer write first", sets: [][]Sibling{{z}, {x, y}}, want: []string{"z"}},
+		{name: "newer write last", sets: [][]Sibling{{x}, {y}, {z}}, want: []string{"z"}},
+		{name: "stale replica", sets: [][]Sibling{{x, y}, {z}, {x}}, want: []string{"z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := siblingValues(ReconcileSiblings(tt.sets...)); !equalStrings(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// siblingValues returns the sorted values of siblings
+func siblingValues(siblings []Sibling) []string {
+	values := make([]string, 0, len(siblings))
+	for _, sibling := range siblings {
+		values = append(values, string(sibling.Value))
+	}
+	sort.Strings(values)
+	return values
+}
+
+// equalStrings returns true if two slices hold the same strings in order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
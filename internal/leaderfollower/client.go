@@ -2,32 +2,93 @@ package leaderfollower
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/gossip"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
 )
 
 // ReplicationClient handles communication between nodes
 type ReplicationClient struct {
 	httpClient *http.Client
+	faults     *fault.Injector
+	detector   *gossip.Detector // Requests to peers it considers dead fail immediately
+	pool       *rpc.Pool        // Writes and reads use gRPC when set
 }
 
 // NewReplicationClient creates a new replication client
-func NewReplicationClient() *ReplicationClient {
+func NewReplicationClient(faults *fault.Injector, detector *gossip.Detector, pool *rpc.Pool) *ReplicationClient {
 	return &ReplicationClient{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		faults:   faults,
+		detector: detector,
+		pool:     pool,
+	}
+}
+
+// post sends a JSON request to a peer through the fault injector
+func (c *ReplicationClient) post(addr string, url string, jsonData []byte) (*http.Response, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, err
+	}
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// postRaw sends a raw value to a peer through the fault injector
+// The value is streamed as the request body rather than encoded into JSON.
+func (c *ReplicationClient) postRaw(addr string, url string, key string, value []byte, version int64) (*http.Response, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, err
 	}
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		return blob.NewRequest(url, key, version, value)
+	})
+}
+
+// get sends a GET request to a peer through the fault injector
+func (c *ReplicationClient) get(addr string, url string) (*http.Response, error) {
+	return c.getContext(context.Background(), addr, url)
+}
+
+// getContext sends a GET request to a peer that is abandoned once ctx is
+// cancelled
+func (c *ReplicationClient) getContext(ctx context.Context, addr string, url string) (*http.Response, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, err
+	}
+	return c.faults.Do(c.httpClient, addr, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		return req, nil
+	})
 }
 
 // ReplicateWriteRequest represents a write replication request
+// Values of blob.StreamThreshold bytes or more are sent raw instead.
 type ReplicateWriteRequest struct {
 	Key     string `json:"key"`
-	Value   string `json:"value"`
+	Value   []byte `json:"value"`
 	Version int64  `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"` // A delete (tombstone) instead of a value
 }
 
 // ReplicateWriteResponse represents a write replication response
@@ -45,38 +106,47 @@ type ReadRequest struct {
 // ReadResponse represents a read response
 type ReadResponse struct {
 	Key     string `json:"key"`
-	Value   string `json:"value"`
+	Value   []byte `json:"value"`
 	Version int64  `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"` // The key was deleted at Version
 	Exists  bool   `json:"exists"`
 }
 
-// ReplicateWrite sends a write request to a follower node
-// Returns the response and any error
-func (c *ReplicationClient) ReplicateWrite(addr string, key string, value string, version int64, addDelay bool) (*ReplicateWriteResponse, error) {
-	reqBody := ReplicateWriteRequest{
-		Key:     key,
-		Value:   value,
-		Version: version,
-	}
+// ReadBatchRequest represents a read of several keys from another node
+type ReadBatchRequest struct {
+	Keys []string `json:"keys"`
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// ReadBatchResponse holds one read response per key of a batch
+type ReadBatchResponse struct {
+	Responses []ReadResponse `json:"responses"`
+}
 
+// ReplicateWrite sends a write request to a follower node
+// Returns the response and any error
+func (c *ReplicationClient) ReplicateWrite(addr string, write ReplicateWriteRequest, addDelay bool) (*ReplicateWriteResponse, error) {
 	url := fmt.Sprintf("http://%s/internal/replicate_write", addr)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
 
-	// Leader sleeps 200ms after each message to a Follower
+	// Injected send delay (Leader sleeps 200ms after each message to a
+	// Follower in the homework profile)
 	if addDelay {
-		time.Sleep(200 * time.Millisecond)
+		c.faults.Delay(fault.StageSendWrite)
+	}
+	if c.pool != nil {
+		return c.replicateWriteGRPC(addr, write)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	var resp *http.Response
+	var err error
+	if len(write.Value) >= blob.StreamThreshold {
+		resp, err = c.postRaw(addr, url, write.Key, write.Value, write.Version)
+	} else {
+		jsonData, marshalErr := json.Marshal(write)
+		if marshalErr != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", marshalErr)
+		}
+		resp, err = c.post(addr, url, jsonData)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -104,19 +174,23 @@ func (c *ReplicationClient) ReplicateWrite(addr string, key string, value string
 
 // ReadFromNode reads a value from another node
 func (c *ReplicationClient) ReadFromNode(addr string, key string, addDelay bool) (*ReadResponse, error) {
-	url := fmt.Sprintf("http://%s/internal/read?key=%s", addr, key)
+	return c.ReadFromNodeContext(context.Background(), addr, key, addDelay)
+}
+
+// ReadFromNodeContext reads a value from another node, giving up once ctx
+// is cancelled
+func (c *ReplicationClient) ReadFromNodeContext(ctx context.Context, addr string, key string, addDelay bool) (*ReadResponse, error) {
+	url := fmt.Sprintf("http://%s/internal/read?key=%s", addr, url.QueryEscape(key))
 	
-	// Follower sleeps 50ms when receiving read request from Leader
+	// Injected read delay (50ms in the homework profile)
 	if addDelay {
-		time.Sleep(50 * time.Millisecond)
+		c.faults.Delay(fault.StageSendRead)
 	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if c.pool != nil {
+		return c.readGRPC(ctx, addr, key)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.getContext(ctx, addr, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -145,4 +219,120 @@ func (c *ReplicationClient) ReadFromNode(addr string, key string, addDelay bool)
 	return &response, nil
 }
 
+// ReadBatch reads several keys from another node in one request, returning
+// one response per key
+func (c *ReplicationClient) ReadBatch(addr string, keys []string, addDelay bool) ([]ReadResponse, error) {
+	url := fmt.Sprintf("http://%s/internal/read_batch", addr)
+
+	// Injected read delay, once per batch like a single read
+	if addDelay {
+		c.faults.Delay(fault.StageSendRead)
+	}
+	if c.pool != nil {
+		return c.readBatchGRPC(addr, keys)
+	}
+
+	jsonData, err := json.Marshal(ReadBatchRequest{Keys: keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
 
+	resp, err := c.post(addr, url, jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var response ReadBatchResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(response.Responses) != len(keys) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(keys), len(response.Responses))
+	}
+	return response.Responses, nil
+}
+
+// ClusterConfig represents the versioned cluster-wide R/W configuration
+type ClusterConfig struct {
+	R       int   `json:"r"`
+	W       int   `json:"w"`
+	Version int64 `json:"version"`
+}
+
+// ReplicateConfigResponse represents a config replication response
+type ReplicateConfigResponse struct {
+	Success bool   `json:"success"`
+	Applied bool   `json:"applied"` // False if the node already had this version or a newer one
+	Version int64  `json:"version"` // The node's config version after the request
+	Error   string `json:"error,omitempty"`
+}
+
+// ReplicateConfig sends a configuration change to another node
+func (c *ReplicationClient) ReplicateConfig(addr string, config ClusterConfig) (*ReplicateConfigResponse, error) {
+	jsonData, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("http://%s/internal/replicate_config", addr)
+	resp, err := c.post(addr, url, jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &ReplicateConfigResponse{
+			Success: false,
+			Error:   string(body),
+		}, nil
+	}
+
+	var response ReplicateConfigResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// FetchConfig reads the current cluster configuration from another node
+func (c *ReplicationClient) FetchConfig(addr string) (*ClusterConfig, error) {
+	url := fmt.Sprintf("http://%s/internal/config", addr)
+	resp, err := c.get(addr, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var config ClusterConfig
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &config, nil
+}
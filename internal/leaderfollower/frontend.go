@@ -0,0 +1,66 @@
+package leaderfollower
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// FrontendBackend serves the protocol frontends (RESP, memcached) through the
+// replication manager, so reads follow the R strategy and writes the W
+// strategy
+// Followers only serve reads.
+type FrontendBackend struct {
+	config     *Config
+	replicator *ReplicationManager
+}
+
+// FrontendBackend returns the backend of this node's protocol frontends
+func (h *Handler) FrontendBackend() *FrontendBackend {
+	return &FrontendBackend{config: h.config, replicator: h.replicator}
+}
+
+// Get reads a key, returning nil if it does not exist
+func (b *FrontendBackend) Get(key string) (*kvstore.KeyValue, error) {
+	kv, err := b.replicator.Read(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	return kv, err
+}
+
+// Set writes a key if its preconditions hold (Leader only)
+func (b *FrontendBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	return b.write(ReplicateWriteRequest{Key: key, Value: value}, preconditions)
+}
+
+// Delete deletes a key if its preconditions hold (Leader only)
+func (b *FrontendBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.write(ReplicateWriteRequest{Key: key, Deleted: true}, preconditions)
+	return err
+}
+
+// write performs a write on the Leader
+func (b *FrontendBackend) write(write ReplicateWriteRequest, preconditions rest.Preconditions) (int64, error) {
+	if !b.config.IsLeader() {
+		return 0, frontend.ErrReadOnly
+	}
+	result, err := b.replicator.WriteIf(write, preconditions.Condition())
+	if err != nil {
+		return 0, err
+	}
+	return result.Version, nil
+}
+
+// CheckNamespace rejects namespace settings the cluster cannot apply: every
+// node holds every key
+func (h *Handler) CheckNamespace(settings namespace.Settings) error {
+	if settings.ReplicationFactor != 0 && settings.ReplicationFactor != h.config.N {
+		return fmt.Errorf("replication_factor must be %d, every node replicates every key", h.config.N)
+	}
+	return nil
+}
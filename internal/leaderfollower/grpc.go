@@ -0,0 +1,235 @@
+package leaderfollower
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/rpc/kvpb"
+)
+
+// ReplicationService serves the internal API over gRPC, like
+// ReplicateWriteHandler, ReplicateStreamHandler, InternalReadHandler and
+// InternalReadBatchHandler
+type ReplicationService struct {
+	kvpb.UnimplementedReplicationServer
+	handler *Handler
+}
+
+// ReplicationService returns this node's gRPC internal API
+func (h *Handler) ReplicationService() *ReplicationService {
+	return &ReplicationService{handler: h}
+}
+
+// ReplicateWrite applies a write from Leader
+func (s *ReplicationService) ReplicateWrite(ctx context.Context, req *kvpb.ReplicateWriteRequest) (*kvpb.ReplicateWriteResponse, error) {
+	write := writeFromProto(req)
+
+	// Injected receive delay (Follower sleeps 100ms when receiving update
+	// before responding in the homework profile)
+	s.handler.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	if err := s.handler.apply(write); err != nil {
+		return &kvpb.ReplicateWriteResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &kvpb.ReplicateWriteResponse{Success: true, Version: write.Version}, nil
+}
+
+// ReplicateStream applies batches from Leader in the order they arrive,
+// acknowledging them like ReplicateStreamHandler
+func (s *ReplicationService) ReplicateStream(stream kvpb.Replication_ReplicateStreamServer) error {
+	acks := make(chan StreamAck, DefaultStreamMaxInFlight)
+	acksDone := make(chan struct{})
+	go func() {
+		defer close(acksDone)
+		send := func(ack StreamAck) error {
+			return stream.Send(&kvpb.StreamAck{Seq: ack.Seq, Success: ack.Success, Error: ack.Error})
+		}
+		// gRPC sends each message as it is written
+		writeStreamAcks(send, func() error { return nil }, acks)
+	}()
+
+	for {
+		batch, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		writes := make([]ReplicateWriteRequest, len(batch.Writes))
+		for i, write := range batch.Writes {
+			writes[i] = writeFromProto(write)
+		}
+		acks <- s.handler.applyBatch(StreamBatch{Seq: batch.Seq, Writes: writes})
+	}
+
+	close(acks)
+	<-acksDone
+	return nil
+}
+
+// Read reads a key from this node's store
+func (s *ReplicationService) Read(ctx context.Context, req *kvpb.ReadRequest) (*kvpb.ReadResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	// Injected read delay (Follower sleeps 50ms when receiving read request
+	// in the homework profile)
+	if !s.handler.config.IsLeader() {
+		s.handler.config.GetFaults().Delay(fault.StageReceiveRead)
+	}
+
+	r := s.handler.internalRead(req.Key)
+	return &kvpb.ReadResponse{Exists: r.Exists, Key: r.Key, Value: r.Value, Version: r.Version, Deleted: r.Deleted}, nil
+}
+
+// ReadBatch reads several keys from this node's store
+func (s *ReplicationService) ReadBatch(ctx context.Context, req *kvpb.ReadBatchRequest) (*kvpb.ReadBatchResponse, error) {
+	resp := &kvpb.ReadBatchResponse{}
+	for _, r := range s.handler.internalReadBatch(req.Keys) {
+		resp.Responses = append(resp.Responses, &kvpb.ReadResponse{Exists: r.Exists, Key: r.Key, Value: r.Value, Version: r.Version, Deleted: r.Deleted})
+	}
+	return resp, nil
+}
+
+// replicateWriteGRPC sends a write to a follower over gRPC
+func (c *ReplicationClient) replicateWriteGRPC(addr string, write ReplicateWriteRequest) (*ReplicateWriteResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	// Same deadline as an HTTP request
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.ReplicateWrite(ctx, writeToProto(write))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return &ReplicateWriteResponse{Success: resp.Success, Version: resp.Version, Error: resp.Error}, nil
+}
+
+// readGRPC reads a value from another node over gRPC, giving up once ctx is
+// cancelled
+func (c *ReplicationClient) readGRPC(ctx context.Context, addr string, key string) (*ReadResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.Read(ctx, &kvpb.ReadRequest{Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return &ReadResponse{Key: resp.Key, Value: resp.Value, Version: resp.Version, Deleted: resp.Deleted, Exists: resp.Exists}, nil
+}
+
+// readBatchGRPC reads several keys from another node over gRPC
+func (c *ReplicationClient) readBatchGRPC(addr string, keys []string) ([]ReadResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.ReadBatch(ctx, &kvpb.ReadBatchRequest{Keys: keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if len(resp.Responses) != len(keys) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(keys), len(resp.Responses))
+	}
+
+	responses := make([]ReadResponse, len(resp.Responses))
+	for i, r := range resp.Responses {
+		responses[i] = ReadResponse{Key: r.Key, Value: r.Value, Version: r.Version, Deleted: r.Deleted, Exists: r.Exists}
+	}
+	return responses, nil
+}
+
+// runGRPCConnection opens a gRPC stream to the follower and pumps batches
+// until it fails, like runConnection
+func (s *FollowerStream) runGRPCConnection() error {
+	client, err := s.pool.Replication(s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	// Cancelling the context tears down the stream in both directions
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.ReplicateStream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	log.Printf("Replication stream to %s established (gRPC)", s.addr)
+
+	window := make(chan struct{}, s.maxInFlight)
+	var ackErr error
+	ackDone := make(chan struct{})
+	go func() {
+		defer close(ackDone)
+		ackErr = s.receiveAcks(func() (StreamAck, error) {
+			ack, err := stream.Recv()
+			if err != nil {
+				return StreamAck{}, err
+			}
+			return StreamAck{Seq: ack.Seq, Success: ack.Success, Error: ack.Error}, nil
+		}, window)
+	}()
+
+	err = s.sendBatches(func(batch StreamBatch) error {
+		message := &kvpb.StreamBatch{Seq: batch.Seq, Writes: make([]*kvpb.ReplicateWriteRequest, len(batch.Writes))}
+		for i, write := range batch.Writes {
+			message.Writes[i] = writeToProto(write)
+		}
+		return stream.Send(message)
+	}, window, ackDone)
+
+	// Make sure the ack reader has stopped before the caller fails the
+	// remaining in-flight batches
+	cancel()
+	<-ackDone
+
+	if err == nil {
+		err = ackErr
+	}
+	return err
+}
+
+// writeToProto converts a replicated write into its gRPC message
+func writeToProto(write ReplicateWriteRequest) *kvpb.ReplicateWriteRequest {
+	return &kvpb.ReplicateWriteRequest{
+		Key:     write.Key,
+		Value:   write.Value,
+		Version: write.Version,
+		Deleted: write.Deleted,
+	}
+}
+
+// writeFromProto converts a gRPC message into a replicated write
+func writeFromProto(req *kvpb.ReplicateWriteRequest) ReplicateWriteRequest {
+	return ReplicateWriteRequest{
+		Key:     req.Key,
+		Value:   req.Value,
+		Version: req.Version,
+		Deleted: req.Deleted,
+	}
+}
@@ -2,10 +2,18 @@ package leaderfollower
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/batch"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/numeric"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
 )
 
 // Handler provides HTTP handlers for Leader-Follower database
@@ -37,8 +45,7 @@ func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Key == "" {
-		http.Error(w, "key cannot be empty", http.StatusBadRequest)
+	if !rest.ValidKey(w, req.Key) {
 		return
 	}
 
@@ -49,8 +56,12 @@ func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Perform write with replication
-	result, err := h.replicator.Write(req.Key, req.Value)
+	result, err := h.replicator.Write(req.Key, []byte(req.Value))
 	if err != nil {
+		if errors.Is(err, kvstore.ErrValueTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -68,8 +79,7 @@ func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 // GetHandler handles read requests (can go to any node)
 func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
-	if key == "" {
-		http.Error(w, "key parameter is required", http.StatusBadRequest)
+	if !rest.ValidKey(w, key) {
 		return
 	}
 
@@ -84,16 +94,273 @@ func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"key":     kv.Key,
-		"value":   kv.Value,
+		"value":   string(kv.Value),
 		"version": kv.Version,
 	})
 }
 
+// MGetHandler handles POST /mget, reading several keys at once (can go to
+// any node)
+// Other nodes are asked for all the keys in one request each.
+func (h *Handler) MGetHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := batch.DecodeKeys(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values, err := h.replicator.ReadBatch(keys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	results := make([]map[string]interface{}, len(keys))
+	for i, kv := range values {
+		if kv == nil {
+			results[i] = batch.ErrorResult(keys[i], http.StatusNotFound, "key not found")
+			continue
+		}
+		results[i] = batch.Result(http.StatusOK, map[string]interface{}{
+			"key":     kv.Key,
+			"value":   string(kv.Value),
+			"version": kv.Version,
+		})
+	}
+	batch.WriteResults(w, results)
+}
+
+// MSetHandler handles POST /mset, writing several keys at once (only from
+// Leader)
+// The writes are replicated concurrently, so with streaming replication each
+// follower receives them in a few batches; each key succeeds or fails on its
+// own.
+func (h *Handler) MSetHandler(w http.ResponseWriter, r *http.Request) {
+	pairs, err := batch.DecodePairs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only Leader can accept writes
+	if !h.config.IsLeader() {
+		http.Error(w, "only leader accepts write requests", http.StatusForbidden)
+		return
+	}
+
+	results := make([]map[string]interface{}, len(pairs))
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		wg.Add(1)
+		go func(i int, pair batch.Pair) {
+			defer wg.Done()
+			result, err := h.replicator.Write(pair.Key, []byte(pair.Value))
+			if err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, kvstore.ErrValueTooLarge) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				results[i] = batch.ErrorResult(pair.Key, status, err.Error())
+				return
+			}
+			results[i] = batch.Result(http.StatusCreated, map[string]interface{}{
+				"key":     pair.Key,
+				"value":   pair.Value,
+				"version": result.Version,
+			})
+		}(i, pair)
+	}
+	wg.Wait()
+	batch.WriteResults(w, results)
+}
+
+// IncrHandler handles POST /incr, adding a delta to an integer value (only
+// from Leader)
+func (h *Handler) IncrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, false)
+}
+
+// DecrHandler handles POST /decr, subtracting a delta from an integer value
+// (only from Leader)
+func (h *Handler) DecrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, true)
+}
+
+// increment updates the value on the Leader, which reads and writes it
+// atomically, and replicates the new value based on current W value
+func (h *Handler) increment(w http.ResponseWriter, r *http.Request, decr bool) {
+	op, err := numeric.Decode(r, decr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only Leader can accept writes
+	if !h.config.IsLeader() {
+		http.Error(w, "only leader accepts write requests", http.StatusForbidden)
+		return
+	}
+
+	kv, err := h.replicator.Update(op.Key, op.Apply)
+	if err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	numeric.WriteResult(w, kv)
+}
+
+// PutValueHandler handles PUT /kv/{key} with the raw value as the body
+// (only from Leader)
+func (h *Handler) PutValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if !h.config.IsLeader() {
+		http.Error(w, "only leader accepts write requests", http.StatusForbidden)
+		return
+	}
+
+	value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.replicator.Write(key, value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     key,
+		"size":    len(value),
+		"version": result.Version,
+		"status":  "created",
+	})
+}
+
+// GetValueHandler handles GET /kv/{key}, returning the raw value (can go to
+// any node)
+func (h *Handler) GetValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	blob.WriteValue(w, kv.Value, kv.Version)
+}
+
+// KeyGetHandler handles GET /v1/keys/{key} (can go to any node)
+// Honors If-None-Match (304 Not Modified) and If-Match (412)
+func (h *Handler) KeyGetHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	if status := rest.ParsePreconditions(r).ReadStatus(kv); status != 0 {
+		rest.WriteStatus(w, status, kv)
+		return
+	}
+	if kv == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	rest.WriteValue(w, kv)
+}
+
+// KeyPutHandler handles PUT /v1/keys/{key} (only on Leader; other nodes
+// redirect to it)
+// With If-Match or If-None-Match the value is only written if the Leader's
+// current version satisfies them (412 otherwise)
+func (h *Handler) KeyPutHandler(w http.ResponseWriter, r *http.Request) {
+	if h.redirectToLeader(w, r) {
+		return
+	}
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	value, err := rest.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.replicator.WriteIf(ReplicateWriteRequest{Key: key, Value: value}, rest.ParsePreconditions(r).Condition())
+	if err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	rest.WriteStored(w, key, result.Version)
+}
+
+// KeyDeleteHandler handles DELETE /v1/keys/{key} (only on Leader; other
+// nodes redirect to it)
+// Conditional like KeyPutHandler
+func (h *Handler) KeyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.redirectToLeader(w, r) {
+		return
+	}
+
+	write := ReplicateWriteRequest{Key: key, Deleted: true}
+	if _, err := h.replicator.WriteIf(write, rest.ParsePreconditions(r).Condition()); err != nil {
+		rest.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// redirectToLeader answers a write received by a follower with a 307
+// Temporary Redirect to the same resource on Leader
+// Returns false on Leader.
+func (h *Handler) redirectToLeader(w http.ResponseWriter, r *http.Request) bool {
+	if h.config.IsLeader() {
+		return false
+	}
+	http.Redirect(w, r, "http://"+h.config.LeaderAddr+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	return true
+}
+
 // LocalReadHandler handles local reads (for testing)
 func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
-	if key == "" {
-		http.Error(w, "key parameter is required", http.StatusBadRequest)
+	if !rest.ValidKey(w, key) {
 		return
 	}
 
@@ -107,7 +374,7 @@ func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"key":     kv.Key,
-		"value":   kv.Value,
+		"value":   string(kv.Value),
 		"version": kv.Version,
 	})
 }
@@ -116,16 +383,30 @@ func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ReplicateWriteHandler(w http.ResponseWriter, r *http.Request) {
 	var req ReplicateWriteRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if blob.IsRaw(r) {
+		// Large values arrive raw, with the metadata in headers
+		key, version, err := blob.Metadata(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req = ReplicateWriteRequest{Key: key, Value: value, Version: version}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Follower sleeps 100ms when receiving update before responding
-	time.Sleep(100 * time.Millisecond)
+	// Injected receive delay (Follower sleeps 100ms when receiving update
+	// before responding in the homework profile)
+	h.config.GetFaults().Delay(fault.StageReceiveWrite)
 
 	// Set the value with the provided version
-	if err := h.store.SetWithVersion(req.Key, req.Value, req.Version); err != nil {
+	if err := h.apply(req); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ReplicateWriteResponse{
@@ -143,6 +424,69 @@ func (h *Handler) ReplicateWriteHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// apply applies a write replicated from Leader
+func (h *Handler) apply(write ReplicateWriteRequest) error {
+	if write.Deleted {
+		_, err := h.store.DeleteWithVersion(write.Key, write.Version)
+		return err
+	}
+	_, err := h.store.SetWithVersion(write.Key, write.Value, write.Version)
+	return err
+}
+
+// ReplicateStreamHandler handles the long-lived replication stream from Leader
+// Batches are applied in the order they arrive and acknowledged back over
+// the same connection, coalescing acks when several batches are applied
+// before the previous ack has been written
+func (h *Handler) ReplicateStreamHandler(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	if err := rc.EnableFullDuplex(); err != nil {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	acks := make(chan StreamAck, DefaultStreamMaxInFlight)
+	acksDone := make(chan struct{})
+	go func() {
+		defer close(acksDone)
+		encoder := json.NewEncoder(w)
+		writeStreamAcks(func(ack StreamAck) error { return encoder.Encode(ack) }, rc.Flush, acks)
+	}()
+
+	decoder := json.NewDecoder(r.Body)
+	for {
+		var batch StreamBatch
+		if err := decoder.Decode(&batch); err != nil {
+			break
+		}
+		acks <- h.applyBatch(batch)
+	}
+
+	close(acks)
+	<-acksDone
+}
+
+// applyBatch applies a batch of writes from a replication stream
+func (h *Handler) applyBatch(batch StreamBatch) StreamAck {
+	// Injected receive delay, applied once per batch
+	h.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	ack := StreamAck{Seq: batch.Seq, Success: true}
+	for _, write := range batch.Writes {
+		if err := h.apply(write); err != nil {
+			ack.Success = false
+			ack.Error = err.Error()
+		}
+	}
+	return ack
+}
+
 // InternalReadHandler handles internal read requests from other nodes
 func (h *Handler) InternalReadHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
@@ -151,31 +495,69 @@ func (h *Handler) InternalReadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Follower sleeps 50ms when receiving read request
+	// Injected read delay (Follower sleeps 50ms when receiving read request
+	// in the homework profile)
 	if !h.config.IsLeader() {
-		time.Sleep(50 * time.Millisecond)
+		h.config.GetFaults().Delay(fault.StageReceiveRead)
 	}
 
-	kv, exists := h.store.Get(key)
-	if !exists {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ReadResponse{
-			Exists: false,
-		})
-		return
+	response := h.internalRead(key)
+	status := http.StatusOK
+	if !response.Exists {
+		status = http.StatusNotFound
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(ReadResponse{
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// internalRead reads a key from this node's store for another node
+func (h *Handler) internalRead(key string) ReadResponse {
+	kv, exists := h.store.Get(key)
+	if !exists {
+		return ReadResponse{Exists: false}
+	}
+	return ReadResponse{
 		Key:     kv.Key,
 		Value:   kv.Value,
 		Version: kv.Version,
+		Deleted: kv.Deleted,
 		Exists:  true,
+	}
+}
+
+// InternalReadBatchHandler handles internal reads of several keys from other
+// nodes
+func (h *Handler) InternalReadBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReadBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReadBatchResponse{
+		Responses: h.internalReadBatch(req.Keys),
 	})
 }
 
+// internalReadBatch reads several keys from this node's store for another
+// node
+func (h *Handler) internalReadBatch(keys []string) []ReadResponse {
+	// Injected read delay, once per batch like a single read
+	if !h.config.IsLeader() {
+		h.config.GetFaults().Delay(fault.StageReceiveRead)
+	}
+
+	responses := make([]ReadResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = h.internalRead(key)
+	}
+	return responses
+}
+
 // ConfigHandler handles configuration requests
 func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" {
@@ -184,11 +566,12 @@ func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"node_id": h.config.NodeID,
-			"role":    h.config.Role,
-			"n":       h.config.N,
-			"r":       readR,
-			"w":       writeW,
+			"node_id":        h.config.NodeID,
+			"role":           h.config.Role,
+			"n":              h.config.N,
+			"r":              readR,
+			"w":              writeW,
+			"config_version": h.config.GetConfigVersion(),
 		})
 		return
 	}
@@ -210,14 +593,31 @@ func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		h.config.SetReplicationParams(req.R, req.W)
+		// Only Leader can change the cluster configuration
+		if !h.config.IsLeader() {
+			http.Error(w, "only leader accepts configuration changes", http.StatusForbidden)
+			return
+		}
+
+		// Apply locally and replicate to all followers
+		version, applied, err := h.replicator.UpdateConfig(req.R, req.W)
+		if errors.Is(err, ErrConfigSuperseded) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status": "configuration updated",
-			"r":      req.R,
-			"w":      req.W,
+			"status":         "configuration updated",
+			"r":              req.R,
+			"w":              req.W,
+			"config_version": version,
+			"applied_nodes":  applied,
 		})
 		return
 	}
@@ -225,6 +625,86 @@ func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 }
 
+// ReplicateConfigHandler handles internal configuration replication requests from Leader
+func (h *Handler) ReplicateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req ClusterConfig
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.R < 1 || req.R > h.config.N || req.W < 1 || req.W > h.config.N {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReplicateConfigResponse{
+			Success: false,
+			Error:   "R and W must be between 1 and N",
+		})
+		return
+	}
+
+	// Older versions are ignored; the Leader learns the current one from
+	// the response
+	applied := h.config.ApplyReplicationParams(req.R, req.W, req.Version)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReplicateConfigResponse{
+		Success: true,
+		Applied: applied,
+		Version: h.config.GetConfigVersion(),
+	})
+}
+
+// InternalConfigHandler returns this node's versioned cluster configuration
+func (h *Handler) InternalConfigHandler(w http.ResponseWriter, r *http.Request) {
+	readR, writeW := h.config.GetReplicationParams()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ClusterConfig{
+		R:       readR,
+		W:       writeW,
+		Version: h.config.GetConfigVersion(),
+	})
+}
+
+// SyncConfig pulls the current cluster configuration from the other nodes,
+// retrying until at least one of them responds or attempts run out
+func (h *Handler) SyncConfig(attempts int, interval time.Duration) {
+	for i := 0; i < attempts; i++ {
+		if h.replicator.SyncConfig() {
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// ClusterStatusHandler reports replication progress
+// Every node reports its own applied version and read latencies; the Leader additionally
+// reports per-follower lag, last ack time, in-flight requests and failures
+func (h *Handler) ClusterStatusHandler(w http.ResponseWriter, r *http.Request) {
+	readR, writeW := h.config.GetReplicationParams()
+	hedged, _, _ := h.config.GetHedging()
+	status := map[string]interface{}{
+		"node_id":         h.config.NodeID,
+		"role":            h.config.Role,
+		"applied_version": h.store.GetVersion(),
+		"config_version":  h.config.GetConfigVersion(),
+		"r":               readR,
+		"w":               writeW,
+		"hedged_reads":    hedged,
+		"read_latency":    h.replicator.GetReadLatency(),
+	}
+	if h.config.IsLeader() {
+		status["followers"] = h.replicator.FollowerStatuses()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
+
 // HealthHandler provides a health check endpoint
 func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2,6 +2,12 @@ package leaderfollower
 
 import (
 	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/gossip"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
 )
 
 // NodeRole represents the role of a node in the cluster
@@ -24,6 +30,20 @@ type Config struct {
 	N             int      // Total number of nodes (default: 5)
 	R             int      // Read quorum size
 	W             int      // Write quorum size
+	ConfigVersion int64    // Version of the cluster-wide R/W configuration
+
+	StreamReplication bool            // Replicate over long-lived streams instead of one request per write
+	StreamMaxBatch    int             // Maximum writes per stream batch
+	StreamMaxInFlight int             // Maximum unacknowledged batches per follower
+	Faults            *fault.Injector // Injected delays and faults (nil = none)
+	GRPC              *rpc.Pool       // gRPC transport for writes and reads between nodes (nil = HTTP JSON)
+
+	Detector   *gossip.Detector    // Live view of peer state (nil = every peer alive)
+	Namespaces *namespace.Registry // Settings of namespaced keys (nil = none)
+
+	HedgedReads     bool          // Send speculative reads to extra nodes when replies are slow
+	HedgeDelay      time.Duration // Wait before a speculative read (0 = observed percentile)
+	HedgePercentile float64       // Latency percentile used as the hedge delay
 }
 
 // NewConfig creates a new configuration
@@ -39,6 +59,10 @@ func NewConfig(nodeID string, role NodeRole, myAddr string, leaderAddr string, f
 		N:             len(allAddrs),
 		R:             1, // Default
 		W:             1, // Default
+
+		StreamReplication: true,
+		StreamMaxBatch:    DefaultStreamMaxBatch,
+		StreamMaxInFlight: DefaultStreamMaxInFlight,
 	}
 }
 
@@ -57,6 +81,39 @@ func (c *Config) SetReplicationParams(r, w int) {
 	c.W = w
 }
 
+// UpdateReplicationParams sets R and W values and bumps the config version
+// past both the current one and newest (a version seen on another node)
+// Returns the new config version (used by the Leader)
+func (c *Config) UpdateReplicationParams(r, w int, newest int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.R = r
+	c.W = w
+	c.ConfigVersion = max(c.ConfigVersion, newest) + 1
+	return c.ConfigVersion
+}
+
+// ApplyReplicationParams sets R and W values only if the given version is newer
+// Returns true if the configuration was applied (used by replicas)
+func (c *Config) ApplyReplicationParams(r, w int, version int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version <= c.ConfigVersion {
+		return false
+	}
+	c.R = r
+	c.W = w
+	c.ConfigVersion = version
+	return true
+}
+
+// GetConfigVersion returns the current config version
+func (c *Config) GetConfigVersion() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ConfigVersion
+}
+
 // GetReplicationParams returns current R and W values
 func (c *Config) GetReplicationParams() (r, w int) {
 	c.mu.RLock()
@@ -64,6 +121,39 @@ func (c *Config) GetReplicationParams() (r, w int) {
 	return c.R, c.W
 }
 
+// GetReplicationParamsFor returns R and W for a key: its namespace's
+// consistency level, or the current R and W
+// May read the namespace registry, so it must not be called while holding
+// the replication lock.
+func (c *Config) GetReplicationParamsFor(key string) (r, w int) {
+	c.mu.RLock()
+	namespaces, n := c.Namespaces, c.N
+	c.mu.RUnlock()
+
+	if settings, ok := namespaces.Lookup(key); ok {
+		if r, w, ok := settings.Quorums(n); ok {
+			return r, w
+		}
+	}
+	return c.GetReplicationParams()
+}
+
+// SetStreamParams configures streaming replication
+func (c *Config) SetStreamParams(enabled bool, maxBatch, maxInFlight int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.StreamReplication = enabled
+	c.StreamMaxBatch = maxBatch
+	c.StreamMaxInFlight = maxInFlight
+}
+
+// GetStreamParams returns the streaming replication settings
+func (c *Config) GetStreamParams() (enabled bool, maxBatch, maxInFlight int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StreamReplication, c.StreamMaxBatch, c.StreamMaxInFlight
+}
+
 // IsLeader returns true if this node is the leader
 func (c *Config) IsLeader() bool {
 	c.mu.RLock()
@@ -85,3 +175,67 @@ func (c *Config) GetAllNodeAddrs() []string {
 	return append([]string{}, c.AllNodeAddrs...)
 }
 
+// SetFaults sets the fault injector used by this node
+func (c *Config) SetFaults(faults *fault.Injector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Faults = faults
+}
+
+// GetFaults returns the fault injector used by this node
+func (c *Config) GetFaults() *fault.Injector {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Faults
+}
+
+// SetGRPC makes writes and reads between nodes use gRPC connections from pool
+func (c *Config) SetGRPC(pool *rpc.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.GRPC = pool
+}
+
+// GetGRPC returns the gRPC connection pool, or nil when nodes talk HTTP JSON
+func (c *Config) GetGRPC() *rpc.Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.GRPC
+}
+
+// SetHedging sets the hedged read settings
+func (c *Config) SetHedging(enabled bool, delay time.Duration, percentile float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.HedgedReads = enabled
+	c.HedgeDelay = delay
+	c.HedgePercentile = percentile
+}
+
+// GetHedging returns the hedged read settings
+func (c *Config) GetHedging() (enabled bool, delay time.Duration, percentile float64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.HedgedReads, c.HedgeDelay, c.HedgePercentile
+}
+
+// SetDetector sets the failure detector used by this node
+func (c *Config) SetDetector(detector *gossip.Detector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Detector = detector
+}
+
+// GetDetector returns the failure detector used by this node
+func (c *Config) GetDetector() *gossip.Detector {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Detector
+}
+
+// SetNamespaces sets the namespace registry used by this node
+func (c *Config) SetNamespaces(namespaces *namespace.Registry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Namespaces = namespaces
+}
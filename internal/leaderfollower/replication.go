@@ -1,29 +1,60 @@
 package leaderfollower
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
+	"time"
 
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/hedge"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
 )
 
 // ReplicationManager handles replication strategies
 type ReplicationManager struct {
-	store    *kvstore.Store
-	config   *Config
-	client   *ReplicationClient
-	mu       sync.Mutex
+	store   *kvstore.Store
+	config  *Config
+	client  *ReplicationClient
+	streams map[string]*FollowerStream // Leader only; nil when streaming is disabled
+	tracker *FollowerTracker           // Leader only; per-follower replication progress
+	hedger  *hedge.Hedger              // Tracks node read latencies and hedges slow reads
+	mu      sync.RWMutex
+	orderMu sync.Mutex // Keeps version order and send order identical
 }
 
 // NewReplicationManager creates a new replication manager
+// On the Leader with streaming enabled, one replication stream is opened per follower
 func NewReplicationManager(store *kvstore.Store, config *Config) *ReplicationManager {
-	return &ReplicationManager{
-		store:  store,
-		config: config,
-		client: NewReplicationClient(),
+	_, hedgeDelay, hedgePercentile := config.GetHedging()
+	rm := &ReplicationManager{
+		store:   store,
+		config:  config,
+		client:  NewReplicationClient(config.GetFaults(), config.GetDetector(), config.GetGRPC()),
+		tracker: NewFollowerTracker(config.GetFollowerAddrs()),
+		hedger:  hedge.NewHedger(hedgeDelay, hedgePercentile),
 	}
+
+	streaming, maxBatch, maxInFlight := config.GetStreamParams()
+	if config.IsLeader() && streaming {
+		rm.streams = make(map[string]*FollowerStream)
+		for i, addr := range config.GetFollowerAddrs() {
+			// Leader sleeps after each message (except to the first follower)
+			stream := NewFollowerStream(addr, i > 0, config.GetFaults(), config.GetGRPC(), maxBatch, maxInFlight)
+			stream.Start()
+			rm.streams[addr] = stream
+		}
+	}
+
+	return rm
 }
 
+// ErrKeyNotFound is returned by reads of a key that does not exist (or
+// whose most recent version is a delete)
+var ErrKeyNotFound = errors.New("key not found")
+
 // WriteResult represents the result of a write operation
 type WriteResult struct {
 	Version int64
@@ -31,39 +62,84 @@ type WriteResult struct {
 	Error   error
 }
 
-// WriteStrategyW5R1 implements W=5, R=1 strategy
-// Write: All nodes must be updated before responding
-func (rm *ReplicationManager) WriteStrategyW5R1(key, value string) (*WriteResult, error) {
+// applyFunc applies a client write to the Leader's store, setting its
+// version (and, for updates, its value)
+type applyFunc func(write *ReplicateWriteRequest) error
+
+// setAndReplicate applies a write (a value or a delete) on the Leader with
+// apply and sends it to all followers
+// Returns the version, a channel receiving one response per follower and the
+// number of followers
+func (rm *ReplicationManager) setAndReplicate(write ReplicateWriteRequest, apply applyFunc) (int64, <-chan *ReplicateWriteResponse, int, error) {
 	if !rm.config.IsLeader() {
-		return nil, fmt.Errorf("only leader can perform writes")
+		return 0, nil, 0, fmt.Errorf("only leader can perform writes")
 	}
 
-	// Leader sets the value locally first
-	version, err := rm.store.Set(key, value)
-	if err != nil {
-		return nil, err
+	// Versions must be handed to the streams in the order they are assigned
+	rm.orderMu.Lock()
+	defer rm.orderMu.Unlock()
+
+	// Leader applies the write locally first
+	if err := apply(&write); err != nil {
+		return 0, nil, 0, err
 	}
+	version := write.Version
 
-	// Replicate to all followers
 	followerAddrs := rm.config.GetFollowerAddrs()
 	results := make(chan *ReplicateWriteResponse, len(followerAddrs))
 
-	// Send replication requests to all followers
+	detector := rm.config.GetDetector()
 	for i, addr := range followerAddrs {
+		rm.tracker.Sent(addr, version)
+
+		if err := detector.Check(addr); err != nil {
+			// Don't wait on a follower the failure detector considers dead;
+			// anti-entropy repairs it once it is back
+			response := &ReplicateWriteResponse{Success: false, Error: err.Error()}
+			rm.tracker.Record(addr, version, response)
+			results <- response
+			continue
+		}
+
+		// Large values skip the stream and are sent raw in their own request,
+		// so batches stay small; followers keep the highest version of a key
+		// whichever way its writes arrive
+		if stream, ok := rm.streams[addr]; ok && len(write.Value) < blob.StreamThreshold {
+			// Enqueue synchronously so the stream sees writes in version order
+			done := stream.Enqueue(write)
+			go func(addr string) {
+				response := <-done
+				rm.tracker.Record(addr, version, response)
+				results <- response
+			}(addr)
+			continue
+		}
+
 		go func(addr string, index int) {
 			// Leader sleeps 200ms after each message (except the first one)
-			response, err := rm.client.ReplicateWrite(addr, key, value, version, index > 0)
+			response, err := rm.client.ReplicateWrite(addr, write, index > 0)
 			if err != nil {
-				results <- &ReplicateWriteResponse{Success: false, Error: err.Error()}
-				return
+				response = &ReplicateWriteResponse{Success: false, Error: err.Error()}
 			}
+			rm.tracker.Record(addr, version, response)
 			results <- response
 		}(addr, i)
 	}
 
+	return version, results, len(followerAddrs), nil
+}
+
+// WriteStrategyW5R1 implements W=5, R=1 strategy
+// Write: All nodes must be updated before responding
+func (rm *ReplicationManager) WriteStrategyW5R1(write ReplicateWriteRequest, apply applyFunc) (*WriteResult, error) {
+	version, results, followers, err := rm.setAndReplicate(write, apply)
+	if err != nil {
+		return nil, err
+	}
+
 	// Wait for all followers to confirm
 	successCount := 1 // Leader already updated
-	for i := 0; i < len(followerAddrs); i++ {
+	for i := 0; i < followers; i++ {
 		result := <-results
 		if result.Success {
 			successCount++
@@ -80,71 +156,39 @@ func (rm *ReplicationManager) WriteStrategyW5R1(key, value string) (*WriteResult
 
 // WriteStrategyW1R5 implements W=1, R=5 strategy
 // Write: Only Leader needs to be updated
-func (rm *ReplicationManager) WriteStrategyW1R5(key, value string) (*WriteResult, error) {
-	if !rm.config.IsLeader() {
-		return nil, fmt.Errorf("only leader can perform writes")
-	}
-
+func (rm *ReplicationManager) WriteStrategyW1R5(write ReplicateWriteRequest, apply applyFunc) (*WriteResult, error) {
 	// Leader sets the value locally and responds immediately
-	version, err := rm.store.Set(key, value)
+	// Replication to followers continues asynchronously (don't wait)
+	version, _, _, err := rm.setAndReplicate(write, apply)
 	if err != nil {
 		return nil, err
 	}
 
-	// Replicate to followers asynchronously (don't wait)
-	followerAddrs := rm.config.GetFollowerAddrs()
-	for i, addr := range followerAddrs {
-		go func(addr string, index int) {
-			rm.client.ReplicateWrite(addr, key, value, version, index > 0)
-		}(addr, i)
-	}
-
 	return &WriteResult{Version: version, Success: true}, nil
 }
 
 // WriteStrategyW3R3 implements W=3, R=3 quorum strategy
 // Write: 3 nodes (including Leader) must be updated
-func (rm *ReplicationManager) WriteStrategyW3R3(key, value string) (*WriteResult, error) {
-	if !rm.config.IsLeader() {
-		return nil, fmt.Errorf("only leader can perform writes")
-	}
-
-	// Leader sets the value locally first
-	version, err := rm.store.Set(key, value)
+func (rm *ReplicationManager) WriteStrategyW3R3(write ReplicateWriteRequest, apply applyFunc) (*WriteResult, error) {
+	version, results, followers, err := rm.setAndReplicate(write, apply)
 	if err != nil {
 		return nil, err
 	}
 
-	// Replicate to followers
-	followerAddrs := rm.config.GetFollowerAddrs()
-	results := make(chan *ReplicateWriteResponse, len(followerAddrs))
-
-	// Send replication requests to all followers
-	for i, addr := range followerAddrs {
-		go func(addr string, index int) {
-			response, err := rm.client.ReplicateWrite(addr, key, value, version, index > 0)
-			if err != nil {
-				results <- &ReplicateWriteResponse{Success: false, Error: err.Error()}
-				return
-			}
-			results <- response
-		}(addr, i)
-	}
-
 	// Wait for W-1 followers to confirm (Leader already counts as 1)
 	successCount := 1 // Leader already updated
-	for i := 0; i < len(followerAddrs); i++ {
+	for i := 0; i < followers; i++ {
 		result := <-results
 		if result.Success {
 			successCount++
-			if successCount >= rm.config.W {
+			if successCount >= 3 {
 				break // We have quorum
 			}
 		}
 	}
 
-	if successCount < rm.config.W {
-		return nil, fmt.Errorf("failed to achieve write quorum: %d/%d succeeded", successCount, rm.config.W)
+	if successCount < 3 {
+		return nil, fmt.Errorf("failed to achieve write quorum: %d/%d succeeded", successCount, 3)
 	}
 
 	return &WriteResult{Version: version, Success: true}, nil
@@ -154,7 +198,7 @@ func (rm *ReplicationManager) WriteStrategyW3R3(key, value string) (*WriteResult
 func (rm *ReplicationManager) ReadStrategyR1(key string) (*kvstore.KeyValue, error) {
 	kv, exists := rm.store.Get(key)
 	if !exists {
-		return nil, fmt.Errorf("key not found")
+		return nil, ErrKeyNotFound
 	}
 	return kv, nil
 }
@@ -187,6 +231,7 @@ func (rm *ReplicationManager) ReadStrategyR5(key string) (*kvstore.KeyValue, err
 					Key:     response.Key,
 					Value:   response.Value,
 					Version: response.Version,
+					Deleted: response.Deleted,
 				}
 			}
 		}(addr)
@@ -202,7 +247,7 @@ func (rm *ReplicationManager) ReadStrategyR5(key string) (*kvstore.KeyValue, err
 	}
 
 	if len(responses) == 0 {
-		return nil, fmt.Errorf("key not found")
+		return nil, ErrKeyNotFound
 	}
 
 	// Return the most recent version
@@ -211,6 +256,10 @@ func (rm *ReplicationManager) ReadStrategyR5(key string) (*kvstore.KeyValue, err
 
 // ReadStrategyR3 reads from 3 nodes (quorum) and returns most recent
 func (rm *ReplicationManager) ReadStrategyR3(key string) (*kvstore.KeyValue, error) {
+	if hedged, _, _ := rm.config.GetHedging(); hedged {
+		return rm.readHedged(key, 3)
+	}
+
 	allAddrs := rm.config.GetAllNodeAddrs()
 	results := make(chan *kvstore.KeyValue, len(allAddrs))
 
@@ -237,6 +286,7 @@ func (rm *ReplicationManager) ReadStrategyR3(key string) (*kvstore.KeyValue, err
 					Key:     response.Key,
 					Value:   response.Value,
 					Version: response.Version,
+					Deleted: response.Deleted,
 				}
 			}
 		}(addr)
@@ -244,7 +294,7 @@ func (rm *ReplicationManager) ReadStrategyR3(key string) (*kvstore.KeyValue, err
 
 	// Collect R responses (quorum)
 	var responses []*kvstore.KeyValue
-	needed := rm.config.R
+	needed := 3
 	for i := 0; i < len(allAddrs) && len(responses) < needed; i++ {
 		kv := <-results
 		if kv != nil {
@@ -253,13 +303,64 @@ func (rm *ReplicationManager) ReadStrategyR3(key string) (*kvstore.KeyValue, err
 	}
 
 	if len(responses) == 0 {
-		return nil, fmt.Errorf("key not found")
+		return nil, ErrKeyNotFound
+	}
+
+	// Return the most recent version
+	return getMostRecentValue(responses), nil
+}
+
+// readHedged reads from R nodes (including this one) and returns the most
+// recent value
+// Other nodes are asked fastest first; when they are slow to reply,
+// speculative reads go to the rest and the stragglers are cancelled.
+func (rm *ReplicationManager) readHedged(key string, r int) (*kvstore.KeyValue, error) {
+	myAddr := rm.config.GetMyAddr()
+	var others []string
+	for _, addr := range rm.config.GetAllNodeAddrs() {
+		if addr != myAddr {
+			others = append(others, addr)
+		}
+	}
+
+	// The local value is the first response
+	var responses []*kvstore.KeyValue
+	if kv, exists := rm.store.Get(key); exists {
+		responses = append(responses, kv)
+	}
+
+	// Read from remote nodes (Follower sleeps 50ms)
+	replies := rm.hedger.Do(others, r-1, func(ctx context.Context, addr string) (interface{}, error) {
+		return rm.client.ReadFromNodeContext(ctx, addr, key, true)
+	})
+	if len(replies) < r-1 {
+		return nil, fmt.Errorf("read quorum not reached: %d/%d nodes responded", len(replies)+1, r)
+	}
+	for _, reply := range replies {
+		if response := reply.Value.(*ReadResponse); response.Exists {
+			responses = append(responses, &kvstore.KeyValue{
+				Key:     response.Key,
+				Value:   response.Value,
+				Version: response.Version,
+				Deleted: response.Deleted,
+			})
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil, ErrKeyNotFound
 	}
 
 	// Return the most recent version
 	return getMostRecentValue(responses), nil
 }
 
+// GetReadLatency returns the observed node read latencies and hedging
+// counters
+func (rm *ReplicationManager) GetReadLatency() hedge.Stats {
+	return rm.hedger.Stats()
+}
+
 // getMostRecentValue compares multiple KeyValue responses and returns the one with highest version
 func getMostRecentValue(responses []*kvstore.KeyValue) *kvstore.KeyValue {
 	if len(responses) == 0 {
@@ -276,41 +377,335 @@ func getMostRecentValue(responses []*kvstore.KeyValue) *kvstore.KeyValue {
 	return mostRecent
 }
 
-// Write performs a write operation based on current W value
-func (rm *ReplicationManager) Write(key, value string) (*WriteResult, error) {
+// lock acquires the replication lock for a client read or write
+// With streaming replication the streams keep writes ordered, so operations
+// only share the lock (which still excludes configuration changes) and many
+// writes can be in flight at once. Otherwise operations are fully serialized.
+// Returns the matching unlock function.
+func (rm *ReplicationManager) lock() func() {
+	if rm.streams != nil {
+		rm.mu.RLock()
+		return rm.mu.RUnlock
+	}
 	rm.mu.Lock()
-	defer rm.mu.Unlock()
+	return rm.mu.Unlock
+}
 
-	_, w := rm.config.GetReplicationParams()
+// Write performs a write operation based on current W value
+func (rm *ReplicationManager) Write(key string, value []byte) (*WriteResult, error) {
+	return rm.WriteIf(ReplicateWriteRequest{Key: key, Value: value}, nil)
+}
+
+// Delete deletes a key based on current W value
+func (rm *ReplicationManager) Delete(key string) (*WriteResult, error) {
+	return rm.WriteIf(ReplicateWriteRequest{Key: key, Deleted: true}, nil)
+}
+
+// WriteIf performs a write (a value or a delete) based on current W value
+// if condition (when not nil) accepts the Leader's current value
+// The Leader orders all writes, so the check is atomic with the write.
+func (rm *ReplicationManager) WriteIf(write ReplicateWriteRequest, condition kvstore.Condition) (*WriteResult, error) {
+	return rm.write(write, func(write *ReplicateWriteRequest) (err error) {
+		if write.Deleted {
+			write.Version, err = rm.store.DeleteIf(write.Key, condition)
+		} else {
+			write.Version, err = rm.store.SetIf(write.Key, write.Value, condition)
+		}
+		return err
+	})
+}
+
+// Update writes the value update computes from the Leader's current value
+// of a key based on current W value
+// The Leader orders all writes, so the read and the write are atomic; the
+// new value is replicated like any other write.
+func (rm *ReplicationManager) Update(key string, update kvstore.UpdateFunc) (*kvstore.KeyValue, error) {
+	var kv *kvstore.KeyValue
+	_, err := rm.write(ReplicateWriteRequest{Key: key}, func(write *ReplicateWriteRequest) (err error) {
+		if kv, err = rm.store.Update(write.Key, update); err != nil {
+			return err
+		}
+		write.Value, write.Version = kv.Value, kv.Version
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kv, nil
+}
+
+// write performs a client write based on current W value, applying it on
+// the Leader with apply
+// The W of a namespaced key is its namespace's consistency level.
+func (rm *ReplicationManager) write(write ReplicateWriteRequest, apply applyFunc) (*WriteResult, error) {
+	_, w := rm.config.GetReplicationParamsFor(write.Key)
+
+	defer rm.lock()()
 
 	switch w {
 	case 5:
-		return rm.WriteStrategyW5R1(key, value)
+		return rm.WriteStrategyW5R1(write, apply)
 	case 1:
-		return rm.WriteStrategyW1R5(key, value)
+		return rm.WriteStrategyW1R5(write, apply)
 	case 3:
-		return rm.WriteStrategyW3R3(key, value)
+		return rm.WriteStrategyW3R3(write, apply)
 	default:
 		return nil, fmt.Errorf("unsupported W value: %d", w)
 	}
 }
 
 // Read performs a read operation based on current R value
+// A key whose most recent version is a delete is not found. The R of a
+// namespaced key is its namespace's consistency level.
 func (rm *ReplicationManager) Read(key string) (*kvstore.KeyValue, error) {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
+	r, _ := rm.config.GetReplicationParamsFor(key)
 
-	r, _ := rm.config.GetReplicationParams()
+	defer rm.lock()()
 
+	var kv *kvstore.KeyValue
+	var err error
 	switch r {
 	case 1:
-		return rm.ReadStrategyR1(key)
+		kv, err = rm.ReadStrategyR1(key)
 	case 5:
-		return rm.ReadStrategyR5(key)
+		kv, err = rm.ReadStrategyR5(key)
 	case 3:
-		return rm.ReadStrategyR3(key)
+		kv, err = rm.ReadStrategyR3(key)
 	default:
 		return nil, fmt.Errorf("unsupported R value: %d", r)
 	}
+	if err == nil && kv.Deleted {
+		return nil, ErrKeyNotFound
+	}
+	return kv, err
+}
+
+// ReadBatch reads several keys based on current R value
+// Each other node is asked for all the keys in one request, and the most
+// recent version of each key among R nodes (including this one) is
+// returned. Keys that are not found (or deleted) are nil.
+func (rm *ReplicationManager) ReadBatch(keys []string) ([]*kvstore.KeyValue, error) {
+	defer rm.lock()()
+
+	r, _ := rm.config.GetReplicationParams()
+
+	// The local values are the first response
+	responses := make([][]*kvstore.KeyValue, len(keys))
+	for i, key := range keys {
+		if kv, exists := rm.store.Get(key); exists {
+			responses[i] = append(responses[i], kv)
+		}
+	}
+
+	if r > 1 {
+		myAddr := rm.config.GetMyAddr()
+		var others []string
+		for _, addr := range rm.config.GetAllNodeAddrs() {
+			if addr != myAddr {
+				others = append(others, addr)
+			}
+		}
+
+		// Read from remote nodes (Follower sleeps 50ms)
+		results := make(chan []ReadResponse, len(others))
+		for _, addr := range others {
+			go func(addr string) {
+				reply, err := rm.client.ReadBatch(addr, keys, true)
+				if err != nil {
+					results <- nil
+					return
+				}
+				results <- reply
+			}(addr)
+		}
+
+		// Collect R-1 replies (quorum, with this node)
+		replies := 0
+		for i := 0; i < len(others) && replies < r-1; i++ {
+			reply := <-results
+			if reply == nil {
+				continue
+			}
+			replies++
+			for j, response := range reply {
+				if response.Exists {
+					responses[j] = append(responses[j], &kvstore.KeyValue{
+						Key:     response.Key,
+						Value:   response.Value,
+						Version: response.Version,
+						Deleted: response.Deleted,
+					})
+				}
+			}
+		}
+		if replies < r-1 {
+			return nil, fmt.Errorf("read quorum not reached: %d/%d nodes responded", replies+1, r)
+		}
+	}
+
+	values := make([]*kvstore.KeyValue, len(keys))
+	for i := range keys {
+		// Return the most recent version (not found if it is a delete)
+		if kv := getMostRecentValue(responses[i]); kv != nil && !kv.Deleted {
+			values[i] = kv
+		}
+	}
+	return values, nil
 }
 
+// Configuration change settings
+const (
+	// configRetryInterval is how long the Leader waits before re-sending a
+	// configuration change to a follower that did not acknowledge it
+	configRetryInterval = 1 * time.Second
+	// configAttempts is how many versions the Leader tries for a change
+	// when followers already have a higher version than its own
+	configAttempts = 3
+)
+
+// ErrConfigSuperseded is returned when followers kept reporting config
+// versions newer than the ones the Leader issued
+var ErrConfigSuperseded = errors.New("followers have a newer configuration version")
+
+// UpdateConfig changes R and W on the Leader and replicates the new
+// configuration to all followers as versioned cluster metadata
+// A follower with a higher version than the Leader's (set by an earlier
+// leader) ignores the change, so the Leader moves its version past it and
+// sends the change again.
+// Returns the new config version and the number of nodes that applied it
+func (rm *ReplicationManager) UpdateConfig(r, w int) (int64, int, error) {
+	if !rm.config.IsLeader() {
+		return 0, 0, fmt.Errorf("only leader can change configuration")
+	}
+
+	var newest int64 // Highest version a follower reported
+	for attempt := 0; attempt < configAttempts; attempt++ {
+		// Hold the replication lock so no write straddles a config change,
+		// but not while waiting for the followers
+		rm.mu.Lock()
+		version := rm.config.UpdateReplicationParams(r, w, newest)
+		rm.mu.Unlock()
+
+		var applied int
+		applied, newest = rm.replicateConfig(ClusterConfig{R: r, W: w, Version: version})
+		if newest <= version {
+			return version, applied, nil
+		}
+		log.Printf("A follower has config version %d, newer than %d; sending the change again", newest, version)
+	}
+	return 0, 0, fmt.Errorf("%w (version %d)", ErrConfigSuperseded, newest)
+}
+
+// replicateConfig sends a configuration to every follower
+// Returns the number of nodes (including this one) at its version, and the
+// highest version a follower reported. Followers that could not be reached
+// are retried in the background.
+func (rm *ReplicationManager) replicateConfig(clusterConfig ClusterConfig) (int, int64) {
+	followerAddrs := rm.config.GetFollowerAddrs()
+	type pushResult struct {
+		addr     string
+		response *ReplicateConfigResponse // nil if not acknowledged
+	}
+	results := make(chan pushResult, len(followerAddrs))
+
+	for _, addr := range followerAddrs {
+		go func(addr string) {
+			results <- pushResult{addr: addr, response: rm.pushConfig(addr, clusterConfig)}
+		}(addr)
+	}
+
+	appliedCount := 1 // Leader already updated
+	newest := clusterConfig.Version
+	for i := 0; i < len(followerAddrs); i++ {
+		result := <-results
+		switch {
+		case result.response == nil:
+			// Keep retrying in the background until the follower catches up
+			// or a newer configuration supersedes this one
+			go rm.retryConfig(result.addr, clusterConfig)
+		case result.response.Version == clusterConfig.Version:
+			appliedCount++
+		case result.response.Version > newest:
+			newest = result.response.Version
+		}
+	}
+	return appliedCount, newest
+}
+
+// pushConfig sends a configuration to a single node
+// Returns the node's response, or nil if it did not acknowledge it
+func (rm *ReplicationManager) pushConfig(addr string, clusterConfig ClusterConfig) *ReplicateConfigResponse {
+	response, err := rm.client.ReplicateConfig(addr, clusterConfig)
+	if err != nil || !response.Success {
+		return nil
+	}
+	return response
+}
+
+// retryConfig re-sends a configuration to a node until it is acknowledged
+func (rm *ReplicationManager) retryConfig(addr string, clusterConfig ClusterConfig) {
+	for {
+		time.Sleep(configRetryInterval)
+		if rm.config.GetConfigVersion() != clusterConfig.Version {
+			return
+		}
+		if response := rm.pushConfig(addr, clusterConfig); response != nil {
+			if response.Version > clusterConfig.Version {
+				log.Printf("Config version %d not applied by %s, which has version %d", clusterConfig.Version, addr, response.Version)
+				return
+			}
+			log.Printf("Config version %d delivered to %s after retry", clusterConfig.Version, addr)
+			return
+		}
+	}
+}
+
+// SyncConfig pulls the cluster configuration from all other nodes and
+// adopts the highest version found. Used when a node (re)starts so that it
+// picks up the current configuration instead of the startup defaults
+// Returns true if at least one other node responded
+func (rm *ReplicationManager) SyncConfig() bool {
+	myAddr := rm.config.GetMyAddr()
+	var otherAddrs []string
+	for _, addr := range rm.config.GetAllNodeAddrs() {
+		if addr != myAddr {
+			otherAddrs = append(otherAddrs, addr)
+		}
+	}
+
+	results := make(chan *ClusterConfig, len(otherAddrs))
+	for _, addr := range otherAddrs {
+		go func(addr string) {
+			clusterConfig, err := rm.client.FetchConfig(addr)
+			if err != nil {
+				results <- nil
+				return
+			}
+			results <- clusterConfig
+		}(addr)
+	}
+
+	var latest *ClusterConfig
+	responded := 0
+	for i := 0; i < len(otherAddrs); i++ {
+		clusterConfig := <-results
+		if clusterConfig == nil {
+			continue
+		}
+		responded++
+		if latest == nil || clusterConfig.Version > latest.Version {
+			latest = clusterConfig
+		}
+	}
+
+	if latest != nil && rm.config.ApplyReplicationParams(latest.R, latest.W, latest.Version) {
+		log.Printf("Synced cluster config: R=%d W=%d (version %d)", latest.R, latest.W, latest.Version)
+	}
+
+	return responded > 0
+}
+
+// FollowerStatuses returns the Leader's view of each follower's replication progress
+func (rm *ReplicationManager) FollowerStatuses() []FollowerStatus {
+	return rm.tracker.Snapshot(rm.store.GetVersion())
+}
@@ -0,0 +1,160 @@
+package leaderfollower
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentFailures is how many failures are kept per follower
+const maxRecentFailures = 10
+
+// ReplicationFailure records a single failed replication attempt
+type ReplicationFailure struct {
+	Time    time.Time `json:"time"`
+	Version int64     `json:"version"`
+	Error   string    `json:"error"`
+}
+
+// FollowerStatus is the Leader's view of a single follower
+type FollowerStatus struct {
+	Addr             string               `json:"addr"`
+	LastAckedVersion int64                `json:"last_acked_version"` // No write up to it is still in flight
+	Lag              int64                `json:"lag"`                // Leader version minus last acked version
+	LastAckTime      *time.Time           `json:"last_ack_time,omitempty"`
+	InFlight         int                  `json:"in_flight"`
+	FailuresTotal    int64                `json:"failures_total"`
+	RecentFailures   []ReplicationFailure `json:"recent_failures"`
+}
+
+// followerState holds the mutable replication state for one follower
+type followerState struct {
+	lastAckedVersion int64       // Every write up to this version has been answered
+	sent             []sentWrite // Writes after lastAckedVersion, in version order
+	lastAckTime      time.Time
+	inFlight         int
+	failuresTotal    int64
+	recentFailures   []ReplicationFailure
+}
+
+// sentWrite is a write sent to a follower
+type sentWrite struct {
+	version  int64
+	answered bool
+}
+
+// FollowerTracker tracks replication progress of every follower (Leader only)
+type FollowerTracker struct {
+	mu        sync.Mutex
+	addrs     []string
+	followers map[string]*followerState
+}
+
+// NewFollowerTracker creates a tracker for the given followers
+func NewFollowerTracker(followerAddrs []string) *FollowerTracker {
+	followers := make(map[string]*followerState, len(followerAddrs))
+	for _, addr := range followerAddrs {
+		followers[addr] = &followerState{}
+	}
+	return &FollowerTracker{
+		addrs:     append([]string{}, followerAddrs...),
+		followers: followers,
+	}
+}
+
+// Sent records that a write was sent to a follower and awaits an ack
+// Writes must be sent in version order.
+func (t *FollowerTracker) Sent(addr string, version int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, ok := t.followers[addr]; ok {
+		state.inFlight++
+		state.sent = append(state.sent, sentWrite{version: version})
+	}
+}
+
+// answered marks a write as answered and moves the follower's watermark past
+// every answered write that no unanswered one precedes
+// Answers to concurrent requests may arrive out of order.
+func (state *followerState) answered(version int64) {
+	state.inFlight--
+	for i := range state.sent {
+		if state.sent[i].version == version {
+			state.sent[i].answered = true
+			break
+		}
+	}
+	for len(state.sent) > 0 && state.sent[0].answered {
+		state.lastAckedVersion = state.sent[0].version
+		state.sent = state.sent[1:]
+	}
+}
+
+// Acked records that a follower acknowledged a write
+func (t *FollowerTracker) Acked(addr string, version int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.followers[addr]
+	if !ok {
+		return
+	}
+	state.answered(version)
+	state.lastAckTime = time.Now()
+}
+
+// Failed records that replicating a write to a follower failed
+func (t *FollowerTracker) Failed(addr string, version int64, errMsg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.followers[addr]
+	if !ok {
+		return
+	}
+	state.answered(version)
+	state.failuresTotal++
+	state.recentFailures = append(state.recentFailures, ReplicationFailure{
+		Time:    time.Now().UTC(),
+		Version: version,
+		Error:   errMsg,
+	})
+	if len(state.recentFailures) > maxRecentFailures {
+		state.recentFailures = state.recentFailures[len(state.recentFailures)-maxRecentFailures:]
+	}
+}
+
+// Record records the outcome of a replication request
+func (t *FollowerTracker) Record(addr string, version int64, response *ReplicateWriteResponse) {
+	if response.Success {
+		t.Acked(addr, version)
+		return
+	}
+	t.Failed(addr, version, response.Error)
+}
+
+// Snapshot returns the status of every follower
+// leaderVersion is used to compute each follower's lag
+func (t *FollowerTracker) Snapshot(leaderVersion int64) []FollowerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]FollowerStatus, 0, len(t.addrs))
+	for _, addr := range t.addrs {
+		state := t.followers[addr]
+		status := FollowerStatus{
+			Addr:             addr,
+			LastAckedVersion: state.lastAckedVersion,
+			Lag:              leaderVersion - state.lastAckedVersion,
+			InFlight:         state.inFlight,
+			FailuresTotal:    state.failuresTotal,
+			RecentFailures:   append([]ReplicationFailure{}, state.recentFailures...),
+		}
+		if status.Lag < 0 {
+			status.Lag = 0
+		}
+		if !state.lastAckTime.IsZero() {
+			lastAckTime := state.lastAckTime.UTC()
+			status.LastAckTime = &lastAckTime
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,42 @@
+package leaderfollower
+
+import "testing"
+
+func TestLastAckedVersionIsContiguous(t *testing.T) {
+	const addr = "127.0.0.1:1"
+
+	tests := []struct {
+		name     string
+		answers  []int64 // Versions answered, in order; negative = failed
+		wantAck  int64
+		wantLag  int64
+		inFlight int
+	}{
+		{name: "in order", answers: []int64{1, 2, 3}, wantAck: 3, wantLag: 0},
+		{name: "later write acked first", answers: []int64{3}, wantAck: 0, wantLag: 3, inFlight: 2},
+		{name: "gap filled", answers: []int64{3, 2, 1}, wantAck: 3, wantLag: 0},
+		{name: "earlier write still in flight", answers: []int64{1, 3}, wantAck: 1, wantLag: 2, inFlight: 1},
+		{name: "failed write", answers: []int64{1, -2, 3}, wantAck: 3, wantLag: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewFollowerTracker([]string{addr})
+			for version := int64(1); version <= 3; version++ {
+				tracker.Sent(addr, version)
+			}
+			for _, version := range tt.answers {
+				if version < 0 {
+					tracker.Failed(addr, -version, "unreachable")
+				} else {
+					tracker.Acked(addr, version)
+				}
+			}
+
+			status := tracker.Snapshot(3)[0]
+			if status.LastAckedVersion != tt.wantAck || status.Lag != tt.wantLag || status.InFlight != tt.inFlight {
+				t.Fatalf("got %+v, want last acked %d, lag %d, in flight %d", status, tt.wantAck, tt.wantLag, tt.inFlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package leaderfollower
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
+)
+
+// Default tuning for replication streams
+const (
+	DefaultStreamMaxBatch    = 256 // Writes per batch
+	DefaultStreamMaxInFlight = 16  // Unacknowledged batches per follower
+	streamQueueSize          = 4096
+	streamReconnectDelay     = 500 * time.Millisecond
+)
+
+// StreamBatch is a batch of writes sent from the Leader to a follower
+// over a replication stream
+type StreamBatch struct {
+	Seq    uint64                  `json:"seq"`
+	Writes []ReplicateWriteRequest `json:"writes"`
+}
+
+// StreamAck acknowledges batches received over a replication stream
+// A successful ack is cumulative: it covers every batch up to and including Seq.
+// A failed ack covers only batch Seq (earlier batches succeeded).
+type StreamAck struct {
+	Seq     uint64 `json:"seq"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// pendingWrite is a write waiting to be sent and acknowledged
+type pendingWrite struct {
+	req  ReplicateWriteRequest
+	done chan *ReplicateWriteResponse
+}
+
+// inFlightBatch is a batch that has been sent but not yet acknowledged
+type inFlightBatch struct {
+	seq    uint64
+	writes []*pendingWrite
+}
+
+// FollowerStream keeps one long-lived streaming connection to a follower.
+// Writes are queued in version order, batched, and pipelined over the
+// connection without waiting for earlier batches to be acknowledged.
+type FollowerStream struct {
+	addr        string
+	addDelay    bool
+	faults      *fault.Injector
+	pool        *rpc.Pool // The stream uses gRPC when set
+	maxBatch    int
+	maxInFlight int
+	httpClient  *http.Client
+	queue       chan *pendingWrite
+
+	mu       sync.Mutex
+	nextSeq  uint64
+	inFlight []*inFlightBatch
+}
+
+// NewFollowerStream creates a replication stream to a follower
+// addDelay enables the injected send delay before each batch it sends
+func NewFollowerStream(addr string, addDelay bool, faults *fault.Injector, pool *rpc.Pool, maxBatch int, maxInFlight int) *FollowerStream {
+	if maxBatch < 1 {
+		maxBatch = DefaultStreamMaxBatch
+	}
+	if maxInFlight < 1 {
+		maxInFlight = DefaultStreamMaxInFlight
+	}
+	return &FollowerStream{
+		addr:        addr,
+		addDelay:    addDelay,
+		faults:      faults,
+		pool:        pool,
+		maxBatch:    maxBatch,
+		maxInFlight: maxInFlight,
+		// No overall timeout: the connection is meant to stay open
+		// indefinitely, but connecting to a dead follower must not hang
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext:           (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+				ResponseHeaderTimeout: 10 * time.Second,
+			},
+		},
+		queue: make(chan *pendingWrite, streamQueueSize),
+	}
+}
+
+// Start runs the stream in the background, reconnecting whenever the
+// connection to the follower is lost
+func (s *FollowerStream) Start() {
+	go func() {
+		for {
+			err := s.runConnection()
+			s.failInFlight(err)
+			s.failQueued(err)
+			time.Sleep(streamReconnectDelay)
+		}
+	}()
+}
+
+// Enqueue queues a write for the follower
+// Writes are sent in the order they are enqueued. The returned channel
+// receives exactly one response once the follower acknowledges the write
+// or the stream fails. Enqueue never blocks: if the follower has fallen so
+// far behind that the queue is full, the write fails immediately and is
+// left to anti-entropy.
+func (s *FollowerStream) Enqueue(req ReplicateWriteRequest) <-chan *ReplicateWriteResponse {
+	write := &pendingWrite{
+		req:  req,
+		done: make(chan *ReplicateWriteResponse, 1),
+	}
+	select {
+	case s.queue <- write:
+	default:
+		s.failWrites([]*pendingWrite{write}, fmt.Errorf("replication queue to %s is full", s.addr))
+	}
+	return write.done
+}
+
+// runConnection opens a stream to the follower and pumps batches until the
+// connection fails
+func (s *FollowerStream) runConnection() error {
+	// A partitioned follower cannot be reached at all
+	if _, err := s.faults.Outbound(s.addr); errors.Is(err, fault.ErrPartitioned) {
+		return err
+	}
+	if s.pool != nil {
+		return s.runGRPCConnection()
+	}
+
+	// Cancelling the context tears down the connection in both directions
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeWriter.Close()
+
+	url := fmt.Sprintf("http://%s/internal/replicate_stream", s.addr)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, pipeReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	log.Printf("Replication stream to %s established", s.addr)
+
+	// window bounds the number of unacknowledged batches
+	window := make(chan struct{}, s.maxInFlight)
+	var ackErr error
+	ackDone := make(chan struct{})
+	go func() {
+		defer close(ackDone)
+		decoder := json.NewDecoder(resp.Body)
+		ackErr = s.receiveAcks(func() (StreamAck, error) {
+			var ack StreamAck
+			err := decoder.Decode(&ack)
+			return ack, err
+		}, window)
+	}()
+
+	encoder := json.NewEncoder(pipeWriter)
+	err = s.sendBatches(func(batch StreamBatch) error { return encoder.Encode(batch) }, window, ackDone)
+
+	// Make sure the ack reader has stopped before the caller fails the
+	// remaining in-flight batches
+	cancel()
+	pipeWriter.Close()
+	<-ackDone
+
+	if err == nil {
+		err = ackErr
+	}
+	return err
+}
+
+// sendBatches batches queued writes and sends them over the stream until
+// sending fails or the ack reader stops
+// Returns nil if the ack reader stopped first
+func (s *FollowerStream) sendBatches(send func(StreamBatch) error, window chan struct{}, ackDone chan struct{}) error {
+	for {
+		var first *pendingWrite
+		select {
+		case first = <-s.queue:
+		case <-ackDone:
+			return nil
+		}
+
+		// Batch everything that is already queued
+		batch := []*pendingWrite{first}
+	drain:
+		for len(batch) < s.maxBatch {
+			select {
+			case write := <-s.queue:
+				batch = append(batch, write)
+			default:
+				break drain
+			}
+		}
+
+		// Wait for room in the pipeline
+		select {
+		case window <- struct{}{}:
+		case <-ackDone:
+			s.failWrites(batch, fmt.Errorf("stream to %s closed", s.addr))
+			return nil
+		}
+
+		// Injected send delay (Leader sleeps 200ms after each message to a
+		// Follower in the homework profile)
+		if s.addDelay {
+			s.faults.Delay(fault.StageSendWrite)
+		}
+
+		duplicate, err := s.faults.Outbound(s.addr)
+		if err != nil {
+			// The batch never reaches the follower
+			s.failWrites(batch, err)
+			<-window
+			if errors.Is(err, fault.ErrPartitioned) {
+				return err
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		s.nextSeq++
+		message := StreamBatch{Seq: s.nextSeq, Writes: make([]ReplicateWriteRequest, len(batch))}
+		for i, write := range batch {
+			message.Writes[i] = write.req
+		}
+		s.inFlight = append(s.inFlight, &inFlightBatch{seq: message.Seq, writes: batch})
+		s.mu.Unlock()
+
+		if err := send(message); err != nil {
+			return fmt.Errorf("failed to send batch: %w", err)
+		}
+		// Re-applying a batch is harmless: every write carries its version
+		if duplicate {
+			if err := send(message); err != nil {
+				return fmt.Errorf("failed to send batch: %w", err)
+			}
+		}
+	}
+}
+
+// receiveAcks reads acknowledgments from the follower and completes the
+// corresponding in-flight batches
+func (s *FollowerStream) receiveAcks(recv func() (StreamAck, error), window chan struct{}) error {
+	for {
+		ack, err := recv()
+		if err != nil {
+			return fmt.Errorf("stream to %s closed: %w", s.addr, err)
+		}
+
+		s.mu.Lock()
+		for len(s.inFlight) > 0 && s.inFlight[0].seq <= ack.Seq {
+			batch := s.inFlight[0]
+			s.inFlight = s.inFlight[1:]
+
+			response := &ReplicateWriteResponse{Success: true}
+			if !ack.Success && batch.seq == ack.Seq {
+				response = &ReplicateWriteResponse{Success: false, Error: ack.Error}
+			}
+			for _, write := range batch.writes {
+				write.done <- &ReplicateWriteResponse{
+					Success: response.Success,
+					Version: write.req.Version,
+					Error:   response.Error,
+				}
+			}
+			<-window
+		}
+		s.mu.Unlock()
+	}
+}
+
+// failInFlight fails every batch that was sent but not acknowledged
+func (s *FollowerStream) failInFlight(err error) {
+	s.mu.Lock()
+	inFlight := s.inFlight
+	s.inFlight = nil
+	s.mu.Unlock()
+
+	for _, batch := range inFlight {
+		s.failWrites(batch.writes, err)
+	}
+}
+
+// failQueued fails every write still waiting in the queue so that callers
+// do not block while the follower is unreachable
+func (s *FollowerStream) failQueued(err error) {
+	for {
+		select {
+		case write := <-s.queue:
+			s.failWrites([]*pendingWrite{write}, err)
+		default:
+			return
+		}
+	}
+}
+
+// failWrites completes writes with a failure response
+func (s *FollowerStream) failWrites(writes []*pendingWrite, err error) {
+	for _, write := range writes {
+		write.done <- &ReplicateWriteResponse{
+			Success: false,
+			Version: write.req.Version,
+			Error:   err.Error(),
+		}
+	}
+}
+
+// writeStreamAcks writes acknowledgments back to the Leader
+// Successful acks that queue up while a previous ack is being written are
+// coalesced into a single cumulative ack. Failed acks are always sent
+// individually, preceded by the latest success before them.
+func writeStreamAcks(send func(StreamAck) error, flush func() error, acks <-chan StreamAck) {
+	broken := false
+
+	for ack := range acks {
+		var toSend []StreamAck
+		var lastSuccess StreamAck
+		hasSuccess := false
+
+		for more := true; more; {
+			if ack.Success {
+				lastSuccess = ack
+				hasSuccess = true
+			} else {
+				if hasSuccess {
+					toSend = append(toSend, lastSuccess)
+					hasSuccess = false
+				}
+				toSend = append(toSend, ack)
+			}
+
+			// Pick up anything else that is already waiting
+			select {
+			case next, ok := <-acks:
+				if ok {
+					ack = next
+				} else {
+					more = false
+				}
+			default:
+				more = false
+			}
+		}
+		if hasSuccess {
+			toSend = append(toSend, lastSuccess)
+		}
+
+		// Keep draining after the Leader goes away so the apply loop never blocks
+		if broken {
+			continue
+		}
+		for _, message := range toSend {
+			if err := send(message); err != nil {
+				broken = true
+				break
+			}
+		}
+		if !broken && flush() != nil {
+			broken = true
+		}
+	}
+}
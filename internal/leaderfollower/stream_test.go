@@ -0,0 +1,28 @@
+package leaderfollower
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnqueueFailsWhenQueueIsFull(t *testing.T) {
+	// Never started: nothing drains the queue, like a follower that stopped
+	// acknowledging
+	s := NewFollowerStream("127.0.0.1:1", false, nil, nil, 0, 0)
+	for i := 0; i < streamQueueSize; i++ {
+		s.Enqueue(ReplicateWriteRequest{Key: "k", Version: int64(i + 1)})
+	}
+
+	enqueued := make(chan (<-chan *ReplicateWriteResponse))
+	go func() { enqueued <- s.Enqueue(ReplicateWriteRequest{Key: "k", Version: streamQueueSize + 1}) }()
+
+	select {
+	case done := <-enqueued:
+		response := <-done
+		if response.Success || response.Version != streamQueueSize+1 {
+			t.Fatalf("got %+v, want a failure for version %d", response, streamQueueSize+1)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue blocked on a full queue")
+	}
+}
@@ -0,0 +1,122 @@
+package leaderless
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// FrontendBackend serves the protocol frontends (RESP, memcached) with quorum
+// reads and writes like the resource API (conditional writes use Paxos)
+// Keys this node does not replicate are forwarded to one of their replicas.
+type FrontendBackend struct {
+	config     *Config
+	replicator *ReplicationManager
+}
+
+// FrontendBackend returns the backend of this node's protocol frontends
+func (h *Handler) FrontendBackend() *FrontendBackend {
+	return &FrontendBackend{config: h.config, replicator: h.replicator}
+}
+
+// Get reads a key, returning nil if it does not exist
+func (b *FrontendBackend) Get(key string) (*kvstore.KeyValue, error) {
+	if b.config.UsesVectorClocks() {
+		return nil, frontend.ErrCausal
+	}
+	if !b.config.IsReplica(key) {
+		return b.forwardGet(key)
+	}
+
+	kv, err := b.replicator.Read(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kv.CRDT != nil {
+		return nil, kvstore.ErrCRDTKey
+	}
+	return kv, nil
+}
+
+// Set writes a key if its preconditions hold
+func (b *FrontendBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	if value == nil {
+		value = []byte{}
+	}
+	return b.write(key, value, false, preconditions)
+}
+
+// Delete deletes a key if its preconditions hold
+func (b *FrontendBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.write(key, nil, true, preconditions)
+	return err
+}
+
+// write writes or deletes a key as a replica, or forwards it to one
+func (b *FrontendBackend) write(key string, value []byte, deleted bool, preconditions rest.Preconditions) (int64, error) {
+	if b.config.UsesVectorClocks() {
+		return 0, frontend.ErrCausal
+	}
+	if b.config.IsReplica(key) {
+		return b.replicator.WriteKey(key, value, deleted, preconditions)
+	}
+
+	method := "PUT"
+	header := http.Header{}
+	header.Set("Content-Type", blob.ContentType)
+	if deleted {
+		method = "DELETE"
+	}
+	preconditions.SetHeaders(header)
+	resp, err := b.replicator.Forward(key, method, "/v1/keys/"+url.PathEscape(key), header, value)
+	if err != nil {
+		return 0, err
+	}
+	return rest.ReadStored(resp)
+}
+
+// forwardGet reads a key this node does not replicate from one of its
+// replicas
+func (b *FrontendBackend) forwardGet(key string) (*kvstore.KeyValue, error) {
+	resp, err := b.replicator.Forward(key, "GET", "/kv/"+url.PathEscape(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	version, _ := strconv.ParseInt(resp.Header.Get(blob.VersionHeader), 10, 64)
+	return &kvstore.KeyValue{Key: key, Value: body, Version: version}, nil
+}
+
+// CheckNamespace rejects namespace settings the cluster cannot apply: a key
+// cannot have more replicas than there are nodes
+func (h *Handler) CheckNamespace(settings namespace.Settings) error {
+	if n := h.config.GetN(); settings.ReplicationFactor > n {
+		return fmt.Errorf("replication_factor must be at most %d, the number of nodes", n)
+	}
+	return nil
+}
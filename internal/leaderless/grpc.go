@@ -0,0 +1,310 @@
+package leaderless
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rpc/kvpb"
+)
+
+// ReplicationService serves the internal API over gRPC, like
+// ReplicateWriteHandler, ReplicateBatchHandler, InternalReadHandler and
+// InternalReadBatchHandler
+type ReplicationService struct {
+	kvpb.UnimplementedReplicationServer
+	handler *Handler
+}
+
+// ReplicationService returns this node's gRPC internal API
+func (h *Handler) ReplicationService() *ReplicationService {
+	return &ReplicationService{handler: h}
+}
+
+// ReplicateWrite applies a write from Write Coordinator
+func (s *ReplicationService) ReplicateWrite(ctx context.Context, req *kvpb.ReplicateWriteRequest) (*kvpb.ReplicateWriteResponse, error) {
+	write := writeFromProto(req)
+
+	// Injected receive delay (Node sleeps 100ms when receiving update before
+	// responding in the homework profile)
+	s.handler.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	response := s.handler.receiveWrite(write)
+	return &kvpb.ReplicateWriteResponse{Success: response.Success, Version: response.Version, Error: response.Error}, nil
+}
+
+// ReplicateBatch applies a batch of writes from Write Coordinator
+func (s *ReplicationService) ReplicateBatch(ctx context.Context, req *kvpb.ReplicateBatchRequest) (*kvpb.ReplicateBatchResponse, error) {
+	writes := make([]ReplicateWriteRequest, len(req.Writes))
+	for i, write := range req.Writes {
+		writes[i] = writeFromProto(write)
+	}
+
+	resp := &kvpb.ReplicateBatchResponse{}
+	for _, response := range s.handler.receiveBatch(writes) {
+		resp.Responses = append(resp.Responses, &kvpb.ReplicateWriteResponse{Success: response.Success, Version: response.Version, Error: response.Error})
+	}
+	return resp, nil
+}
+
+// Read reads a key from this node's store
+func (s *ReplicationService) Read(ctx context.Context, req *kvpb.ReadRequest) (*kvpb.ReadResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	// Injected read delay (Node sleeps 50ms when receiving read request
+	// in the homework profile)
+	s.handler.config.GetFaults().Delay(fault.StageReceiveRead)
+
+	return readToProto(s.handler.internalRead(req.Key)), nil
+}
+
+// ReadBatch reads several keys from this node's store
+func (s *ReplicationService) ReadBatch(ctx context.Context, req *kvpb.ReadBatchRequest) (*kvpb.ReadBatchResponse, error) {
+	resp := &kvpb.ReadBatchResponse{}
+	for _, r := range s.handler.internalReadBatch(req.Keys) {
+		resp.Responses = append(resp.Responses, readToProto(r))
+	}
+	return resp, nil
+}
+
+// replicateGRPC sends a write to a replica over gRPC
+func (c *ReplicationClient) replicateGRPC(addr string, write ReplicateWriteRequest) (*ReplicateWriteResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	// Same deadline as an HTTP request
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.ReplicateWrite(ctx, writeToProto(write))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return &ReplicateWriteResponse{Success: resp.Success, Version: resp.Version, Error: resp.Error}, nil
+}
+
+// readGRPC reads a value from another node over gRPC, giving up once ctx is
+// cancelled
+func (c *ReplicationClient) readGRPC(ctx context.Context, addr string, key string) (*ReadResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.Read(ctx, &kvpb.ReadRequest{Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return readFromProto(resp), nil
+}
+
+// replicateBatchGRPC sends a batch of writes to a replica over gRPC
+func (c *ReplicationClient) replicateBatchGRPC(addr string, writes []ReplicateWriteRequest) ([]ReplicateWriteResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	req := &kvpb.ReplicateBatchRequest{Writes: make([]*kvpb.ReplicateWriteRequest, len(writes))}
+	for i, write := range writes {
+		req.Writes[i] = writeToProto(write)
+	}
+	resp, err := client.ReplicateBatch(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if len(resp.Responses) != len(writes) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(writes), len(resp.Responses))
+	}
+
+	responses := make([]ReplicateWriteResponse, len(resp.Responses))
+	for i, response := range resp.Responses {
+		responses[i] = ReplicateWriteResponse{Success: response.Success, Version: response.Version, Error: response.Error}
+	}
+	return responses, nil
+}
+
+// readBatchGRPC reads several keys from a replica over gRPC
+func (c *ReplicationClient) readBatchGRPC(addr string, keys []string) ([]ReadResponse, error) {
+	if err := c.detector.Check(addr); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	client, err := c.pool.Replication(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+	defer cancel()
+
+	resp, err := client.ReadBatch(ctx, &kvpb.ReadBatchRequest{Keys: keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if len(resp.Responses) != len(keys) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(keys), len(resp.Responses))
+	}
+
+	responses := make([]ReadResponse, len(resp.Responses))
+	for i, r := range resp.Responses {
+		responses[i] = *readFromProto(r)
+	}
+	return responses, nil
+}
+
+// readToProto converts a read response into its gRPC message
+func readToProto(r ReadResponse) *kvpb.ReadResponse {
+	resp := &kvpb.ReadResponse{
+		Exists:  r.Exists,
+		Key:     r.Key,
+		Value:   r.Value,
+		Version: r.Version,
+		Deleted: r.Deleted,
+		Crdt:    crdtToProto(r.CRDT),
+	}
+	for _, sibling := range r.Siblings {
+		resp.Siblings = append(resp.Siblings, &kvpb.Sibling{
+			Value:   sibling.Value,
+			Dot:     &kvpb.Dot{Node: sibling.Dot.Node, Counter: sibling.Dot.Counter},
+			Context: sibling.Context,
+		})
+	}
+	return resp
+}
+
+// readFromProto converts a gRPC message into a read response
+func readFromProto(resp *kvpb.ReadResponse) *ReadResponse {
+	response := &ReadResponse{
+		Key:     resp.Key,
+		Value:   resp.Value,
+		Version: resp.Version,
+		CRDT:    crdtFromProto(resp.Crdt),
+		Deleted: resp.Deleted,
+		Exists:  resp.Exists,
+	}
+	for _, sibling := range resp.Siblings {
+		response.Siblings = append(response.Siblings, kvstore.Sibling{
+			Value:   sibling.Value,
+			Dot:     kvstore.Dot{Node: sibling.Dot.GetNode(), Counter: sibling.Dot.GetCounter()},
+			Context: sibling.Context,
+		})
+	}
+	return response
+}
+
+// writeToProto converts a replicated write into its gRPC message
+func writeToProto(write ReplicateWriteRequest) *kvpb.ReplicateWriteRequest {
+	req := &kvpb.ReplicateWriteRequest{
+		Key:     write.Key,
+		Value:   write.Value,
+		Version: write.Version,
+		Deleted: write.Deleted,
+		Context: write.Context,
+		HintFor: write.HintFor,
+		Crdt:    crdtToProto(write.CRDT),
+	}
+	if write.Dot != nil {
+		req.Dot = &kvpb.Dot{Node: write.Dot.Node, Counter: write.Dot.Counter}
+	}
+	return req
+}
+
+// writeFromProto converts a gRPC message into a replicated write
+func writeFromProto(req *kvpb.ReplicateWriteRequest) ReplicateWriteRequest {
+	write := ReplicateWriteRequest{
+		Key:     req.Key,
+		Value:   req.Value,
+		Version: req.Version,
+		Deleted: req.Deleted,
+		Context: req.Context,
+		HintFor: req.HintFor,
+		CRDT:    crdtFromProto(req.Crdt),
+	}
+	if req.Dot != nil {
+		write.Dot = &kvstore.Dot{Node: req.Dot.Node, Counter: req.Dot.Counter}
+	}
+	return write
+}
+
+// crdtToProto converts a CRDT state into its gRPC message (nil stays nil)
+func crdtToProto(crdt *kvstore.CRDT) *kvpb.CRDT {
+	if crdt == nil {
+		return nil
+	}
+	message := &kvpb.CRDT{
+		Type:      crdt.Type,
+		P:         crdt.P,
+		N:         crdt.N,
+		Value:     crdt.Value,
+		Timestamp: crdt.Timestamp,
+		Clock:     crdt.Clock,
+	}
+	if crdt.Elements != nil {
+		message.Elements = make(map[string]*kvpb.Dots, len(crdt.Elements))
+		for element, dots := range crdt.Elements {
+			adds := &kvpb.Dots{Dots: make([]*kvpb.Dot, len(dots))}
+			for i, dot := range dots {
+				adds.Dots[i] = &kvpb.Dot{Node: dot.Node, Counter: dot.Counter}
+			}
+			message.Elements[element] = adds
+		}
+	}
+	if crdt.Fields != nil {
+		message.Fields = make(map[string]*kvpb.CRDT, len(crdt.Fields))
+		for field, value := range crdt.Fields {
+			message.Fields[field] = crdtToProto(value)
+		}
+	}
+	return message
+}
+
+// crdtFromProto converts a gRPC message into a CRDT state (nil stays nil)
+func crdtFromProto(message *kvpb.CRDT) *kvstore.CRDT {
+	if message == nil {
+		return nil
+	}
+	crdt := &kvstore.CRDT{
+		Type:      message.Type,
+		P:         message.P,
+		N:         message.N,
+		Value:     message.Value,
+		Timestamp: message.Timestamp,
+		Clock:     message.Clock,
+	}
+	if message.Elements != nil {
+		crdt.Elements = make(map[string][]kvstore.Dot, len(message.Elements))
+		for element, adds := range message.Elements {
+			dots := make([]kvstore.Dot, len(adds.GetDots()))
+			for i, dot := range adds.GetDots() {
+				dots[i] = kvstore.Dot{Node: dot.Node, Counter: dot.Counter}
+			}
+			crdt.Elements[element] = dots
+		}
+	}
+	if message.Fields != nil {
+		crdt.Fields = make(map[string]*kvstore.CRDT, len(message.Fields))
+		for field, value := range message.Fields {
+			crdt.Fields[field] = crdtFromProto(value)
+		}
+	}
+	return crdt
+}
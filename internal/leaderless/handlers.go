@@ -2,10 +2,21 @@ package leaderless
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/antientropy"
+	"github.com/yourusername/distributed-kv-store/internal/batch"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/fault"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/numeric"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
 )
 
 // Handler provides HTTP handlers for Leaderless database
@@ -13,23 +24,27 @@ type Handler struct {
 	store      *kvstore.Store
 	config     *Config
 	replicator *ReplicationManager
+	rebalancer *Rebalancer
 }
 
 // NewHandler creates a new Leaderless handler
 func NewHandler(store *kvstore.Store, config *Config) *Handler {
 	replicator := NewReplicationManager(store, config)
-	return &Handler{
+	h := &Handler{
 		store:      store,
 		config:     config,
 		replicator: replicator,
 	}
+	h.rebalancer = NewRebalancer(store, config, h.ApplyEntry)
+	return h
 }
 
 // SetHandler handles write requests (any node can receive writes)
 func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Key   string `json:"key"`
-		Value string `json:"value"`
+		Key     string `json:"key"`
+		Value   string `json:"value"`
+		Context string `json:"context"` // Causal context from /get (vector clock mode only)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -37,59 +52,745 @@ func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Key == "" {
-		http.Error(w, "key cannot be empty", http.StatusBadRequest)
+	if !rest.ValidKey(w, req.Key) {
+		return
+	}
+
+	// Keys this node does not replicate are coordinated by one of their replicas
+	body, _ := json.Marshal(req)
+	if h.forward(w, r, req.Key, body) {
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		h.setCausal(w, req.Key, []byte(req.Value), req.Context, map[string]interface{}{"value": req.Value})
 		return
 	}
 
 	// This node becomes the Write Coordinator
-	// It must coordinate writes to all other nodes
-	result, err := h.replicator.WriteWithCoordination(req.Key, req.Value)
+	// It replicates to all other nodes and waits for W acknowledgments
+	result, err := h.replicator.WriteWithCoordination(req.Key, []byte(req.Value))
 	if err != nil {
+		if errors.Is(err, ErrNoQuorum) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, kvstore.ErrCRDTKey) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, kvstore.ErrValueTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"key":     req.Key,
 		"value":   req.Value,
 		"version": result.Version,
 		"status":  "created",
-	})
+	}
+	if result.Hinted > 0 {
+		response["hinted"] = result.Hinted
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// setCausal handles a write under vector clock conflict resolution
+// The write replaces every sibling covered by the supplied context. The
+// response holds the fields of response plus the write's context.
+func (h *Handler) setCausal(w http.ResponseWriter, key string, value []byte, encodedContext string, response map[string]interface{}) {
+	context, err := kvstore.DecodeContext(encodedContext)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.replicator.WriteCausal(key, value, context)
+	if err != nil {
+		if errors.Is(err, ErrNoQuorum) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, kvstore.ErrCRDTKey) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, kvstore.ErrValueTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response["key"] = key
+	response["context"] = kvstore.EncodeContext(result.Sibling.Clock())
+	response["siblings"] = len(result.Siblings)
+	response["status"] = "created"
+	if result.Hinted > 0 {
+		response["hinted"] = result.Hinted
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// CASHandler handles compare-and-set requests
+// The value is written only if the key's current value equals "expected"
+// (a missing or null "expected" means the key must not exist). The check and
+// the write are one Paxos round across a majority of the key's replicas, so
+// compare-and-sets on a key are linearizable.
+func (h *Handler) CASHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Key      string  `json:"key"`
+		Expected *string `json:"expected"`
+		Value    string  `json:"value"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !rest.ValidKey(w, req.Key) {
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		http.Error(w, "compare-and-set requires last-writer-wins conflict resolution", http.StatusBadRequest)
+		return
+	}
+
+	// Compare-and-sets are coordinated by one of the key's replicas
+	body, _ := json.Marshal(req)
+	if h.forward(w, r, req.Key, body) {
+		return
+	}
+
+	result, err := h.replicator.CompareAndSet(req.Key, req.Expected, []byte(req.Value))
+	if err != nil {
+		if errors.Is(err, ErrNoQuorum) || errors.Is(err, ErrCASContention) || errors.Is(err, ErrCASUnknown) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, kvstore.ErrCRDTKey) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"key":     req.Key,
+		"applied": result.Applied,
+		"exists":  result.Exists,
+	}
+	if result.Exists {
+		response["value"] = string(result.Value)
+		response["version"] = result.Version
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Applied {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		// The current value is returned so the client can retry
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// IncrHandler handles POST /incr, adding a delta to an integer value
+// Unlike /counter/incr the value is a plain key, read and written like
+// /cas in one Paxos round, so increments are linearizable and return the
+// new value.
+func (h *Handler) IncrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, false)
+}
+
+// DecrHandler handles POST /decr, subtracting a delta from an integer value
+// like IncrHandler
+func (h *Handler) DecrHandler(w http.ResponseWriter, r *http.Request) {
+	h.increment(w, r, true)
+}
+
+// increment updates the value through one of the key's replicas
+func (h *Handler) increment(w http.ResponseWriter, r *http.Request, decr bool) {
+	op, err := numeric.Decode(r, decr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		http.Error(w, "incr and decr require last-writer-wins conflict resolution", http.StatusBadRequest)
+		return
+	}
+
+	// Increments are coordinated by one of the key's replicas. The body
+	// carries the signed delta, so a decrement is forwarded as an /incr.
+	body, _ := json.Marshal(op)
+	forwarded := r.Clone(r.Context())
+	forwarded.URL.Path = "/incr"
+	if h.forward(w, forwarded, op.Key, body) {
+		return
+	}
+
+	result, err := h.replicator.Update(op.Key, op.Apply)
+	if err != nil {
+		if errors.Is(err, ErrNoQuorum) || errors.Is(err, ErrCASContention) || errors.Is(err, ErrCASUnknown) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		rest.WriteError(w, err)
+		return
+	}
+
+	numeric.WriteResult(w, &kvstore.KeyValue{Key: op.Key, Value: result.Value, Version: result.Version})
+}
+
+// crdtRequest is the body of the CRDT update endpoints
+type crdtRequest struct {
+	Key     string `json:"key"`
+	Type    string `json:"type,omitempty"`   // Counter type when the key is created: pncounter (default) or gcounter
+	Amount  *int64 `json:"amount,omitempty"` // Counters (default 1)
+	Value   string `json:"value,omitempty"`  // Registers
+	Element string `json:"element,omitempty"`
+	Field   string `json:"field,omitempty"` // Maps
+	Op      string `json:"op,omitempty"`    // Maps: incr, decr, assign, add or remove
+}
+
+// CounterIncrHandler increments a counter
+func (h *Handler) CounterIncrHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateCRDT(w, r, kvstore.OpIncrement)
+}
+
+// CounterDecrHandler decrements a PN-counter
+func (h *Handler) CounterDecrHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateCRDT(w, r, kvstore.OpDecrement)
+}
+
+// RegisterAssignHandler sets an LWW-register
+func (h *Handler) RegisterAssignHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateCRDT(w, r, kvstore.OpAssign)
+}
+
+// SetAddHandler adds an element to an OR-set
+func (h *Handler) SetAddHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateCRDT(w, r, kvstore.OpAdd)
+}
+
+// SetRemoveHandler removes an element from an OR-set
+func (h *Handler) SetRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateCRDT(w, r, kvstore.OpRemove)
+}
+
+// MapUpdateHandler applies an operation to a field of a map
+func (h *Handler) MapUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateCRDT(w, r, "")
+}
+
+// updateCRDT handles a CRDT update request
+// op is the operation of the endpoint ("" for maps, where the request
+// names it). The key is created with the type the endpoint implies.
+func (h *Handler) updateCRDT(w http.ResponseWriter, r *http.Request, op string) {
+	var req crdtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !rest.ValidKey(w, req.Key) {
+		return
+	}
+
+	operation := kvstore.Operation{
+		Op:      op,
+		Amount:  1,
+		Value:   req.Value,
+		Element: req.Element,
+	}
+	if req.Amount != nil {
+		operation.Amount = *req.Amount
+	}
+
+	var typ string
+	switch op {
+	case kvstore.OpIncrement, kvstore.OpDecrement:
+		typ = kvstore.TypePNCounter
+		if req.Type == kvstore.TypeGCounter {
+			typ = kvstore.TypeGCounter
+		} else if req.Type != "" && req.Type != kvstore.TypePNCounter {
+			http.Error(w, "type must be 'pncounter' or 'gcounter'", http.StatusBadRequest)
+			return
+		}
+	case kvstore.OpAssign:
+		typ = kvstore.TypeLWWRegister
+	case kvstore.OpAdd, kvstore.OpRemove:
+		typ = kvstore.TypeORSet
+	default:
+		if req.Field == "" || req.Op == "" {
+			http.Error(w, "field and op are required", http.StatusBadRequest)
+			return
+		}
+		typ = kvstore.TypeMap
+		operation.Op = req.Op
+		operation.Field = req.Field
+	}
+
+	// Updates are coordinated by one of the key's replicas
+	body, _ := json.Marshal(req)
+	if h.forward(w, r, req.Key, body) {
+		return
+	}
+
+	state, hinted, err := h.replicator.UpdateCRDT(req.Key, typ, operation)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoQuorum):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, kvstore.ErrPlainKey), errors.Is(err, kvstore.ErrCRDTOp):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, kvstore.ErrCRDTField), errors.Is(err, kvstore.ErrCRDTType):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := valueResponse(&kvstore.KeyValue{Key: req.Key, CRDT: state})
+	if hinted > 0 {
+		response["hinted"] = hinted
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// valueResponse builds the JSON response for a read
+// Under vector clock conflict resolution all sibling values are returned
+// together with the causal context that resolves them. CRDT keys return
+// their type and merged value.
+func valueResponse(kv *kvstore.KeyValue) map[string]interface{} {
+	if kv.CRDT != nil {
+		return map[string]interface{}{
+			"key":   kv.Key,
+			"type":  kv.CRDT.Type,
+			"value": kv.CRDT.Result(),
+		}
+	}
+
+	if kv.Siblings == nil {
+		return map[string]interface{}{
+			"key":     kv.Key,
+			"value":   string(kv.Value),
+			"version": kv.Version,
+		}
+	}
+
+	values := make([]string, len(kv.Siblings))
+	for i, sibling := range kv.Siblings {
+		values[i] = string(sibling.Value)
+	}
+	response := map[string]interface{}{
+		"key":     kv.Key,
+		"values":  values,
+		"context": kvstore.EncodeContext(kvstore.CausalContext(kv.Siblings)),
+	}
+	if len(values) == 1 {
+		response["value"] = values[0]
+	}
+	return response
 }
 
 // GetHandler handles read requests (any node can receive reads)
-// Returns local value immediately (R=1)
+// Returns the most recent value among R nodes (local value when R=1)
 func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
-	if key == "" {
-		http.Error(w, "key parameter is required", http.StatusBadRequest)
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.forward(w, r, key, nil) {
 		return
 	}
 
-	// Read local value immediately (no coordination)
-	kv, err := h.replicator.ReadLocal(key)
+	kv, err := h.replicator.Read(key)
 	if err != nil {
-		http.Error(w, "key not found", http.StatusNotFound)
+		if errors.Is(err, ErrKeyNotFound) {
+			http.Error(w, "key not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(valueResponse(kv))
+}
+
+// MGetHandler handles POST /mget, reading several keys at once (any node
+// can receive reads)
+// Each key is read from R of its replicas like /get, with one request per
+// replica for all the keys it holds.
+func (h *Handler) MGetHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := batch.DecodeKeys(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values, errs := h.replicator.ReadBatch(keys)
+	results := make([]map[string]interface{}, len(keys))
+	for i, kv := range values {
+		switch {
+		case errors.Is(errs[i], ErrKeyNotFound):
+			results[i] = batch.ErrorResult(keys[i], http.StatusNotFound, "key not found")
+		case errs[i] != nil:
+			results[i] = batch.ErrorResult(keys[i], http.StatusServiceUnavailable, errs[i].Error())
+		default:
+			results[i] = batch.Result(http.StatusOK, valueResponse(kv))
+		}
+	}
+	batch.WriteResults(w, results)
+}
+
+// MSetHandler handles POST /mset, writing several keys at once (any node can
+// receive writes; last-writer-wins conflict resolution only)
+// Each key is written to W of its replicas like /set, with one request per
+// replica for all the keys it holds.
+func (h *Handler) MSetHandler(w http.ResponseWriter, r *http.Request) {
+	if h.config.UsesVectorClocks() {
+		http.Error(w, "mset needs last-writer-wins conflict resolution", http.StatusBadRequest)
+		return
+	}
+
+	pairs, err := batch.DecodePairs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writes := make([]ReplicateWriteRequest, len(pairs))
+	for i, pair := range pairs {
+		writes[i] = ReplicateWriteRequest{Key: pair.Key, Value: []byte(pair.Value)}
+	}
+
+	results := make([]map[string]interface{}, len(pairs))
+	for i, result := range h.replicator.WriteBatch(writes) {
+		if result.Error != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(result.Error, ErrNoQuorum):
+				status = http.StatusServiceUnavailable
+			case errors.Is(result.Error, kvstore.ErrCRDTKey):
+				status = http.StatusConflict
+			case errors.Is(result.Error, kvstore.ErrValueTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			}
+			results[i] = batch.ErrorResult(pairs[i].Key, status, result.Error.Error())
+			continue
+		}
+		response := map[string]interface{}{
+			"key":     pairs[i].Key,
+			"value":   pairs[i].Value,
+			"version": result.Version,
+		}
+		if result.Hinted > 0 {
+			response["hinted"] = result.Hinted
+		}
+		results[i] = batch.Result(http.StatusCreated, response)
+	}
+	batch.WriteResults(w, results)
+}
+
+// forward proxies a request for a key this node does not replicate to one
+// of the key's replicas and copies the replica's response back
+// Returns false if this node handles the request itself (it is a replica,
+// or the request was already forwarded).
+func (h *Handler) forward(w http.ResponseWriter, r *http.Request, key string, body []byte) bool {
+	if h.config.IsReplica(key) || r.Header.Get(ForwardedHeader) != "" {
+		return false
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if blob.IsRaw(r) {
+		header.Set("Content-Type", blob.ContentType)
+	}
+	for _, name := range append(rest.ConditionalHeaders, blob.ContextHeader) {
+		if value := r.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	resp, err := h.replicator.Forward(key, r.Method, r.URL.RequestURI(), header, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return true
+	}
+	defer resp.Body.Close()
+
+	for _, header := range []string{"Content-Type", "ETag", blob.VersionHeader, blob.ContextHeader} {
+		if value := resp.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+	return true
+}
+
+// PutValueHandler handles PUT /kv/{key} with the raw value as the body
+// Under vector clock conflict resolution the causal context read from
+// GET /kv/{key} is sent in the X-KV-Context header (none for a blind write).
+func (h *Handler) PutValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.forward(w, r, key, value) {
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		h.setCausal(w, key, value, r.Header.Get(blob.ContextHeader), map[string]interface{}{"size": len(value)})
+		return
+	}
+
+	result, err := h.replicator.WriteWithCoordination(key, value)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoQuorum):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, kvstore.ErrCRDTKey):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{
+		"key":     key,
+		"size":    len(value),
+		"version": result.Version,
+		"status":  "created",
+	}
+	if result.Hinted > 0 {
+		response["hinted"] = result.Hinted
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetValueHandler handles GET /kv/{key}, returning the raw value of a
+// quorum read
+// Under vector clock conflict resolution concurrent values are returned as
+// 300 Multiple Choices (see writeSiblings).
+func (h *Handler) GetValueHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.forward(w, r, key, nil) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			http.Error(w, "key not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if kv.CRDT != nil {
+		http.Error(w, kvstore.ErrCRDTKey.Error(), http.StatusConflict)
+		return
+	}
+	if kv.Siblings != nil {
+		writeSiblings(w, kv)
+		return
+	}
+
+	blob.WriteValue(w, kv.Value, kv.Version)
+}
+
+// writeSiblings answers a raw read of a causally versioned key: the value
+// itself if there is a single sibling, otherwise 300 Multiple Choices with
+// every value (base64-encoded in JSON)
+// Either way the X-KV-Context header holds the causal context a write must
+// carry to replace them.
+func writeSiblings(w http.ResponseWriter, kv *kvstore.KeyValue) {
+	context := kvstore.EncodeContext(kvstore.CausalContext(kv.Siblings))
+	if len(kv.Siblings) == 1 {
+		blob.WriteSibling(w, kv.Siblings[0].Value, context)
+		return
+	}
+
+	values := make([][]byte, len(kv.Siblings))
+	for i, sibling := range kv.Siblings {
+		values[i] = sibling.Value
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(blob.ContextHeader, context)
+	w.WriteHeader(http.StatusMultipleChoices)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"key":     kv.Key,
-		"value":   kv.Value,
-		"version": kv.Version,
+		"values":  values,
+		"context": context,
 	})
 }
 
+// KeyGetHandler handles GET /v1/keys/{key} with a quorum read
+// (last-writer-wins conflict resolution only)
+// Honors If-None-Match (304 Not Modified) and If-Match (412)
+func (h *Handler) KeyGetHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		http.Error(w, "the resource API needs last-writer-wins conflict resolution", http.StatusBadRequest)
+		return
+	}
+
+	if h.forward(w, r, key, nil) {
+		return
+	}
+
+	kv, err := h.replicator.Read(key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if kv != nil && kv.CRDT != nil {
+		http.Error(w, kvstore.ErrCRDTKey.Error(), http.StatusConflict)
+		return
+	}
+
+	if status := rest.ParsePreconditions(r).ReadStatus(kv); status != 0 {
+		rest.WriteStatus(w, status, kv)
+		return
+	}
+	if kv == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	rest.WriteValue(w, kv)
+}
+
+// KeyPutHandler handles PUT /v1/keys/{key} (last-writer-wins conflict
+// resolution only)
+// Unconditional writes go to a quorum like /set. With If-Match or
+// If-None-Match the check and the write are one Paxos round like /cas, and
+// a failed check answers 412.
+func (h *Handler) KeyPutHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		http.Error(w, "the resource API needs last-writer-wins conflict resolution", http.StatusBadRequest)
+		return
+	}
+
+	value, err := rest.ReadValue(w, r, h.store.GetMaxValueSize())
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.CheckValueSize(len(value)); err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The body was consumed, so a forwarded request carries the value again
+	body := value
+	if !blob.IsRaw(r) {
+		body, _ = json.Marshal(map[string]string{"value": string(value)})
+	}
+	if h.forward(w, r, key, body) {
+		return
+	}
+
+	h.writeKey(w, key, value, false, rest.ParsePreconditions(r))
+}
+
+// KeyDeleteHandler handles DELETE /v1/keys/{key}
+// Conditional like KeyPutHandler
+func (h *Handler) KeyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !rest.ValidKey(w, key) {
+		return
+	}
+
+	if h.config.UsesVectorClocks() {
+		http.Error(w, "the resource API needs last-writer-wins conflict resolution", http.StatusBadRequest)
+		return
+	}
+
+	if h.forward(w, r, key, nil) {
+		return
+	}
+
+	h.writeKey(w, key, nil, true, rest.ParsePreconditions(r))
+}
+
+// writeKey writes (or deletes) a key for the resource API and answers the
+// request
+func (h *Handler) writeKey(w http.ResponseWriter, key string, value []byte, deleted bool, preconditions rest.Preconditions) {
+	version, err := h.replicator.WriteKey(key, value, deleted, preconditions)
+	if err != nil {
+		if errors.Is(err, ErrNoQuorum) || errors.Is(err, ErrCASContention) || errors.Is(err, ErrCASUnknown) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		rest.WriteError(w, err)
+		return
+	}
+
+	if deleted {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	rest.WriteStored(w, key, version)
+}
+
 // LocalReadHandler handles local reads (for testing)
 func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 	key := r.URL.Query().Get("key")
-	if key == "" {
-		http.Error(w, "key parameter is required", http.StatusBadRequest)
+	if !rest.ValidKey(w, key) {
 		return
 	}
 
@@ -101,27 +802,36 @@ func (h *Handler) LocalReadHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"key":     kv.Key,
-		"value":   kv.Value,
-		"version": kv.Version,
-	})
+	json.NewEncoder(w).Encode(valueResponse(kv))
 }
 
 // ReplicateWriteHandler handles internal write replication requests from Write Coordinator
 func (h *Handler) ReplicateWriteHandler(w http.ResponseWriter, r *http.Request) {
 	var req ReplicateWriteRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if blob.IsRaw(r) {
+		// Large values arrive raw, with the metadata in headers
+		key, version, err := blob.Metadata(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value, err := blob.ReadValue(w, r, h.store.GetMaxValueSize())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req = ReplicateWriteRequest{Key: key, Value: value, Version: version, HintFor: r.Header.Get(HintForHeader)}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Node sleeps 100ms when receiving update before responding
-	time.Sleep(100 * time.Millisecond)
+	// Injected receive delay (Node sleeps 100ms when receiving update before
+	// responding in the homework profile)
+	h.config.GetFaults().Delay(fault.StageReceiveWrite)
 
-	// Set the value with the provided version
-	if err := h.store.SetWithVersion(req.Key, req.Value, req.Version); err != nil {
+	if err := h.applyReplicated(req); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ReplicateWriteResponse{
@@ -131,6 +841,19 @@ func (h *Handler) ReplicateWriteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// As a substitute, hold the write for the node it was meant for
+	if req.HintFor != "" && req.HintFor != h.config.GetMyAddr() {
+		if err := h.replicator.StoreHint(req); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ReplicateWriteResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(ReplicateWriteResponse{
@@ -139,6 +862,555 @@ func (h *Handler) ReplicateWriteHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// ReplicateBatchHandler handles internal batches of writes from a Write
+// Coordinator, acknowledging each write
+func (h *Handler) ReplicateBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReplicateBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReplicateBatchResponse{
+		Responses: h.receiveBatch(req.Writes),
+	})
+}
+
+// receiveBatch applies a batch of writes from a Write Coordinator
+func (h *Handler) receiveBatch(writes []ReplicateWriteRequest) []ReplicateWriteResponse {
+	// Injected receive delay, once per batch like a single write
+	h.config.GetFaults().Delay(fault.StageReceiveWrite)
+
+	responses := make([]ReplicateWriteResponse, len(writes))
+	for i, write := range writes {
+		responses[i] = h.receiveWrite(write)
+	}
+	return responses
+}
+
+// receiveWrite applies a write from a Write Coordinator and, as a
+// substitute, holds it for the node it was meant for
+func (h *Handler) receiveWrite(req ReplicateWriteRequest) ReplicateWriteResponse {
+	if err := h.applyReplicated(req); err != nil {
+		return ReplicateWriteResponse{Success: false, Error: err.Error()}
+	}
+	if req.HintFor != "" && req.HintFor != h.config.GetMyAddr() {
+		if err := h.replicator.StoreHint(req); err != nil {
+			return ReplicateWriteResponse{Success: false, Error: err.Error()}
+		}
+	}
+	return ReplicateWriteResponse{Success: true, Version: req.Version}
+}
+
+// applyReplicated applies a write from Write Coordinator to the store
+// The value is set with the provided version (ignored if a newer version is
+// already stored).
+func (h *Handler) applyReplicated(req ReplicateWriteRequest) error {
+	var err error
+	switch {
+	case req.HintFor != "" && !h.config.IsWriteReplica(req.Key):
+		// A substitute that does not replicate the key only keeps the hint
+	case req.CRDT != nil:
+		_, err = h.replicator.ApplyCRDT(req.Key, req.CRDT)
+	case req.Dot != nil:
+		_, err = h.replicator.ApplySibling(req.Key, kvstore.Sibling{
+			Value:   req.Value,
+			Dot:     *req.Dot,
+			Context: req.Context,
+		})
+	case req.Deleted:
+		_, err = h.replicator.ApplyDelete(req.Key, req.Version)
+	default:
+		_, err = h.replicator.ApplyWrite(req.Key, req.Value, req.Version)
+	}
+	return err
+}
+
+// ApplyEntry applies an entry received through anti-entropy
+// Returns true if it was newer than the local value
+func (h *Handler) ApplyEntry(entry antientropy.Entry) bool {
+	if entry.CRDT != nil {
+		merged, _ := h.replicator.ApplyCRDT(entry.Key, entry.CRDT)
+		return merged
+	}
+	if entry.Siblings != nil {
+		added := false
+		for _, sibling := range entry.Siblings {
+			if ok, _ := h.replicator.ApplySibling(entry.Key, sibling); ok {
+				added = true
+			}
+		}
+		return added
+	}
+	if entry.Deleted {
+		applied, _ := h.replicator.ApplyDelete(entry.Key, entry.Version)
+		return applied
+	}
+	applied, _ := h.replicator.ApplyWrite(entry.Key, entry.Value, entry.Version)
+	return applied
+}
+
+// SharesKey returns true if both this node and a peer replicate a key
+// (the anti-entropy replica filter)
+func (h *Handler) SharesKey(peer, key string) bool {
+	if !h.config.IsReplica(key) {
+		return false
+	}
+	for _, addr := range h.config.GetPreferenceList(key) {
+		if addr == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// PaxosPrepareHandler answers a compare-and-set prepare from a coordinator
+func (h *Handler) PaxosPrepareHandler(w http.ResponseWriter, r *http.Request) {
+	var req PaxosPrepareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.replicator.Acceptor().Prepare(req))
+}
+
+// PaxosProposeHandler answers a compare-and-set proposal from a coordinator
+func (h *Handler) PaxosProposeHandler(w http.ResponseWriter, r *http.Request) {
+	var req PaxosProposal
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.replicator.Acceptor().Propose(req))
+}
+
+// PaxosCommitHandler applies a chosen compare-and-set value
+func (h *Handler) PaxosCommitHandler(w http.ResponseWriter, r *http.Request) {
+	var req PaxosProposal
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.replicator.Acceptor().Commit(req); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// InternalReadHandler handles internal read requests from a Read Coordinator
+func (h *Handler) InternalReadHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "key parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	// Injected read delay (Node sleeps 50ms when receiving read request
+	// in the homework profile)
+	h.config.GetFaults().Delay(fault.StageReceiveRead)
+
+	response := h.internalRead(key)
+	status := http.StatusOK
+	if !response.Exists {
+		status = http.StatusNotFound
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// internalRead reads a key from this node's store for a Read Coordinator
+func (h *Handler) internalRead(key string) ReadResponse {
+	kv, exists := h.store.Get(key)
+	if !exists {
+		return ReadResponse{Exists: false}
+	}
+	return ReadResponse{
+		Key:      kv.Key,
+		Value:    kv.Value,
+		Version:  kv.Version,
+		Siblings: kv.Siblings,
+		CRDT:     kv.CRDT,
+		Deleted:  kv.Deleted,
+		Exists:   true,
+	}
+}
+
+// InternalReadBatchHandler handles internal reads of several keys from a
+// Read Coordinator
+func (h *Handler) InternalReadBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReadBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReadBatchResponse{
+		Responses: h.internalReadBatch(req.Keys),
+	})
+}
+
+// internalReadBatch reads several keys from this node's store for a Read
+// Coordinator
+func (h *Handler) internalReadBatch(keys []string) []ReadResponse {
+	// Injected read delay, once per batch like a single read
+	h.config.GetFaults().Delay(fault.StageReceiveRead)
+
+	responses := make([]ReadResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = h.internalRead(key)
+	}
+	return responses
+}
+
+// ConfigHandler handles configuration requests
+// Any node accepts a configuration change and propagates it to the others
+func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		// Get current configuration
+		readR, writeW := h.config.GetReplicationParams()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"node_id":        h.config.NodeID,
+			"mode":           "leaderless",
+			"n":              h.config.GetN(),
+			"rf":             h.config.GetReplicationFactor(),
+			"r":              readR,
+			"w":              writeW,
+			"config_version": h.config.GetConfigVersion(),
+			"conflicts":      h.config.GetConflictResolution(),
+		})
+		return
+	}
+
+	if r.Method == "POST" {
+		// Set R and W values
+		var req struct {
+			R int `json:"r"`
+			W int `json:"w"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		rf := h.config.GetReplicationFactor()
+		if req.R < 1 || req.R > rf || req.W < 1 || req.W > rf {
+			http.Error(w, "R and W must be between 1 and the replication factor", http.StatusBadRequest)
+			return
+		}
+
+		// Apply locally and replicate to all other nodes
+		version, applied, err := h.replicator.UpdateConfig(req.R, req.W)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":         "configuration updated",
+			"r":              req.R,
+			"w":              req.W,
+			"config_version": version,
+			"applied_nodes":  applied,
+		})
+		return
+	}
+
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// ReplicateConfigHandler handles configuration changes propagated by another node
+func (h *Handler) ReplicateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req ClusterConfig
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rf := h.config.GetReplicationFactor()
+	if req.R < 1 || req.R > rf || req.W < 1 || req.W > rf {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReplicateConfigResponse{
+			Success: false,
+			Error:   "R and W must be between 1 and the replication factor",
+		})
+		return
+	}
+
+	// Older versions are ignored; the node is already at least this current
+	h.replicator.ApplyConfig(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReplicateConfigResponse{
+		Success: true,
+		Version: h.config.GetConfigVersion(),
+	})
+}
+
+// InternalConfigHandler returns this node's versioned cluster configuration
+func (h *Handler) InternalConfigHandler(w http.ResponseWriter, r *http.Request) {
+	readR, writeW := h.config.GetReplicationParams()
+	membership := h.config.GetMembership()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ClusterConfig{
+		R:          readR,
+		W:          writeW,
+		Version:    h.config.GetConfigVersion(),
+		Membership: &membership,
+	})
+}
+
+// SyncConfig pulls the current cluster configuration from the other nodes,
+// retrying until at least one of them responds or attempts run out
+func (h *Handler) SyncConfig(attempts int, interval time.Duration) {
+	for i := 0; i < attempts; i++ {
+		if h.replicator.SyncConfig() {
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// ClusterStatusHandler reports this node's configuration, hinted handoff
+// metrics (hints held here for other nodes) and replica read latencies
+func (h *Handler) ClusterStatusHandler(w http.ResponseWriter, r *http.Request) {
+	readR, writeW := h.config.GetReplicationParams()
+	hedged, _, _ := h.config.GetHedging()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"node_id":         h.config.NodeID,
+		"mode":            "leaderless",
+		"applied_version": h.store.GetVersion(),
+		"config_version":  h.config.GetConfigVersion(),
+		"r":               readR,
+		"w":               writeW,
+		"sloppy_quorum":   h.config.UsesSloppyQuorum(),
+		"hinted_handoff":  h.replicator.HintStats(),
+		"hedged_reads":    hedged,
+		"read_latency":    h.replicator.GetReadLatency(),
+	})
+}
+
+// RingHandler shows how the consistent-hash ring places keys on nodes
+// With ?key=... it also returns the key's preference list (its replicas),
+// and with &namespace=... that of the key in a namespace.
+func (h *Handler) RingHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if name := r.URL.Query().Get("namespace"); name != "" && key != "" {
+		key = namespace.Key(name, key)
+	}
+
+	ring := h.config.GetRing()
+	response := map[string]interface{}{
+		"nodes":              h.config.GetN(),
+		"replication_factor": h.config.GetReplicationFactor(),
+		"virtual_nodes":      ring.VirtualNodes(),
+		"ownership":          ring.Ownership(),
+		"tokens":             ring.Tokens(),
+	}
+	if key != "" {
+		response["key"] = r.URL.Query().Get("key")
+		response["preference_list"] = h.config.GetPreferenceList(key)
+	}
+	if _, pending := h.config.GetRings(); pending != nil {
+		// Rebalancing: also show where keys are moving to
+		response["pending_ownership"] = pending.Ownership()
+		if key != "" {
+			response["pending_preference_list"] = pending.PreferenceList(key, h.config.GetKeyReplicationFactor(key))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// MembersHandler shows or changes the ring membership
+// POST {"nodes": [...]} starts moving data to a ring of the given nodes and
+// returns immediately; follow the progress with GET /cluster/rebalance.
+func (h *Handler) MembersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(h.config.GetMembership())
+		return
+	}
+
+	var req struct {
+		Nodes []string `json:"nodes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Nodes))
+	for _, addr := range req.Nodes {
+		if addr == "" || seen[addr] {
+			http.Error(w, "nodes must be unique, non-empty addresses", http.StatusBadRequest)
+			return
+		}
+		seen[addr] = true
+	}
+	if len(req.Nodes) < h.config.GetReplicationFactor() {
+		http.Error(w, "nodes must hold at least the replication factor", http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.rebalancer.ChangeMembership(req.Nodes)
+	if err != nil {
+		if errors.Is(err, ErrRebalanceInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(change)
+}
+
+// RebalanceHandler reports rebalancing progress: this node's streaming and,
+// if this node coordinated a membership change, the progress of every node
+func (h *Handler) RebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"membership": h.config.GetMembership(),
+		"streaming":  h.rebalancer.GetStatus(),
+		"rate_limit": h.config.GetRebalanceRate(),
+	}
+	if change := h.rebalancer.GetChange(); change != nil {
+		response["change"] = change
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RebalanceAdminHandler throttles rebalancing on this node
+// POST {"rate": keys per second} (0 = unlimited); applies to streaming in progress
+func (h *Handler) RebalanceAdminHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		var req struct {
+			Rate *int `json:"rate"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Rate == nil || *req.Rate < 0 {
+			http.Error(w, "rate must be 0 (unlimited) or more keys per second", http.StatusBadRequest)
+			return
+		}
+		h.config.SetRebalanceRate(*req.Rate)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rate_limit": h.config.GetRebalanceRate(),
+	})
+}
+
+// InternalMembershipHandler applies a ring membership pushed by the node
+// coordinating a membership change
+func (h *Handler) InternalMembershipHandler(w http.ResponseWriter, r *http.Request) {
+	var req Membership
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Older versions are ignored; the node is already at least this current
+	if h.config.ApplyMembership(req, h.config.IsJoining()) {
+		h.config.SetJoining(false)
+		log.Printf("Ring membership version %d: members %v, pending %v", req.Version, req.Members, req.Pending)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.config.GetMembership())
+}
+
+// InternalRebalanceStartHandler starts streaming this node's moved keys
+func (h *Handler) InternalRebalanceStartHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Version int64 `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.rebalancer.Start(req.Version) {
+		http.Error(w, "membership version is not pending on this node", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.rebalancer.GetStatus())
+}
+
+// InternalRebalanceStatusHandler returns this node's streaming progress
+func (h *Handler) InternalRebalanceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.rebalancer.GetStatus())
+}
+
+// RebalanceStreamHandler stores keys streamed from a previous owner
+func (h *Handler) RebalanceStreamHandler(w http.ResponseWriter, r *http.Request) {
+	var req antientropy.EntriesMessage
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	applied := 0
+	for _, entry := range req.Entries {
+		if h.ApplyEntry(entry) {
+			applied++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"applied": applied,
+	})
+}
+
 // HealthHandler provides a health check endpoint
 func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
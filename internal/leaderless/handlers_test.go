@@ -0,0 +1,126 @@
+package leaderless
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestIncrement(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     string // Value of the key before the request ("" = none)
+		path       string
+		body       string
+		wantStatus int
+		wantValue  string // Value of the key after the request
+	}{
+		{name: "first increment", path: "/incr", body: `{"key": "k", "initial": 10}`, wantStatus: http.StatusOK, wantValue: "11"},
+		{name: "decrement", stored: "5", path: "/decr", body: `{"key": "k", "delta": 7}`, wantStatus: http.StatusOK, wantValue: "-2"},
+		{name: "overflow", stored: "9223372036854775807", path: "/incr", body: `{"key": "k"}`, wantStatus: http.StatusConflict, wantValue: "9223372036854775807"},
+		{name: "underflow", stored: "-9223372036854775807", path: "/decr", body: `{"key": "k", "delta": 2}`, wantStatus: http.StatusConflict, wantValue: "-9223372036854775807"},
+		{name: "decrement by the minimum", stored: "0", path: "/decr", body: `{"key": "k", "delta": -9223372036854775808}`, wantStatus: http.StatusBadRequest, wantValue: "0"},
+		{name: "not an integer", stored: "abc", path: "/incr", body: `{"key": "k"}`, wantStatus: http.StatusConflict, wantValue: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := "localhost:1"
+			store := kvstore.NewStore()
+			h := NewHandler(store, NewConfig("n1", addr, []string{addr}))
+			if tt.stored != "" {
+				store.Set("k", []byte(tt.stored))
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if tt.path == "/decr" {
+				h.DecrHandler(w, r)
+			} else {
+				h.IncrHandler(w, r)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tt.wantStatus)
+			}
+			if kv, _ := store.LocalRead("k"); kv == nil || string(kv.Value) != tt.wantValue {
+				t.Fatalf("got stored %v, want %q", kv, tt.wantValue)
+			}
+			if w.Code == http.StatusOK {
+				var response struct {
+					Value json.Number `json:"value"`
+				}
+				json.NewDecoder(w.Body).Decode(&response)
+				if response.Value.String() != tt.wantValue {
+					t.Fatalf("got value %s, want %s", response.Value, tt.wantValue)
+				}
+			}
+		})
+	}
+}
+
+func TestRawCausalValues(t *testing.T) {
+	addr := "localhost:1"
+	config := NewConfig("n1", addr, []string{addr})
+	config.SetConflictResolution(ConflictVectorClock)
+	h := NewHandler(kvstore.NewStore(), config)
+	x, y, z := []byte{0, 0xff, 'x'}, []byte{0, 0xfe, 'y'}, []byte{0xc3, 0x28}
+
+	put := func(value []byte, context string) {
+		t.Helper()
+		r := mux.SetURLVars(httptest.NewRequest("PUT", "/kv/k", bytes.NewReader(value)), map[string]string{"key": "k"})
+		r.Header.Set("Content-Type", blob.ContentType)
+		if context != "" {
+			r.Header.Set(blob.ContextHeader, context)
+		}
+		w := httptest.NewRecorder()
+		h.PutValueHandler(w, r)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("PUT got status %d (%s)", w.Code, strings.TrimSpace(w.Body.String()))
+		}
+	}
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.GetValueHandler(w, mux.SetURLVars(httptest.NewRequest("GET", "/kv/k", nil), map[string]string{"key": "k"}))
+		return w
+	}
+
+	// Two blind writes are concurrent, so both values are kept
+	put(x, "")
+	put(y, "")
+	w := get()
+	if w.Code != http.StatusMultipleChoices {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusMultipleChoices)
+	}
+	var siblings struct {
+		Values  [][]byte `json:"values"`
+		Context string   `json:"context"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&siblings); err != nil {
+		t.Fatal(err)
+	}
+	if len(siblings.Values) != 2 || siblings.Context != w.Header().Get(blob.ContextHeader) {
+		t.Fatalf("got %+v", siblings)
+	}
+	for _, value := range siblings.Values {
+		if !bytes.Equal(value, x) && !bytes.Equal(value, y) {
+			t.Fatalf("got value %v, want %v or %v", value, x, y)
+		}
+	}
+
+	// A write with their context replaces both
+	put(z, siblings.Context)
+	w = get()
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), z) {
+		t.Fatalf("got status %d and value %v, want %v", w.Code, w.Body.Bytes(), z)
+	}
+	if w.Header().Get(blob.ContextHeader) == "" {
+		t.Fatal("missing context header")
+	}
+}
@@ -0,0 +1,259 @@
+package leaderless
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default hinted handoff settings
+const (
+	DefaultHintTTL      = 3 * time.Hour // Hints older than this are discarded
+	DefaultMaxHints     = 10000         // Hints kept per node (oldest dropped first)
+	hintReplayInterval  = 2 * time.Second
+	hintReplayBatchSize = 100
+)
+
+// Hint is a write held by a substitute node on behalf of a node that was
+// unreachable when the write was coordinated
+type Hint struct {
+	Owner     string                `json:"owner"`
+	Write     ReplicateWriteRequest `json:"write"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// HintStats reports the hinted handoff state of a node
+type HintStats struct {
+	Pending        int            `json:"pending"`
+	PendingByOwner map[string]int `json:"pending_by_owner"`
+	OldestPending  *time.Time     `json:"oldest_pending,omitempty"`
+	StoredTotal    int64          `json:"stored_total"`
+	ReplayedTotal  int64          `json:"replayed_total"`
+	ExpiredTotal   int64          `json:"expired_total"`
+	DroppedTotal   int64          `json:"dropped_total"` // Evicted because the store was full
+}
+
+// HintStore keeps hints for unreachable nodes until they can be handed off
+// Hints are persisted to a JSON file (rewritten on every change) so that
+// they survive a restart of the substitute node.
+type HintStore struct {
+	mu       sync.Mutex
+	path     string // Empty = memory only
+	ttl      time.Duration
+	maxHints int
+	hints    []*Hint // Oldest first
+
+	storedTotal   int64
+	replayedTotal int64
+	expiredTotal  int64
+	droppedTotal  int64
+}
+
+// NewHintStore creates a hint store, loading any hints persisted at path
+func NewHintStore(path string, ttl time.Duration, maxHints int) (*HintStore, error) {
+	if ttl <= 0 {
+		ttl = DefaultHintTTL
+	}
+	if maxHints < 1 {
+		maxHints = DefaultMaxHints
+	}
+	s := &HintStore{
+		path:     path,
+		ttl:      ttl,
+		maxHints: maxHints,
+	}
+
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hints: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.hints); err != nil {
+		return nil, fmt.Errorf("failed to parse hints: %w", err)
+	}
+	return s, nil
+}
+
+// Add stores a hint for an owner
+// For last-writer-wins writes only the newest hinted version of a key is
+// kept per owner, and CRDT states of a key are merged into one hint.
+func (s *HintStore) Add(owner string, write ReplicateWriteRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	write.HintFor = ""
+	if write.Dot == nil {
+		for i, hint := range s.hints {
+			if hint.Owner == owner && hint.Write.Key == write.Key && hint.Write.Dot == nil {
+				s.storedTotal++
+				if write.CRDT != nil && hint.Write.CRDT != nil {
+					merged := hint.Write.CRDT.Clone()
+					merged.Merge(write.CRDT)
+					if merged.Equal(hint.Write.CRDT) {
+						return nil
+					}
+					write.CRDT = merged
+				} else if hint.Write.CRDT != nil || (write.CRDT == nil && write.Version <= hint.Write.Version) {
+					// A CRDT state supersedes plain values of the key
+					return nil
+				}
+				// Replace rather than update the hint so that a replay of the
+				// older version in progress cannot remove the newer one
+				s.hints = append(s.hints[:i], s.hints[i+1:]...)
+				break
+			}
+		}
+	}
+
+	s.hints = append(s.hints, &Hint{
+		Owner:     owner,
+		Write:     write,
+		CreatedAt: time.Now().UTC(),
+	})
+	s.storedTotal++
+
+	if overflow := len(s.hints) - s.maxHints; overflow > 0 {
+		s.hints = s.hints[overflow:]
+		s.droppedTotal += int64(overflow)
+	}
+	return s.persist()
+}
+
+// Owners returns the owners that have pending hints
+func (s *HintStore) Owners() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var owners []string
+	for _, hint := range s.hints {
+		if !seen[hint.Owner] {
+			seen[hint.Owner] = true
+			owners = append(owners, hint.Owner)
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// Pending returns up to limit of the oldest hints for an owner
+func (s *HintStore) Pending(owner string, limit int) []*Hint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*Hint
+	for _, hint := range s.hints {
+		if hint.Owner == owner {
+			pending = append(pending, hint)
+			if len(pending) == limit {
+				break
+			}
+		}
+	}
+	return pending
+}
+
+// Delivered removes hints that were handed off to their owner
+func (s *HintStore) Delivered(delivered []*Hint) error {
+	if len(delivered) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	done := make(map[*Hint]bool, len(delivered))
+	for _, hint := range delivered {
+		done[hint] = true
+	}
+	remaining := s.hints[:0]
+	for _, hint := range s.hints {
+		if done[hint] {
+			s.replayedTotal++
+			continue
+		}
+		remaining = append(remaining, hint)
+	}
+	s.hints = remaining
+	return s.persist()
+}
+
+// Expire discards hints older than the retention limit
+// Returns the number of hints discarded
+func (s *HintStore) Expire() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.ttl)
+	remaining := s.hints[:0]
+	for _, hint := range s.hints {
+		if hint.CreatedAt.Before(cutoff) {
+			continue
+		}
+		remaining = append(remaining, hint)
+	}
+	expired := len(s.hints) - len(remaining)
+	s.hints = remaining
+	if expired == 0 {
+		return 0, nil
+	}
+	s.expiredTotal += int64(expired)
+	return expired, s.persist()
+}
+
+// Stats returns the hinted handoff metrics
+func (s *HintStore) Stats() HintStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := HintStats{
+		Pending:        len(s.hints),
+		PendingByOwner: make(map[string]int),
+		StoredTotal:    s.storedTotal,
+		ReplayedTotal:  s.replayedTotal,
+		ExpiredTotal:   s.expiredTotal,
+		DroppedTotal:   s.droppedTotal,
+	}
+	for _, hint := range s.hints {
+		stats.PendingByOwner[hint.Owner]++
+	}
+	if len(s.hints) > 0 {
+		oldest := s.hints[0].CreatedAt
+		stats.OldestPending = &oldest
+	}
+	return stats
+}
+
+// persist writes all hints to disk (caller holds s.mu)
+// The file is replaced atomically so a crash never leaves it half written
+func (s *HintStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.hints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hints: %w", err)
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create hint directory: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write hints: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write hints: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package leaderless
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestHintStoreAdd(t *testing.T) {
+	dot := &kvstore.Dot{Node: "n1", Counter: 1}
+
+	tests := []struct {
+		name     string
+		adds     []Hint // Owner and Write of each hint added
+		maxHints int
+		want     map[string][]int64 // Versions pending per owner, oldest first
+	}{
+		{
+			name: "newer version replaces the hint",
+			adds: []Hint{
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Version: 1}},
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Version: 2}},
+			},
+			want: map[string][]int64{"a": {2}},
+		},
+		{
+			name: "older version is dropped",
+			adds: []Hint{
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Version: 2}},
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Version: 1}},
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Version: 2}},
+			},
+			want: map[string][]int64{"a": {2}},
+		},
+		{
+			name: "owners and keys are kept apart",
+			adds: []Hint{
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Version: 1}},
+				{Owner: "b", Write: ReplicateWriteRequest{Key: "k", Version: 2}},
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "j", Version: 3}},
+			},
+			want: map[string][]int64{"a": {1, 3}, "b": {2}},
+		},
+		{
+			name: "causal writes are all kept",
+			adds: []Hint{
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Dot: dot}},
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k", Dot: &kvstore.Dot{Node: "n2", Counter: 1}}},
+			},
+			want: map[string][]int64{"a": {0, 0}},
+		},
+		{
+			name: "oldest hints are evicted when full",
+			adds: []Hint{
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k1", Version: 1}},
+				{Owner: "a", Write: ReplicateWriteRequest{Key: "k2", Version: 2}},
+				{Owner: "b", Write: ReplicateWriteRequest{Key: "k3", Version: 3}},
+			},
+			maxHints: 2,
+			want:     map[string][]int64{"a": {2}, "b": {3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hints.json")
+			store, err := NewHintStore(path, 0, tt.maxHints)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, hint := range tt.adds {
+				hint.Write.HintFor = hint.Owner
+				if err := store.Add(hint.Owner, hint.Write); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The hints survive a restart
+			reloaded, err := NewHintStore(path, 0, tt.maxHints)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range []*HintStore{store, reloaded} {
+				for owner, want := range tt.want {
+					pending := s.Pending(owner, 100)
+					if len(pending) != len(want) {
+						t.Fatalf("%s: got %d hints, want %v", owner, len(pending), want)
+					}
+					for i, hint := range pending {
+						if hint.Write.Version != want[i] || hint.Write.HintFor != "" {
+							t.Fatalf("%s: hint %d is %+v, want version %d", owner, i, hint.Write, want[i])
+						}
+					}
+				}
+				if got := len(s.Owners()); got != len(tt.want) {
+					t.Fatalf("got %d owners, want %d", got, len(tt.want))
+				}
+			}
+		})
+	}
+}
+
+func TestHintStoreDelivered(t *testing.T) {
+	store, _ := NewHintStore("", 0, 0)
+	store.Add("a", ReplicateWriteRequest{Key: "k", Version: 1})
+	replaying := store.Pending("a", 10)
+
+	// A newer write arrives while the older one is being handed off
+	store.Add("a", ReplicateWriteRequest{Key: "k", Version: 2})
+	if err := store.Delivered(replaying); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := store.Pending("a", 10)
+	if len(pending) != 1 || pending[0].Write.Version != 2 {
+		t.Fatalf("got %d pending hints, want the one of version 2", len(pending))
+	}
+	if stats := store.Stats(); stats.ReplayedTotal != 0 || stats.Pending != 1 {
+		t.Fatalf("got %+v, want 0 replayed and 1 pending", stats)
+	}
+}
+
+func TestHintStoreMergesCRDT(t *testing.T) {
+	counter := func(actor string, amount int64) *kvstore.CRDT {
+		c, _ := kvstore.NewCRDT(kvstore.TypeGCounter)
+		c.Apply(kvstore.Operation{Op: kvstore.OpIncrement, Amount: amount}, actor, 0)
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		writes []ReplicateWriteRequest
+		want   string // Result of the only pending hint, as JSON
+	}{
+		{
+			name: "states of two actors",
+			writes: []ReplicateWriteRequest{
+				{Key: "k", CRDT: counter("n1", 2)},
+				{Key: "k", CRDT: counter("n2", 3)},
+			},
+			want: `5`,
+		},
+		{
+			name: "state already hinted",
+			writes: []ReplicateWriteRequest{
+				{Key: "k", CRDT: counter("n1", 2)},
+				{Key: "k", CRDT: counter("n1", 1)},
+			},
+			want: `2`,
+		},
+		{
+			name: "plain value after a state",
+			writes: []ReplicateWriteRequest{
+				{Key: "k", CRDT: counter("n1", 2)},
+				{Key: "k", Version: 10},
+			},
+			want: `2`,
+		},
+		{
+			name: "state after a plain value",
+			writes: []ReplicateWriteRequest{
+				{Key: "k", Version: 10},
+				{Key: "k", CRDT: counter("n1", 2)},
+			},
+			want: `2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := NewHintStore("", 0, 0)
+			for _, write := range tt.writes {
+				if err := store.Add("a", write); err != nil {
+					t.Fatal(err)
+				}
+			}
+			pending := store.Pending("a", 10)
+			if len(pending) != 1 || pending[0].Write.CRDT == nil {
+				t.Fatalf("got %d pending hints, want one CRDT state", len(pending))
+			}
+			if got, _ := json.Marshal(pending[0].Write.CRDT.Result()); string(got) != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
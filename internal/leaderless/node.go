@@ -1,18 +1,56 @@
 package leaderless
 
 import (
+	"fmt"
+	"hash/fnv"
 	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/gossip"
+	"github.com/yourusername/distributed-kv-store/internal/hlc"
+	"github.com/yourusername/distributed-kv-store/internal/namespace"
+	"github.com/yourusername/distributed-kv-store/internal/rpc"
+)
+
+// Conflict resolution modes
+const (
+	ConflictLWW         = "lww"    // Last-writer-wins on hybrid logical clock versions
+	ConflictVectorClock = "vclock" // Vector clocks; concurrent writes are kept as siblings
 )
 
 // Config holds the configuration for the Leaderless cluster
 type Config struct {
-	mu           sync.RWMutex
-	NodeID       string   // Unique identifier for this node
-	MyAddr       string   // Address of this node
-	AllNodeAddrs []string // All node addresses in the cluster
-	N            int      // Total number of nodes (default: 5)
-	R            int      // Read quorum size (always 1 for leaderless)
-	W            int      // Write quorum size (always N for leaderless)
+	mu            sync.RWMutex
+	NodeID        string          // Unique identifier for this node
+	MyAddr        string          // Address of this node
+	AllNodeAddrs  []string        // All node addresses in the cluster
+	N             int             // Total number of nodes (default: 5)
+	RF            int             // Replicas per key (default: N)
+	Ring          *Ring           // Consistent-hash ring placing keys on nodes
+	RingVersion   int64           // Version of the ring membership
+	PendingRing   *Ring           // Ring being rebalanced to (nil = none)
+	PendingAddrs  []string        // Members of the pending ring
+	Joining       bool            // Adopt the cluster's membership at startup
+	R             int             // Read quorum size (default: 1)
+	W             int             // Write quorum size (default: N)
+	ConfigVersion int64           // Version of the cluster-wide R/W configuration
+	Faults        *fault.Injector // Injected delays and faults (nil = none)
+	GRPC          *rpc.Pool       // gRPC transport for writes and reads between nodes (nil = HTTP JSON)
+	Conflicts     string          // Conflict resolution mode (default: lww)
+	SloppyQuorum  bool            // Count hints on substitutes toward W (default: false)
+	HintFile      string          // Where hints for unreachable nodes are persisted ("" = memory only)
+	HintTTL       time.Duration   // How long hints are kept
+	MaxHints      int             // Maximum number of hints kept
+	RebalanceRate int             // Keys per second streamed while rebalancing (0 = unlimited)
+
+	membershipObserver func()           // Called after the ring membership changes
+	Detector           *gossip.Detector // Live view of peer state (nil = every peer alive)
+	HedgedReads        bool             // Send speculative reads to extra replicas when replies are slow
+	HedgeDelay         time.Duration    // Wait before a speculative read (0 = observed percentile)
+	HedgePercentile    float64          // Latency percentile used as the hedge delay
+
+	Namespaces *namespace.Registry // Settings of namespaced keys (nil = none)
 }
 
 // NewConfig creates a new leaderless configuration
@@ -22,8 +60,13 @@ func NewConfig(nodeID string, myAddr string, allNodeAddrs []string) *Config {
 		MyAddr:       myAddr,
 		AllNodeAddrs: allNodeAddrs,
 		N:            len(allNodeAddrs),
-		R:            1, // Always 1 for leaderless
-		W:            len(allNodeAddrs), // Always N for leaderless
+		R:            1,                 // Default
+		W:            len(allNodeAddrs), // Default
+		Conflicts:    ConflictLWW,       // Default
+		HintTTL:      DefaultHintTTL,
+		MaxHints:     DefaultMaxHints,
+		RF:           len(allNodeAddrs),
+		Ring:         NewRing(allNodeAddrs, DefaultVirtualNodes),
 	}
 }
 
@@ -42,19 +85,326 @@ func (c *Config) GetAllNodeAddrs() []string {
 }
 
 // GetOtherNodeAddrs returns addresses of all other nodes (excluding self)
+// While rebalancing this includes the members of the pending ring
 func (c *Config) GetOtherNodeAddrs() []string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	
-	otherAddrs := make([]string, 0, len(c.AllNodeAddrs)-1)
-	for _, addr := range c.AllNodeAddrs {
-		if addr != c.MyAddr {
+
+	seen := map[string]bool{c.MyAddr: true}
+	otherAddrs := make([]string, 0, len(c.AllNodeAddrs))
+	for _, addr := range append(append([]string{}, c.AllNodeAddrs...), c.PendingAddrs...) {
+		if !seen[addr] {
+			seen[addr] = true
 			otherAddrs = append(otherAddrs, addr)
 		}
 	}
 	return otherAddrs
 }
 
+// Validate returns an error if this node's address is not in the cluster
+// address list or two nodes would issue the same write versions
+func (c *Config) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !contains(c.AllNodeAddrs, c.MyAddr) {
+		return fmt.Errorf("node address %s not found in all-node-addrs list", c.MyAddr)
+	}
+	return CheckClockIDs(c.AllNodeAddrs)
+}
+
+// ClockID returns the node id used as the tiebreaker in the write versions
+// of the node at addr
+// It is a hash of the address, so it stays the same when the member list
+// changes.
+func ClockID(addr string) int {
+	h := fnv.New32a()
+	h.Write([]byte(addr))
+	return int(h.Sum32() % (hlc.MaxNodeID + 1))
+}
+
+// CheckClockIDs returns an error if two of the given nodes have the same
+// clock id
+func CheckClockIDs(addrs []string) error {
+	seen := make(map[int]string)
+	for _, addr := range addrs {
+		id := ClockID(addr)
+		if other, ok := seen[id]; ok && other != addr {
+			return fmt.Errorf("nodes %s and %s have the same clock id %d; change one of their addresses", other, addr, id)
+		}
+		seen[id] = addr
+	}
+	return nil
+}
+
+// SetPartitioning sets the replication factor and rebuilds the ring with
+// the given number of virtual nodes per node
+func (c *Config) SetPartitioning(rf int, vnodes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.RF = rf
+	c.Ring = NewRing(c.AllNodeAddrs, vnodes)
+}
+
+// GetReplicationFactor returns the number of replicas per key
+func (c *Config) GetReplicationFactor() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.RF
+}
+
+// GetRing returns the consistent-hash ring
+func (c *Config) GetRing() *Ring {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Ring
+}
+
+// GetKeyReplicationFactor returns the number of replicas of a key: its
+// namespace's replication factor, or the cluster's
+// The namespace registry is replicated to every node, so that each node
+// can build the indexes of the namespaces.
+func (c *Config) GetKeyReplicationFactor(key string) int {
+	// The registry may be read from other nodes, so without holding c.mu
+	c.mu.RLock()
+	namespaces, rf, n := c.Namespaces, c.RF, c.N
+	c.mu.RUnlock()
+
+	if namespace.IsRegistryKey(key) {
+		return n
+	}
+
+	if settings, ok := namespaces.Lookup(key); ok && settings.ReplicationFactor > 0 {
+		return settings.ReplicationFactor
+	}
+	return rf
+}
+
+// GetPreferenceList returns the nodes that replicate a key, in ring order
+func (c *Config) GetPreferenceList(key string) []string {
+	rf := c.GetKeyReplicationFactor(key)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Ring.PreferenceList(key, rf)
+}
+
+// IsReplica returns true if this node replicates a key
+func (c *Config) IsReplica(key string) bool {
+	for _, addr := range c.GetPreferenceList(key) {
+		if addr == c.GetMyAddr() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOtherReplicas returns the nodes other than this one that replicate a key
+func (c *Config) GetOtherReplicas(key string) []string {
+	myAddr := c.GetMyAddr()
+	var others []string
+	for _, addr := range c.GetPreferenceList(key) {
+		if addr != myAddr {
+			others = append(others, addr)
+		}
+	}
+	return others
+}
+
+// GetWriteReplicas returns the nodes other than this one that must receive
+// a write to a key. While rebalancing, writes go to the key's replicas on
+// both the current and the pending ring; pending is the number of replicas
+// only on the pending ring, which are added to the write quorum.
+func (c *Config) GetWriteReplicas(key string) (others []string, pending int) {
+	rf := c.GetKeyReplicationFactor(key)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	current := c.Ring.PreferenceList(key, rf)
+	seen := make(map[string]bool, len(current))
+	for _, addr := range current {
+		seen[addr] = true
+		if addr != c.MyAddr {
+			others = append(others, addr)
+		}
+	}
+	if c.PendingRing == nil {
+		return others, 0
+	}
+	for _, addr := range c.PendingRing.PreferenceList(key, rf) {
+		if seen[addr] {
+			continue
+		}
+		pending++
+		if addr != c.MyAddr {
+			others = append(others, addr)
+		}
+	}
+	return others, pending
+}
+
+// IsWriteReplica returns true if this node stores writes to a key
+// (it replicates the key on the current or the pending ring)
+func (c *Config) IsWriteReplica(key string) bool {
+	if c.IsReplica(key) {
+		return true
+	}
+	rf := c.GetKeyReplicationFactor(key)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.PendingRing == nil {
+		return false
+	}
+	for _, addr := range c.PendingRing.PreferenceList(key, rf) {
+		if addr == c.MyAddr {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMembership returns the versioned ring membership
+func (c *Config) GetMembership() Membership {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Membership{
+		Version: c.RingVersion,
+		Members: append([]string{}, c.AllNodeAddrs...),
+		Pending: append([]string(nil), c.PendingAddrs...),
+	}
+}
+
+// GetRings returns the current ring and the pending ring (nil if the
+// cluster is not rebalancing)
+func (c *Config) GetRings() (current *Ring, pending *Ring) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Ring, c.PendingRing
+}
+
+// ApplyMembership adopts a ring membership if it is newer than the local
+// one (or unconditionally with force, used when a node joins)
+// Returns true if it was applied
+func (c *Config) ApplyMembership(m Membership, force bool) bool {
+	c.mu.Lock()
+	if !force && m.Version <= c.RingVersion {
+		c.mu.Unlock()
+		return false
+	}
+	vnodes := c.Ring.VirtualNodes()
+	c.RingVersion = m.Version
+	c.AllNodeAddrs = append([]string{}, m.Members...)
+	c.N = len(m.Members)
+	c.Ring = NewRing(m.Members, vnodes)
+	c.PendingRing = nil
+	c.PendingAddrs = nil
+	if len(m.Pending) > 0 {
+		c.PendingRing = NewRing(m.Pending, vnodes)
+		c.PendingAddrs = append([]string{}, m.Pending...)
+	}
+	observer := c.membershipObserver
+	c.mu.Unlock()
+
+	if observer != nil {
+		observer()
+	}
+	return true
+}
+
+// SetMembershipObserver registers a function called after the ring
+// membership changes
+func (c *Config) SetMembershipObserver(observer func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.membershipObserver = observer
+}
+
+// SetJoining marks this node as joining an existing cluster
+func (c *Config) SetJoining(joining bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Joining = joining
+}
+
+// IsJoining returns true if this node has not yet adopted the cluster's membership
+func (c *Config) IsJoining() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Joining
+}
+
+// SetRebalanceRate sets the rebalancing throttle in keys per second
+func (c *Config) SetRebalanceRate(rate int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.RebalanceRate = rate
+}
+
+// GetRebalanceRate returns the rebalancing throttle in keys per second
+func (c *Config) GetRebalanceRate() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.RebalanceRate
+}
+
+// SetReplicationParams sets R and W values
+func (c *Config) SetReplicationParams(r, w int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.R = r
+	c.W = w
+}
+
+// ApplyReplicationParams sets R and W values only if the given version is newer
+// Versions are hybrid logical clock timestamps, which are unique across
+// nodes (they end with the node id), so an equal version is the same
+// change received again and every node orders concurrent changes the same
+// way.
+// Returns true if the configuration was applied
+func (c *Config) ApplyReplicationParams(r, w int, version int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version <= c.ConfigVersion {
+		return false
+	}
+	c.R = r
+	c.W = w
+	c.ConfigVersion = version
+	return true
+}
+
+// GetReplicationParams returns current R and W values
+func (c *Config) GetReplicationParams() (r, w int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.R, c.W
+}
+
+// GetReplicationParamsFor returns R and W for a key
+// A namespaced key uses its namespace's consistency level over its
+// replicas, or the current R and W capped at its replication factor.
+func (c *Config) GetReplicationParamsFor(key string) (r, w int) {
+	c.mu.RLock()
+	namespaces := c.Namespaces
+	c.mu.RUnlock()
+
+	r, w = c.GetReplicationParams()
+	settings, ok := namespaces.Lookup(key)
+	if !ok {
+		return r, w
+	}
+	rf := c.GetKeyReplicationFactor(key)
+	if r, w, ok := settings.Quorums(rf); ok {
+		return r, w
+	}
+	return min(r, rf), min(w, rf)
+}
+
+// GetConfigVersion returns the current config version
+func (c *Config) GetConfigVersion() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ConfigVersion
+}
+
 // GetN returns the total number of nodes
 func (c *Config) GetN() int {
 	c.mu.RLock()
@@ -62,3 +412,110 @@ func (c *Config) GetN() int {
 	return c.N
 }
 
+// SetFaults sets the fault injector used by this node
+func (c *Config) SetFaults(faults *fault.Injector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Faults = faults
+}
+
+// GetFaults returns the fault injector used by this node
+func (c *Config) GetFaults() *fault.Injector {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Faults
+}
+
+// SetGRPC makes writes and reads between nodes use gRPC connections from pool
+func (c *Config) SetGRPC(pool *rpc.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.GRPC = pool
+}
+
+// GetGRPC returns the gRPC connection pool, or nil when nodes talk HTTP JSON
+func (c *Config) GetGRPC() *rpc.Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.GRPC
+}
+
+// SetDetector sets the failure detector used by this node
+func (c *Config) SetDetector(detector *gossip.Detector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Detector = detector
+}
+
+// GetDetector returns the failure detector used by this node
+func (c *Config) GetDetector() *gossip.Detector {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Detector
+}
+
+// SetHedging sets the hedged read settings
+func (c *Config) SetHedging(enabled bool, delay time.Duration, percentile float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.HedgedReads = enabled
+	c.HedgeDelay = delay
+	c.HedgePercentile = percentile
+}
+
+// GetHedging returns the hedged read settings
+func (c *Config) GetHedging() (enabled bool, delay time.Duration, percentile float64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.HedgedReads, c.HedgeDelay, c.HedgePercentile
+}
+
+// SetConflictResolution sets the conflict resolution mode
+func (c *Config) SetConflictResolution(mode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Conflicts = mode
+}
+
+// GetConflictResolution returns the conflict resolution mode
+func (c *Config) GetConflictResolution() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Conflicts
+}
+
+// UsesVectorClocks returns true if concurrent writes are kept as siblings
+func (c *Config) UsesVectorClocks() bool {
+	return c.GetConflictResolution() == ConflictVectorClock
+}
+
+// SetHintedHandoff sets the sloppy quorum and hint retention settings
+func (c *Config) SetHintedHandoff(sloppyQuorum bool, hintFile string, ttl time.Duration, maxHints int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SloppyQuorum = sloppyQuorum
+	c.HintFile = hintFile
+	c.HintTTL = ttl
+	c.MaxHints = maxHints
+}
+
+// GetHintedHandoff returns the sloppy quorum and hint retention settings
+func (c *Config) GetHintedHandoff() (sloppyQuorum bool, hintFile string, ttl time.Duration, maxHints int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.SloppyQuorum, c.HintFile, c.HintTTL, c.MaxHints
+}
+
+// UsesSloppyQuorum returns true if hints on substitutes count toward W
+func (c *Config) UsesSloppyQuorum() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.SloppyQuorum
+}
+
+// SetNamespaces sets the namespace registry used by this node
+func (c *Config) SetNamespaces(namespaces *namespace.Registry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Namespaces = namespaces
+}
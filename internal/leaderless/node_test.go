@@ -0,0 +1,130 @@
+package leaderless
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/hlc"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestApplyReplicationParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     int64
+		wantApplied bool
+		wantR       int
+	}{
+		{name: "newer version", version: 11, wantApplied: true, wantR: 3},
+		{name: "same version", version: 10, wantApplied: false, wantR: 2},
+		{name: "older version", version: 9, wantApplied: false, wantR: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{R: 2, W: 2, ConfigVersion: 10}
+			if applied := config.ApplyReplicationParams(3, 3, tt.version); applied != tt.wantApplied {
+				t.Fatalf("got applied %v, want %v", applied, tt.wantApplied)
+			}
+			if r, _ := config.GetReplicationParams(); r != tt.wantR {
+				t.Fatalf("got R=%d, want %d", r, tt.wantR)
+			}
+		})
+	}
+}
+
+func TestConcurrentConfigChangesConverge(t *testing.T) {
+	const (
+		nodes   = 5
+		changes = 50 // Per node
+	)
+
+	configs := make([]*Config, nodes)
+	clocks := make([]*hlc.Clock, nodes)
+	for i := range configs {
+		configs[i] = &Config{R: 1, W: 1}
+		clocks[i] = hlc.NewClock(i)
+	}
+
+	// Every node accepts changes at the same time as the others, versioned
+	// like UpdateConfig, and delivers them in a random order like ApplyConfig
+	var wg sync.WaitGroup
+	for i := 0; i < nodes; i++ {
+		for change := 0; change < changes; change++ {
+			wg.Add(1)
+			go func(i, change int) {
+				defer wg.Done()
+				r, w := i%3+1, change%3+1
+				version := clocks[i].Now()
+				configs[i].ApplyReplicationParams(r, w, version)
+				for _, j := range rand.Perm(nodes) {
+					clocks[j].Update(version)
+					configs[j].ApplyReplicationParams(r, w, version)
+				}
+			}(i, change)
+		}
+	}
+	wg.Wait()
+
+	wantR, wantW := configs[0].GetReplicationParams()
+	wantVersion := configs[0].GetConfigVersion()
+	for i, config := range configs[1:] {
+		r, w := config.GetReplicationParams()
+		if r != wantR || w != wantW || config.GetConfigVersion() != wantVersion {
+			t.Fatalf("node %d has R=%d W=%d version %d, node 0 has R=%d W=%d version %d",
+				i+1, r, w, config.GetConfigVersion(), wantR, wantW, wantVersion)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// The addresses of the examples in the README
+	cluster := []string{"localhost:8080", "localhost:8081", "localhost:8082", "localhost:8083", "localhost:8084", "localhost:8085"}
+
+	tests := []struct {
+		name    string
+		myAddr  string
+		addrs   []string
+		wantErr bool
+	}{
+		{name: "example cluster", myAddr: "localhost:8080", addrs: cluster},
+		{name: "address not in the list", myAddr: "localhost:9090", addrs: cluster, wantErr: true},
+		{name: "same clock id", myAddr: "localhost:8080", addrs: []string{"localhost:8080", collidingAddr(t, "localhost:8080")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewConfig("n1", tt.myAddr, tt.addrs).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClockIDIgnoresMemberOrder(t *testing.T) {
+	addrs := []string{"localhost:8080", "localhost:8081", "localhost:8082"}
+	before := NewReplicationManager(kvstore.NewStore(), NewConfig("n3", "localhost:8082", addrs)).clock.Now()
+
+	// A node listed ahead of the others leaves every id unchanged
+	grown := append([]string{"localhost:8079"}, addrs...)
+	after := NewReplicationManager(kvstore.NewStore(), NewConfig("n3", "localhost:8082", grown)).clock.Now()
+	if hlc.NodeID(after) != hlc.NodeID(before) {
+		t.Fatalf("got node id %d, want %d", hlc.NodeID(after), hlc.NodeID(before))
+	}
+}
+
+// collidingAddr returns another address with the same clock id as addr
+func collidingAddr(t *testing.T, addr string) string {
+	t.Helper()
+	for port := 1; port < 1<<16; port++ {
+		other := fmt.Sprintf("10.0.0.1:%d", port)
+		if ClockID(other) == ClockID(addr) {
+			return other
+		}
+	}
+	t.Fatalf("no address has the clock id of %s", addr)
+	return ""
+}
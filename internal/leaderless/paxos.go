@@ -0,0 +1,463 @@
+package leaderless
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/hlc"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Compare-and-set settings
+const (
+	casMaxAttempts = 10                    // Paxos rounds tried before giving up under contention
+	casBackoff     = 10 * time.Millisecond // Upper bound of the random wait before a retry, per attempt
+)
+
+// Errors returned by compare-and-set
+var (
+	// ErrCASContention is returned when competing compare-and-set
+	// operations kept pre-empting each other
+	ErrCASContention = errors.New("compare-and-set contention")
+	// ErrCASUnknown is returned when a proposal was pre-empted after some
+	// replicas accepted it: another coordinator may still commit it
+	ErrCASUnknown = errors.New("compare-and-set outcome unknown")
+)
+
+// PaxosPrepareRequest asks a replica to promise a ballot for a key
+type PaxosPrepareRequest struct {
+	Key    string `json:"key"`
+	Ballot int64  `json:"ballot"`
+}
+
+// PaxosPromise is a replica's answer to a prepare
+// A replica that promised also reports the proposal it accepted but has not
+// seen committed, and its current value of the key (which may be a delete).
+type PaxosPromise struct {
+	Promised        bool   `json:"promised"`
+	Ballot          int64  `json:"ballot"`                    // Highest ballot promised
+	AcceptedBallot  int64  `json:"accepted_ballot,omitempty"` // In-progress proposal (0 = none)
+	AcceptedValue   []byte `json:"accepted_value,omitempty"`
+	AcceptedDeleted bool   `json:"accepted_deleted,omitempty"`
+	CommitBallot    int64  `json:"commit_ballot"` // Most recent commit seen
+	Value           []byte `json:"value,omitempty"`
+	Version         int64  `json:"version,omitempty"`
+	Deleted         bool   `json:"deleted,omitempty"` // The most recent version is a delete
+	Exists          bool   `json:"exists"`
+	CRDT            bool   `json:"crdt,omitempty"` // The key holds a CRDT value
+}
+
+// PaxosProposal carries a value (or a delete) proposed or committed under a
+// ballot
+type PaxosProposal struct {
+	Key     string `json:"key"`
+	Ballot  int64  `json:"ballot"`
+	Value   []byte `json:"value"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// PaxosAccepted is a replica's answer to a proposal
+type PaxosAccepted struct {
+	Accepted bool  `json:"accepted"`
+	Ballot   int64 `json:"ballot"` // Highest ballot promised
+}
+
+// paxosState is a replica's Paxos state for one key
+type paxosState struct {
+	promised       int64
+	acceptedBallot int64
+	acceptedValue  []byte
+	acceptedDelete bool
+	commitBallot   int64
+}
+
+// Acceptor keeps the per-key Paxos state of this replica
+// Committed values are applied to the store as LWW writes versioned by
+// their ballot. The state is kept in memory like the store itself, and only
+// while a round is in progress: once a key's last proposal is committed and
+// no higher ballot is promised, the stored version (at least the commit
+// ballot) stands for both, so the state is dropped.
+type Acceptor struct {
+	store *kvstore.Store
+	clock *hlc.Clock // Advanced past every ballot seen, so later writes order after commits
+	keys  map[string]*paxosState
+	mu    sync.Mutex
+}
+
+// NewAcceptor creates an acceptor over a store
+func NewAcceptor(store *kvstore.Store, clock *hlc.Clock) *Acceptor {
+	return &Acceptor{
+		store: store,
+		clock: clock,
+		keys:  make(map[string]*paxosState),
+	}
+}
+
+// state returns the Paxos state of a key; callers hold a.mu
+// A key without state starts from its stored version: no ballot at or below
+// it can be promised again.
+func (a *Acceptor) state(key string) *paxosState {
+	s, ok := a.keys[key]
+	if !ok {
+		s = &paxosState{}
+		if kv, exists := a.store.Get(key); exists {
+			s.promised = kv.Version
+			s.commitBallot = kv.Version
+		}
+		a.keys[key] = s
+	}
+	return s
+}
+
+// prune drops the Paxos state of a key once nothing in it is newer than
+// its last commit; callers hold a.mu
+func (a *Acceptor) prune(key string) {
+	if s, ok := a.keys[key]; ok && s.promised <= s.commitBallot && s.acceptedBallot <= s.commitBallot {
+		delete(a.keys, key)
+	}
+}
+
+// Prepare promises not to accept ballots lower than the given one
+func (a *Acceptor) Prepare(req PaxosPrepareRequest) PaxosPromise {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.prune(req.Key)
+	a.clock.Update(req.Ballot)
+
+	s := a.state(req.Key)
+	if req.Ballot <= s.promised {
+		return PaxosPromise{Promised: false, Ballot: s.promised, CommitBallot: s.commitBallot}
+	}
+	s.promised = req.Ballot
+
+	promise := PaxosPromise{Promised: true, Ballot: s.promised, CommitBallot: s.commitBallot}
+	if s.acceptedBallot > s.commitBallot {
+		promise.AcceptedBallot = s.acceptedBallot
+		promise.AcceptedValue = s.acceptedValue
+		promise.AcceptedDeleted = s.acceptedDelete
+	}
+	if kv, exists := a.store.Get(req.Key); exists {
+		promise.Value = kv.Value
+		promise.Version = kv.Version
+		promise.Deleted = kv.Deleted
+		promise.Exists = true
+		promise.CRDT = kv.CRDT != nil
+	}
+	return promise
+}
+
+// Propose accepts a value unless a higher ballot was promised
+func (a *Acceptor) Propose(req PaxosProposal) PaxosAccepted {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.prune(req.Key)
+	a.clock.Update(req.Ballot)
+
+	s := a.state(req.Key)
+	if req.Ballot < s.promised {
+		return PaxosAccepted{Accepted: false, Ballot: s.promised}
+	}
+	s.promised = req.Ballot
+	s.acceptedBallot = req.Ballot
+	s.acceptedValue = req.Value
+	s.acceptedDelete = req.Deleted
+	return PaxosAccepted{Accepted: true, Ballot: s.promised}
+}
+
+// Commit applies a chosen value to the store
+func (a *Acceptor) Commit(req PaxosProposal) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.prune(req.Key)
+	a.clock.Update(req.Ballot)
+
+	s := a.state(req.Key)
+	if req.Ballot <= s.commitBallot {
+		return nil // Already committed
+	}
+	s.commitBallot = req.Ballot
+	if s.promised < req.Ballot {
+		s.promised = req.Ballot
+	}
+	var err error
+	if req.Deleted {
+		_, err = a.store.DeleteWithVersion(req.Key, req.Ballot)
+	} else {
+		_, err = a.store.SetWithVersion(req.Key, req.Value, req.Ballot)
+	}
+	return err
+}
+
+// CASResult represents the result of a compare-and-set
+type CASResult struct {
+	Applied bool   // The value was written
+	Value   []byte // The value after the operation
+	Version int64
+	Exists  bool
+}
+
+// errCASMismatch rejects a compare-and-set whose expected value differs
+var errCASMismatch = errors.New("current value does not match")
+
+// CompareAndSet writes value if the key's current value matches expected
+// (expected == nil means the key must not exist)
+func (rm *ReplicationManager) CompareAndSet(key string, expected *string, value []byte) (*CASResult, error) {
+	return rm.ConditionalWrite(key, value, false, func(current *kvstore.KeyValue) error {
+		if (expected == nil) != (current == nil) || (current != nil && *expected != string(current.Value)) {
+			return errCASMismatch
+		}
+		return nil
+	})
+}
+
+// errConditionFailed leaves a key unchanged in paxosWrite, which returns
+// the current value instead
+var errConditionFailed = errors.New("condition failed")
+
+// ConditionalWrite writes value (or deletes the key if deleted is set) if
+// condition accepts the key's current value (nil if it does not exist)
+// If the condition fails, the current value is returned with Applied unset.
+func (rm *ReplicationManager) ConditionalWrite(key string, value []byte, deleted bool, condition kvstore.Condition) (*CASResult, error) {
+	return rm.paxosWrite(key, func(current *kvstore.KeyValue) ([]byte, bool, error) {
+		if condition != nil && condition(current) != nil {
+			return nil, false, errConditionFailed
+		}
+		return value, deleted, nil
+	})
+}
+
+// Update writes the value update computes from the key's current value
+// (nil if it does not exist); errors from update are returned as is
+func (rm *ReplicationManager) Update(key string, update kvstore.UpdateFunc) (*CASResult, error) {
+	return rm.paxosWrite(key, func(current *kvstore.KeyValue) ([]byte, bool, error) {
+		value, err := update(current)
+		return value, false, err
+	})
+}
+
+// paxosWrite writes the value (or delete) decide returns for the key's
+// current value
+// Each attempt runs one Paxos round over a majority of the key's replicas:
+// prepare and promise, a quorum read of the current value from the promises,
+// then propose and commit, so nothing is written to the key between the
+// read and the write. A proposal left unfinished by another coordinator is
+// completed first. Returns ErrNoQuorum if a majority is unreachable,
+// ErrCASContention if competing rounds kept pre-empting this one and
+// ErrCASUnknown if this node's proposal may or may not have been chosen.
+func (rm *ReplicationManager) paxosWrite(key string, decide func(current *kvstore.KeyValue) ([]byte, bool, error)) (*CASResult, error) {
+	// The result of this node's proposal once it is committed
+	var written *CASResult
+
+	replicas := rm.config.GetPreferenceList(key)
+	quorum := len(replicas)/2 + 1
+
+	// Ballot of this node's proposal that was pre-empted after some replicas
+	// accepted it (0 = none)
+	var partial int64
+
+	for attempt := 1; attempt <= casMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(rand.Int63n(int64(casBackoff) * int64(attempt))))
+		}
+		ballot := rm.clock.Now()
+
+		// Phase 1: prepare / promise
+		promises, highest := rm.prepare(replicas, PaxosPrepareRequest{Key: key, Ballot: ballot})
+		if len(promises) < quorum {
+			if highest > ballot {
+				rm.clock.Update(highest)
+				continue // Pre-empted by a higher ballot
+			}
+			return nil, fmt.Errorf("%w: %d/%d replicas promised", ErrNoQuorum, len(promises), quorum)
+		}
+
+		// Finish a proposal another coordinator accepted but did not commit
+		var commitBallot int64
+		var inProgress *PaxosPromise
+		for i, promise := range promises {
+			if promise.CommitBallot > commitBallot {
+				commitBallot = promise.CommitBallot
+			}
+			if promise.AcceptedBallot > 0 && (inProgress == nil || promise.AcceptedBallot > inProgress.AcceptedBallot) {
+				inProgress = &promises[i]
+			}
+		}
+		if inProgress == nil || inProgress.AcceptedBallot <= commitBallot {
+			inProgress = nil
+		}
+		if partial != 0 && (inProgress == nil || inProgress.AcceptedBallot != partial) {
+			// The partly accepted proposal was either committed or superseded
+			// by another coordinator; which one cannot be told apart
+			return nil, fmt.Errorf("%w: proposal pre-empted after it was partly accepted", ErrCASUnknown)
+		}
+		if inProgress != nil {
+			proposal := PaxosProposal{Key: key, Ballot: ballot, Value: inProgress.AcceptedValue, Deleted: inProgress.AcceptedDeleted}
+			if _, err := rm.proposeAndCommit(replicas, quorum, proposal); err != nil {
+				if errors.Is(err, ErrCASContention) {
+					continue
+				}
+				return nil, err
+			}
+			if partial != 0 {
+				// This node's own proposal, now committed
+				written.Version = ballot
+				return written, nil
+			}
+			continue
+		}
+
+		// The promises are a quorum read of the current value; a delete
+		// newer than every value leaves the key absent
+		var latest *PaxosPromise
+		for i, promise := range promises {
+			if promise.CRDT {
+				return nil, kvstore.ErrCRDTKey
+			}
+			if promise.Exists && (latest == nil || promise.Version > latest.Version) {
+				latest = &promises[i]
+			}
+		}
+		current := CASResult{}
+		var currentKV *kvstore.KeyValue
+		if latest != nil && !latest.Deleted {
+			current = CASResult{Value: latest.Value, Version: latest.Version, Exists: true}
+			currentKV = &kvstore.KeyValue{Key: key, Value: latest.Value, Version: latest.Version}
+		}
+		value, deleted, err := decide(currentKV)
+		if errors.Is(err, errConditionFailed) {
+			return &current, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		written = &CASResult{Applied: true, Value: value, Exists: !deleted}
+
+		// Phase 2: propose / accept, then commit
+		proposal := PaxosProposal{Key: key, Ballot: ballot, Value: value, Deleted: deleted}
+		if acceptCount, err := rm.proposeAndCommit(replicas, quorum, proposal); err != nil {
+			if errors.Is(err, ErrCASContention) {
+				// Retrying is safe only if no replica holds the proposal;
+				// otherwise the next round has to finish it
+				if acceptCount > 0 {
+					partial = ballot
+				}
+				continue
+			}
+			return nil, err
+		}
+		written.Version = ballot
+		return written, nil
+	}
+
+	if partial != 0 {
+		return nil, fmt.Errorf("%w: gave up after %d attempts", ErrCASUnknown, casMaxAttempts)
+	}
+	return nil, fmt.Errorf("%w: gave up after %d attempts", ErrCASContention, casMaxAttempts)
+}
+
+// prepare sends a prepare to every replica and collects the promises
+// Also returns the highest ballot a rejecting replica had promised.
+func (rm *ReplicationManager) prepare(replicas []string, req PaxosPrepareRequest) ([]PaxosPromise, int64) {
+	results := make(chan *PaxosPromise, len(replicas))
+	for _, addr := range replicas {
+		go func(addr string) {
+			if addr == rm.config.GetMyAddr() {
+				promise := rm.paxos.Prepare(req)
+				results <- &promise
+				return
+			}
+			promise, err := rm.client.PaxosPrepare(addr, req)
+			if err != nil {
+				results <- nil
+				return
+			}
+			results <- promise
+		}(addr)
+	}
+
+	var promises []PaxosPromise
+	var highest int64
+	for i := 0; i < len(replicas); i++ {
+		promise := <-results
+		if promise == nil {
+			continue
+		}
+		if !promise.Promised {
+			if promise.Ballot > highest {
+				highest = promise.Ballot
+			}
+			continue
+		}
+		promises = append(promises, *promise)
+	}
+	return promises, highest
+}
+
+// proposeAndCommit runs the accept phase of a proposal and commits it once
+// a quorum accepted
+// The commit is sent to every replica; it returns once a quorum applied it.
+// Also returns how many replicas accepted the proposal.
+func (rm *ReplicationManager) proposeAndCommit(replicas []string, quorum int, proposal PaxosProposal) (int, error) {
+	accepted := make(chan *PaxosAccepted, len(replicas))
+	for _, addr := range replicas {
+		go func(addr string) {
+			if addr == rm.config.GetMyAddr() {
+				response := rm.paxos.Propose(proposal)
+				accepted <- &response
+				return
+			}
+			response, err := rm.client.PaxosPropose(addr, proposal)
+			if err != nil {
+				accepted <- nil
+				return
+			}
+			accepted <- response
+		}(addr)
+	}
+
+	acceptCount, rejected := 0, false
+	for i := 0; i < len(replicas) && acceptCount < quorum; i++ {
+		response := <-accepted
+		switch {
+		case response == nil:
+		case response.Accepted:
+			acceptCount++
+		default:
+			rejected = true
+			rm.clock.Update(response.Ballot)
+		}
+	}
+	if acceptCount < quorum {
+		if rejected {
+			return acceptCount, ErrCASContention
+		}
+		return acceptCount, fmt.Errorf("%w: %d/%d replicas accepted the proposal", ErrNoQuorum, acceptCount, quorum)
+	}
+
+	// Commit everywhere; replicas that miss it are repaired by later rounds
+	// and anti-entropy
+	committed := make(chan bool, len(replicas))
+	for _, addr := range replicas {
+		go func(addr string) {
+			if addr == rm.config.GetMyAddr() {
+				committed <- rm.paxos.Commit(proposal) == nil
+				return
+			}
+			committed <- rm.client.PaxosCommit(addr, proposal) == nil
+		}(addr)
+	}
+
+	commitCount := 0
+	for i := 0; i < len(replicas) && commitCount < quorum; i++ {
+		if <-committed {
+			commitCount++
+		}
+	}
+	if commitCount < quorum {
+		return acceptCount, fmt.Errorf("%w: %d/%d replicas committed the proposal", ErrNoQuorum, commitCount, quorum)
+	}
+	return acceptCount, nil
+}
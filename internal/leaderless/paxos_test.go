@@ -0,0 +1,179 @@
+package leaderless
+
+import (
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/hlc"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestAcceptorPrunesCommittedKeys(t *testing.T) {
+	acceptor := NewAcceptor(kvstore.NewStore(), hlc.NewClock(0))
+	proposal := PaxosProposal{Key: "k", Ballot: 10, Value: []byte("v")}
+
+	acceptor.Prepare(PaxosPrepareRequest{Key: "k", Ballot: 10})
+	acceptor.Propose(proposal)
+	if len(acceptor.keys) != 1 {
+		t.Fatalf("got %d keys with state during a round, want 1", len(acceptor.keys))
+	}
+	if err := acceptor.Commit(proposal); err != nil {
+		t.Fatal(err)
+	}
+	if len(acceptor.keys) != 0 {
+		t.Fatalf("got %d keys with state after the commit, want 0", len(acceptor.keys))
+	}
+
+	// The stored version stands for the dropped state
+	tests := []struct {
+		name         string
+		ballot       int64
+		wantPromised bool
+	}{
+		{name: "older ballot", ballot: 9},
+		{name: "commit ballot", ballot: 10},
+		{name: "newer ballot", ballot: 11, wantPromised: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promise := acceptor.Prepare(PaxosPrepareRequest{Key: "k", Ballot: tt.ballot})
+			if promise.Promised != tt.wantPromised {
+				t.Fatalf("got promised %v, want %v", promise.Promised, tt.wantPromised)
+			}
+			if promise.CommitBallot != 10 || promise.AcceptedBallot != 0 {
+				t.Fatalf("got commit ballot %d and accepted ballot %d, want 10 and 0", promise.CommitBallot, promise.AcceptedBallot)
+			}
+			if accepted := acceptor.Propose(PaxosProposal{Key: "k", Ballot: 9}); accepted.Accepted {
+				t.Fatal("a ballot older than the commit was accepted")
+			}
+		})
+	}
+	if len(acceptor.keys) != 1 {
+		t.Fatalf("got %d keys with state, want 1 (promised ballot 11)", len(acceptor.keys))
+	}
+}
+
+// paxosStep is a message delivered to an acceptor, and the answer expected
+type paxosStep struct {
+	prepare      int64 // Ballot of a prepare, or 0
+	propose      int64 // Ballot of a proposal, or 0
+	commit       int64 // Ballot of a commit, or 0
+	value        string
+	wantOK       bool   // Promised or accepted
+	wantBallot   int64  // Highest ballot promised
+	wantAccepted int64  // In-progress ballot reported by a promise
+	wantValue    string // In-progress value reported by a promise
+}
+
+func TestAcceptorPreemption(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []paxosStep
+	}{
+		{
+			name: "higher prepare pre-empts a proposal",
+			steps: []paxosStep{
+				{prepare: 10, wantOK: true, wantBallot: 10},
+				{prepare: 20, wantOK: true, wantBallot: 20},
+				{propose: 10, value: "a", wantBallot: 20},
+			},
+		},
+		{
+			name: "lower prepare is rejected",
+			steps: []paxosStep{
+				{prepare: 20, wantOK: true, wantBallot: 20},
+				{prepare: 10, wantBallot: 20},
+				{prepare: 20, wantBallot: 20},
+			},
+		},
+		{
+			name: "promise reports an uncommitted proposal",
+			steps: []paxosStep{
+				{prepare: 10, wantOK: true, wantBallot: 10},
+				{propose: 10, value: "a", wantOK: true, wantBallot: 10},
+				{prepare: 20, wantOK: true, wantBallot: 20, wantAccepted: 10, wantValue: "a"},
+			},
+		},
+		{
+			name: "promise reports the latest uncommitted proposal",
+			steps: []paxosStep{
+				{propose: 10, value: "a", wantOK: true, wantBallot: 10},
+				{propose: 20, value: "b", wantOK: true, wantBallot: 20},
+				{prepare: 30, wantOK: true, wantBallot: 30, wantAccepted: 20, wantValue: "b"},
+			},
+		},
+		{
+			name: "promise leaves out a committed proposal",
+			steps: []paxosStep{
+				{propose: 10, value: "a", wantOK: true, wantBallot: 10},
+				{commit: 10, value: "a"},
+				{prepare: 20, wantOK: true, wantBallot: 20},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acceptor := NewAcceptor(kvstore.NewStore(), hlc.NewClock(0))
+			for i, step := range tt.steps {
+				switch {
+				case step.prepare != 0:
+					promise := acceptor.Prepare(PaxosPrepareRequest{Key: "k", Ballot: step.prepare})
+					if promise.Promised != step.wantOK || promise.Ballot != step.wantBallot ||
+						promise.AcceptedBallot != step.wantAccepted || string(promise.AcceptedValue) != step.wantValue {
+						t.Fatalf("step %d: got %+v", i, promise)
+					}
+				case step.propose != 0:
+					accepted := acceptor.Propose(PaxosProposal{Key: "k", Ballot: step.propose, Value: []byte(step.value)})
+					if accepted.Accepted != step.wantOK || accepted.Ballot != step.wantBallot {
+						t.Fatalf("step %d: got %+v", i, accepted)
+					}
+				default:
+					if err := acceptor.Commit(PaxosProposal{Key: "k", Ballot: step.commit, Value: []byte(step.value)}); err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCompareAndSetFinishesAcceptedProposal(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    *string
+		wantApplied bool
+		wantValue   string
+	}{
+		{name: "expects no value", expected: nil, wantValue: "pending"},
+		{name: "expects the accepted value", expected: stringPtr("pending"), wantApplied: true, wantValue: "new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := "localhost:1"
+			rm := NewReplicationManager(kvstore.NewStore(), NewConfig("n1", addr, []string{addr}))
+
+			// Another coordinator got a value accepted, then failed before
+			// committing it
+			ballot := hlc.NewClock(1).Now()
+			rm.paxos.Prepare(PaxosPrepareRequest{Key: "k", Ballot: ballot})
+			rm.paxos.Propose(PaxosProposal{Key: "k", Ballot: ballot, Value: []byte("pending")})
+
+			result, err := rm.CompareAndSet("k", tt.expected, []byte("new"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Applied != tt.wantApplied || string(result.Value) != tt.wantValue {
+				t.Fatalf("got applied %v value %q, want %v %q", result.Applied, result.Value, tt.wantApplied, tt.wantValue)
+			}
+			kv, _ := rm.store.Get("k")
+			if kv == nil || string(kv.Value) != tt.wantValue {
+				t.Fatalf("got stored %v, want %q", kv, tt.wantValue)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
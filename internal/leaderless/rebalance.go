@@ -0,0 +1,463 @@
+package leaderless
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/antientropy"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Default rebalancing settings
+const (
+	DefaultRebalanceRate       = 1000 // Keys per second streamed to new owners
+	rebalanceBatchSize         = 100
+	rebalancePollInterval      = 1 * time.Second
+	rebalanceMaxAttempts       = 30 // Consecutive failures before a change is marked failed
+	rebalanceStreamMaxAttempts = 5
+)
+
+// Rebalancing states
+const (
+	RebalanceIdle      = "idle"
+	RebalanceStreaming = "streaming"
+	RebalanceDone      = "done"
+	RebalanceFailed    = "failed"
+)
+
+// Membership change phases (on the node coordinating the change)
+const (
+	PhaseBegin     = "begin"     // Every node learns the pending ring and starts double-writing
+	PhaseStreaming = "streaming" // Every node streams moved keys to their new owners
+	PhaseCommit    = "commit"    // Every node switches to the new ring
+	PhaseDone      = "done"
+	PhaseFailed    = "failed"
+)
+
+// ErrRebalanceInProgress is returned when a membership change is requested
+// while another one is still moving data
+var ErrRebalanceInProgress = errors.New("a membership change is already in progress")
+
+// Membership is the versioned list of nodes on the ring
+// While Pending is set the cluster is moving data from the ring of Members
+// to the ring of Pending.
+type Membership struct {
+	Version int64    `json:"version"`
+	Members []string `json:"members"`
+	Pending []string `json:"pending,omitempty"`
+}
+
+// RebalanceStatus reports the progress of streaming on one node
+type RebalanceStatus struct {
+	Version     int64      `json:"version"` // Membership version being moved to
+	State       string     `json:"state"`
+	KeysTotal   int        `json:"keys_total"`   // Local keys when streaming started
+	KeysScanned int        `json:"keys_scanned"` // Local keys checked so far
+	KeysMoved   int64      `json:"keys_moved"`   // Entries streamed to new owners
+	RateLimit   int        `json:"rate_limit"`   // Keys per second (0 = unlimited)
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// MembershipChange reports a membership change driven by this node
+type MembershipChange struct {
+	From       []string                   `json:"from"`
+	To         []string                   `json:"to"`
+	Version    int64                      `json:"version"` // Version of the pending membership
+	Phase      string                     `json:"phase"`
+	Nodes      map[string]RebalanceStatus `json:"nodes,omitempty"` // Streaming progress per node
+	StartedAt  time.Time                  `json:"started_at"`
+	FinishedAt *time.Time                 `json:"finished_at,omitempty"`
+	Error      string                     `json:"error,omitempty"`
+}
+
+// Rebalancer moves data when the ring membership changes
+// Any node can coordinate a change. It runs in three phases:
+//  1. begin: every node learns the pending ring. From then on writes go to
+//     the key's replicas on both rings (double-writing), while reads and
+//     forwarding still use the current ring, whose replicas hold all data.
+//  2. streaming: every node streams the keys it replicates on the current
+//     ring to their new replicas on the pending ring, throttled to the
+//     rebalance rate.
+//  3. commit: once every node has finished streaming, every node switches
+//     to the new ring.
+//
+// Every current replica streams its copy, so one unreachable old owner does
+// not lose data. Moved keys are not deleted from their old owners.
+type Rebalancer struct {
+	store  *kvstore.Store
+	config *Config
+	client *ReplicationClient
+	apply  func(antientropy.Entry) bool
+
+	mu     sync.Mutex
+	status RebalanceStatus
+	change *MembershipChange // Last change coordinated by this node
+}
+
+// NewRebalancer creates a rebalancer
+// apply stores an entry streamed from another node
+func NewRebalancer(store *kvstore.Store, config *Config, apply func(antientropy.Entry) bool) *Rebalancer {
+	return &Rebalancer{
+		store:  store,
+		config: config,
+		client: NewReplicationClient(config.GetFaults(), config.GetDetector(), config.GetGRPC()),
+		apply:  apply,
+		status: RebalanceStatus{State: RebalanceIdle},
+	}
+}
+
+// ChangeMembership starts moving the cluster to a new member list and
+// returns immediately; the change is driven in the background
+// Repeating the pending member list resumes a change that failed or whose
+// coordinator stopped.
+func (rb *Rebalancer) ChangeMembership(nodes []string) (*MembershipChange, error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.change != nil && rb.change.Phase != PhaseDone && rb.change.Phase != PhaseFailed {
+		return nil, ErrRebalanceInProgress
+	}
+
+	current := rb.config.GetMembership()
+	var begin Membership
+	switch {
+	case len(current.Pending) == 0:
+		if sameMembers(current.Members, nodes) {
+			return nil, fmt.Errorf("membership is unchanged")
+		}
+		// Old and new members write side by side until the change ends
+		if err := CheckClockIDs(append(append([]string{}, current.Members...), nodes...)); err != nil {
+			return nil, err
+		}
+		begin = Membership{
+			Version: current.Version + 1,
+			Members: current.Members,
+			Pending: append([]string{}, nodes...),
+		}
+	case sameMembers(current.Pending, nodes):
+		// Resume the pending change
+		begin = current
+	default:
+		return nil, ErrRebalanceInProgress
+	}
+
+	rb.change = &MembershipChange{
+		From:      begin.Members,
+		To:        begin.Pending,
+		Version:   begin.Version,
+		Phase:     PhaseBegin,
+		Nodes:     make(map[string]RebalanceStatus),
+		StartedAt: time.Now().UTC(),
+	}
+	change := *rb.change
+
+	go rb.drive(begin)
+	return &change, nil
+}
+
+// drive runs a membership change through its phases
+func (rb *Rebalancer) drive(begin Membership) {
+	log.Printf("Membership change to %v (version %d): begin", begin.Pending, begin.Version)
+
+	// Begin: every node (old and new) learns the pending ring
+	rb.config.ApplyMembership(begin, false)
+	addrs := rb.config.GetOtherNodeAddrs()
+	if err := rb.pushMembership(addrs, begin); err != nil {
+		rb.fail(err)
+		return
+	}
+
+	// Streaming: every node moves its keys and reports when done
+	rb.setPhase(PhaseStreaming)
+	if err := rb.waitForStreaming(addrs, begin.Version); err != nil {
+		rb.fail(err)
+		return
+	}
+
+	// Commit: every node switches to the new ring
+	rb.setPhase(PhaseCommit)
+	commit := Membership{Version: begin.Version + 1, Members: begin.Pending}
+	if err := rb.pushMembership(addrs, commit); err != nil {
+		rb.fail(err)
+		return
+	}
+	rb.config.ApplyMembership(commit, false)
+
+	rb.mu.Lock()
+	now := time.Now().UTC()
+	rb.change.Phase = PhaseDone
+	rb.change.FinishedAt = &now
+	rb.mu.Unlock()
+	log.Printf("Membership change to %v (version %d): committed", commit.Members, commit.Version)
+}
+
+// pushMembership sends a membership to every node, retrying each node until
+// it acknowledges or attempts run out
+func (rb *Rebalancer) pushMembership(addrs []string, m Membership) error {
+	errs := make(chan error, len(addrs))
+	for _, addr := range addrs {
+		go func(addr string) {
+			var err error
+			for attempt := 0; attempt < rebalanceMaxAttempts; attempt++ {
+				if err = rb.client.ReplicateMembership(addr, m); err == nil {
+					errs <- nil
+					return
+				}
+				time.Sleep(rebalancePollInterval)
+			}
+			errs <- fmt.Errorf("%s did not acknowledge membership version %d: %w", addr, m.Version, err)
+		}(addr)
+	}
+
+	var firstErr error
+	for range addrs {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// waitForStreaming starts streaming on every node (including this one) and
+// polls until all of them are done, restarting nodes whose streaming failed
+func (rb *Rebalancer) waitForStreaming(addrs []string, version int64) error {
+	rb.Start(version)
+	for _, addr := range addrs {
+		rb.client.StartRebalance(addr, version)
+	}
+
+	failures := make(map[string]int)
+	for {
+		done := true
+		nodes := map[string]RebalanceStatus{rb.config.GetMyAddr(): rb.GetStatus()}
+		for _, addr := range addrs {
+			status, err := rb.client.FetchRebalanceStatus(addr)
+			if err != nil {
+				failures[addr]++
+				if failures[addr] >= rebalanceMaxAttempts {
+					return fmt.Errorf("%s is unreachable: %w", addr, err)
+				}
+				done = false
+				continue
+			}
+			failures[addr] = 0
+			nodes[addr] = *status
+		}
+
+		for addr, status := range nodes {
+			if status.Version == version && status.State == RebalanceDone {
+				continue
+			}
+			done = false
+			if status.Version != version || status.State == RebalanceFailed || status.State == RebalanceIdle {
+				// Not started (e.g. the node restarted) or failed: start again
+				if addr == rb.config.GetMyAddr() {
+					rb.Start(version)
+				} else {
+					rb.client.StartRebalance(addr, version)
+				}
+			}
+		}
+
+		rb.mu.Lock()
+		rb.change.Nodes = nodes
+		rb.mu.Unlock()
+
+		if done {
+			return nil
+		}
+		time.Sleep(rebalancePollInterval)
+	}
+}
+
+// setPhase records the phase of the change driven by this node
+func (rb *Rebalancer) setPhase(phase string) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.change.Phase = phase
+	log.Printf("Membership change to %v (version %d): %s", rb.change.To, rb.change.Version, phase)
+}
+
+// fail marks the change driven by this node as failed
+func (rb *Rebalancer) fail(err error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	now := time.Now().UTC()
+	rb.change.Phase = PhaseFailed
+	rb.change.Error = err.Error()
+	rb.change.FinishedAt = &now
+	log.Printf("Membership change to %v (version %d) failed: %v", rb.change.To, rb.change.Version, err)
+}
+
+// Start streams this node's moved keys for a pending membership version in
+// the background
+// It does nothing if streaming for that version is running or finished, or
+// if the version is not the node's pending membership.
+func (rb *Rebalancer) Start(version int64) bool {
+	current, pending := rb.config.GetRings()
+	if pending == nil || rb.config.GetMembership().Version != version {
+		return false
+	}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.status.Version == version && (rb.status.State == RebalanceStreaming || rb.status.State == RebalanceDone) {
+		return true
+	}
+	now := time.Now().UTC()
+	rb.status = RebalanceStatus{
+		Version:   version,
+		State:     RebalanceStreaming,
+		RateLimit: rb.config.GetRebalanceRate(),
+		StartedAt: &now,
+	}
+	go rb.stream(version, current, pending)
+	return true
+}
+
+// stream sends every local key whose pending replicas include nodes that
+// are not current replicas to those nodes, in throttled batches
+func (rb *Rebalancer) stream(version int64, current *Ring, pending *Ring) {
+	myAddr := rb.config.GetMyAddr()
+	keys := rb.store.Keys()
+
+	rb.mu.Lock()
+	rb.status.KeysTotal = len(keys)
+	rb.mu.Unlock()
+
+	batches := make(map[string][]antientropy.Entry)
+	var streamErr error
+	for i, key := range keys {
+		rf := rb.config.GetKeyReplicationFactor(key)
+		oldOwners := current.PreferenceList(key, rf)
+		if contains(oldOwners, myAddr) {
+			for _, addr := range pending.PreferenceList(key, rf) {
+				if contains(oldOwners, addr) {
+					continue
+				}
+				kv, exists := rb.store.Get(key)
+				if !exists {
+					continue
+				}
+				batches[addr] = append(batches[addr], antientropy.Entry{
+					Key:      kv.Key,
+					Value:    kv.Value,
+					Version:  kv.Version,
+					Siblings: kv.Siblings,
+					CRDT:     kv.CRDT,
+					Deleted:  kv.Deleted,
+				})
+				if len(batches[addr]) >= rebalanceBatchSize {
+					if streamErr = rb.send(addr, batches[addr]); streamErr != nil {
+						break
+					}
+					batches[addr] = nil
+				}
+			}
+		}
+		if streamErr != nil {
+			break
+		}
+
+		rb.mu.Lock()
+		rb.status.KeysScanned = i + 1
+		rb.mu.Unlock()
+	}
+
+	for addr, batch := range batches {
+		if streamErr != nil {
+			break
+		}
+		if len(batch) > 0 {
+			streamErr = rb.send(addr, batch)
+		}
+	}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.status.Version != version {
+		return
+	}
+	now := time.Now().UTC()
+	rb.status.FinishedAt = &now
+	if streamErr != nil {
+		rb.status.State = RebalanceFailed
+		rb.status.Error = streamErr.Error()
+		log.Printf("Rebalance streaming (version %d) failed: %v", version, streamErr)
+		return
+	}
+	rb.status.State = RebalanceDone
+	log.Printf("Rebalance streaming (version %d) done: %d keys moved", version, rb.status.KeysMoved)
+}
+
+// send streams a batch of entries to a new owner, then waits long enough to
+// keep the rate under the rebalance rate
+func (rb *Rebalancer) send(addr string, entries []antientropy.Entry) error {
+	var err error
+	for attempt := 0; attempt < rebalanceStreamMaxAttempts; attempt++ {
+		if err = rb.client.StreamEntries(addr, entries); err == nil {
+			break
+		}
+		time.Sleep(rebalancePollInterval)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stream to %s: %w", addr, err)
+	}
+
+	rate := rb.config.GetRebalanceRate()
+	rb.mu.Lock()
+	rb.status.KeysMoved += int64(len(entries))
+	rb.status.RateLimit = rate
+	rb.mu.Unlock()
+
+	if rate > 0 {
+		time.Sleep(time.Duration(len(entries)) * time.Second / time.Duration(rate))
+	}
+	return nil
+}
+
+// GetStatus returns the streaming progress of this node
+func (rb *Rebalancer) GetStatus() RebalanceStatus {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.status
+}
+
+// GetChange returns the last membership change coordinated by this node
+func (rb *Rebalancer) GetChange() *MembershipChange {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.change == nil {
+		return nil
+	}
+	change := *rb.change
+	return &change
+}
+
+// sameMembers returns true if two member lists hold the same addresses
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, addr := range b {
+		if !contains(a, addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains returns true if addr is in addrs
+func contains(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
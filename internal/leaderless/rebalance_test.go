@@ -0,0 +1,195 @@
+package leaderless
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/antientropy"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestWriteReplicasDuringRebalance(t *testing.T) {
+	members := []string{"a:1", "b:1", "c:1"}
+	pending := []string{"a:1", "b:1", "c:1", "d:1"}
+	config := NewConfig("a", "a:1", members)
+	config.RF = 2
+
+	// Reads and writes use the current ring until the change begins
+	checkWriteReplicas(t, config, NewRing(members, DefaultVirtualNodes), nil)
+
+	// Between begin and commit, writes also go to the pending replicas
+	// while reads stay on the current ring
+	config.ApplyMembership(Membership{Version: 1, Members: members, Pending: pending}, false)
+	checkWriteReplicas(t, config, NewRing(members, DefaultVirtualNodes), NewRing(pending, DefaultVirtualNodes))
+
+	// After commit, only the new ring is used
+	config.ApplyMembership(Membership{Version: 2, Members: pending}, false)
+	checkWriteReplicas(t, config, NewRing(pending, DefaultVirtualNodes), nil)
+}
+
+// checkWriteReplicas checks the read and write replicas of many keys
+// against the current ring and the pending ring (nil when not rebalancing)
+func checkWriteReplicas(t *testing.T, config *Config, current, pending *Ring) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		key := "key:" + strconv.Itoa(i)
+		readers := current.PreferenceList(key, config.RF)
+		if got := config.GetPreferenceList(key); !isPrefix(got, readers) || len(got) != len(readers) {
+			t.Fatalf("%s: read replicas %v, want %v", key, got, readers)
+		}
+
+		want := without(readers, config.MyAddr)
+		wantPending := 0
+		if pending != nil {
+			for _, addr := range pending.PreferenceList(key, config.RF) {
+				if !contains(readers, addr) {
+					wantPending++
+					if addr != config.MyAddr {
+						want = append(want, addr)
+					}
+				}
+			}
+		}
+		others, pendingCount := config.GetWriteReplicas(key)
+		if !sameMembers(others, want) || pendingCount != wantPending {
+			t.Fatalf("%s: write replicas %v (%d pending), want %v (%d pending)", key, others, pendingCount, want, wantPending)
+		}
+	}
+}
+
+func TestChangeMembership(t *testing.T) {
+	// Unreachable nodes: the background change never gets past begin
+	members := []string{"127.0.0.1:1", "127.0.0.1:2"}
+	grown := []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3"}
+	clash := []string{"127.0.0.1:1", collidingAddr(t, "127.0.0.1:2")}
+
+	tests := []struct {
+		name        string
+		pending     []string // Pending membership left by an earlier change
+		phase       string   // Phase of the last change coordinated here ("" = none)
+		nodes       []string
+		wantVersion int64
+		wantErr     bool
+		wantBusy    bool
+	}{
+		{name: "new member list", nodes: grown, wantVersion: 2},
+		{name: "unchanged member list", nodes: members, wantErr: true},
+		{name: "new member with the clock id of an old one", nodes: clash, wantErr: true},
+		{name: "resume a failed change", pending: grown, phase: PhaseFailed, nodes: grown, wantVersion: 1},
+		{name: "resume in another order", pending: grown, phase: PhaseFailed, nodes: []string{"127.0.0.1:3", "127.0.0.1:1", "127.0.0.1:2"}, wantVersion: 1},
+		{name: "resume a change whose coordinator stopped", pending: grown, nodes: grown, wantVersion: 1},
+		{name: "other list while a change is pending", pending: grown, phase: PhaseFailed, nodes: members[:1], wantBusy: true},
+		{name: "change still running", phase: PhaseStreaming, nodes: grown, wantBusy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig("n1", members[0], members)
+			config.ApplyMembership(Membership{Version: 1, Members: members, Pending: tt.pending}, false)
+			rb := NewRebalancer(kvstore.NewStore(), config, nil)
+			if tt.phase != "" {
+				rb.change = &MembershipChange{Phase: tt.phase}
+			}
+
+			change, err := rb.ChangeMembership(tt.nodes)
+			switch {
+			case tt.wantBusy:
+				if !errors.Is(err, ErrRebalanceInProgress) {
+					t.Fatalf("got %v, want %v", err, ErrRebalanceInProgress)
+				}
+				return
+			case tt.wantErr:
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+			if change.Version != tt.wantVersion || !sameMembers(change.From, members) || !sameMembers(change.To, tt.nodes) {
+				t.Fatalf("got change %+v, want version %d from %v to %v", change, tt.wantVersion, members, tt.nodes)
+			}
+			if _, err := rb.ChangeMembership(tt.nodes); !errors.Is(err, ErrRebalanceInProgress) {
+				t.Fatalf("second change: got %v, want %v", err, ErrRebalanceInProgress)
+			}
+		})
+	}
+}
+
+func TestStreamSendsOnlyMovedKeys(t *testing.T) {
+	// Two new nodes record the keys streamed to them
+	var mu sync.Mutex
+	received := make(map[string]map[string]bool)
+	var joining []string
+	for i := 0; i < 2; i++ {
+		var addr string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var msg antientropy.EntriesMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			mu.Lock()
+			for _, entry := range msg.Entries {
+				received[addr][entry.Key] = true
+			}
+			mu.Unlock()
+		}))
+		defer server.Close()
+		addr = strings.TrimPrefix(server.URL, "http://")
+		received[addr] = make(map[string]bool)
+		joining = append(joining, addr)
+	}
+
+	// The old nodes other than this one are unreachable: nothing may be
+	// sent to them
+	members := []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3"}
+	pending := append(append([]string{}, members...), joining...)
+	config := NewConfig("n1", members[0], members)
+	config.RF = 2
+	config.SetRebalanceRate(0)
+	config.ApplyMembership(Membership{Version: 1, Members: members, Pending: pending}, false)
+
+	store := kvstore.NewStore()
+	const keys = 500
+	for i := 0; i < keys; i++ {
+		store.Set("key:"+strconv.Itoa(i), []byte("v"))
+	}
+
+	rb := NewRebalancer(store, config, nil)
+	if !rb.Start(1) {
+		t.Fatal("streaming did not start")
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for rb.GetStatus().State == RebalanceStreaming && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := rb.GetStatus()
+	if status.State != RebalanceDone {
+		t.Fatalf("got state %s (%s), want %s", status.State, status.Error, RebalanceDone)
+	}
+
+	current, next := config.GetRings()
+	var moved int64
+	for i := 0; i < keys; i++ {
+		key := "key:" + strconv.Itoa(i)
+		owners := current.PreferenceList(key, config.RF)
+		newOwners := next.PreferenceList(key, config.RF)
+		for _, addr := range joining {
+			want := contains(owners, config.MyAddr) && contains(newOwners, addr)
+			if received[addr][key] != want {
+				t.Fatalf("%s sent to %s: %v, want %v (owners %v, new owners %v)", key, addr, received[addr][key], want, owners, newOwners)
+			}
+			if want {
+				moved++
+			}
+		}
+	}
+	if moved == 0 || status.KeysMoved != moved || status.KeysScanned != keys {
+		t.Fatalf("got %d moved and %d scanned, want %d moved and %d scanned", status.KeysMoved, status.KeysScanned, moved, keys)
+	}
+}
@@ -1,10 +1,17 @@
 package leaderless
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"log"
+	"net/http"
+	"time"
 
+	"github.com/yourusername/distributed-kv-store/internal/hedge"
+	"github.com/yourusername/distributed-kv-store/internal/hlc"
 	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
 )
 
 // ReplicationManager handles replication for leaderless database
@@ -12,84 +19,921 @@ type ReplicationManager struct {
 	store  *kvstore.Store
 	config *Config
 	client *ReplicationClient
-	mu     sync.Mutex
+	clock  *hlc.Clock    // Issues write versions that order writes across coordinators
+	hints  *HintStore    // Writes held for unreachable nodes
+	hedger *hedge.Hedger // Tracks replica read latencies and hedges slow reads
+	paxos  *Acceptor     // Paxos state for compare-and-set
+	actor  string        // Identifies this node's CRDT updates and causal writes (new on every start)
 }
 
 // NewReplicationManager creates a new replication manager
 func NewReplicationManager(store *kvstore.Store, config *Config) *ReplicationManager {
-	return &ReplicationManager{
+	_, hintFile, hintTTL, maxHints := config.GetHintedHandoff()
+	hints, err := NewHintStore(hintFile, hintTTL, maxHints)
+	if err != nil {
+		log.Printf("Warning: keeping hints in memory only: %v", err)
+		hints, _ = NewHintStore("", hintTTL, maxHints)
+	}
+	_, hedgeDelay, hedgePercentile := config.GetHedging()
+
+	clock := hlc.NewClock(ClockID(config.GetMyAddr()))
+	rm := &ReplicationManager{
 		store:  store,
 		config: config,
-		client: NewReplicationClient(),
+		client: NewReplicationClient(config.GetFaults(), config.GetDetector(), config.GetGRPC()),
+		clock:  clock,
+		hints:  hints,
+		hedger: hedge.NewHedger(hedgeDelay, hedgePercentile),
+		paxos:  NewAcceptor(store, clock),
+		// A restarted node has lost its CRDT and sibling state, so it must
+		// not reuse the counter slots or dots of its previous run
+		actor: fmt.Sprintf("%s.%d", config.NodeID, time.Now().UnixNano()),
 	}
+
+	// Hand off hints held for other nodes once they are reachable again
+	go rm.replayHints()
+
+	return rm
 }
 
 // WriteResult represents the result of a write operation
 type WriteResult struct {
 	Version int64
 	Success bool
+	Hinted  int // Nodes whose copy is held as a hint on a substitute
 	Error   error
 }
 
-// WriteWithCoordination implements W=N strategy
-// When a node receives a write, it becomes the Write Coordinator
-// and must write to all other nodes (W=N)
-func (rm *ReplicationManager) WriteWithCoordination(key, value string) (*WriteResult, error) {
+// Errors returned by quorum operations
+var (
+	ErrKeyNotFound = errors.New("key not found")
+	ErrNoQuorum    = errors.New("quorum not reached")
+	// ErrConfigSuperseded is returned for a configuration change that lost
+	// to a newer one accepted concurrently by another node
+	ErrConfigSuperseded = errors.New("a newer configuration change was applied first")
+)
+
+// ApplyWrite applies a write replicated from another coordinator
+// Returns false if a newer version of the key is already stored
+// (last-writer-wins)
+func (rm *ReplicationManager) ApplyWrite(key string, value []byte, version int64) (bool, error) {
+	rm.clock.Update(version)
+	return rm.store.SetWithVersion(key, value, version)
+}
+
+// ApplyDelete applies a delete replicated from another coordinator
+// Returns false if a newer version of the key is already stored
+func (rm *ReplicationManager) ApplyDelete(key string, version int64) (bool, error) {
+	rm.clock.Update(version)
+	return rm.store.DeleteWithVersion(key, version)
+}
+
+// ApplySibling applies a causally versioned write replicated from another
+// coordinator
+// Returns false if the write is already known or superseded
+func (rm *ReplicationManager) ApplySibling(key string, sibling kvstore.Sibling) (bool, error) {
+	return rm.store.MergeSibling(key, sibling)
+}
+
+// ApplyCRDT merges a CRDT state replicated from another coordinator
+// Returns false if the local state already included it
+func (rm *ReplicationManager) ApplyCRDT(key string, state *kvstore.CRDT) (bool, error) {
+	return rm.store.MergeCRDT(key, state)
+}
+
+// UpdateCRDT applies an operation to a CRDT key and replicates the
+// resulting state to a quorum of W replicas
+// typ is the type the key is created with if it does not exist yet.
+// Returns the new state and the number of replicas whose copy is held as a
+// hint
+func (rm *ReplicationManager) UpdateCRDT(key string, typ string, op kvstore.Operation) (*kvstore.CRDT, int, error) {
+	state, err := rm.store.UpdateCRDT(key, typ, func(c *kvstore.CRDT) error {
+		return c.Apply(op, rm.actor, rm.clock.Now())
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Replicas merge the whole state, so retries and reordering are harmless
+	hinted, err := rm.replicateToQuorum(ReplicateWriteRequest{Key: key, CRDT: state})
+	if err != nil {
+		return nil, 0, err
+	}
+	return state, hinted, nil
+}
+
+// WriteWithCoordination writes a value with a quorum of W nodes
+// When a node receives a write, it becomes the Write Coordinator: it sets
+// the value locally and replicates it to every other node, returning as soon
+// as W nodes (including itself) have acknowledged. Replication to the
+// remaining nodes continues in the background.
+// The coordinator must be one of the key's replicas (see Config.IsReplica);
+// the value goes to the other nodes in the key's preference list.
+func (rm *ReplicationManager) WriteWithCoordination(key string, value []byte) (*WriteResult, error) {
+	// The version is a hybrid logical clock timestamp, so concurrent writes
+	// from different coordinators are ordered the same way on every node
+	version := rm.clock.Now()
+
 	// Coordinator sets the value locally first
-	version, err := rm.store.Set(key, value)
+	if _, err := rm.store.SetWithVersion(key, value, version); err != nil {
+		return nil, err
+	}
+
+	hinted, err := rm.replicateToQuorum(ReplicateWriteRequest{
+		Key:     key,
+		Value:   value,
+		Version: version,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Get addresses of all other nodes
-	otherNodeAddrs := rm.config.GetOtherNodeAddrs()
-	
+	return &WriteResult{Version: version, Success: true, Hinted: hinted}, nil
+}
+
+// DeleteWithCoordination deletes a key with a quorum of W nodes
+// The delete is a last-writer-wins write of a tombstone, replicated like
+// WriteWithCoordination.
+func (rm *ReplicationManager) DeleteWithCoordination(key string) (*WriteResult, error) {
+	version := rm.clock.Now()
+
+	if _, err := rm.store.DeleteWithVersion(key, version); err != nil {
+		return nil, err
+	}
+
+	hinted, err := rm.replicateToQuorum(ReplicateWriteRequest{
+		Key:     key,
+		Version: version,
+		Deleted: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriteResult{Version: version, Success: true, Hinted: hinted}, nil
+}
+
+// WriteKey writes (or deletes) a key for the resource API and returns the
+// version written
+// Unconditional writes go to a quorum; with preconditions the check and the
+// write are one Paxos round, and a failed check returns
+// rest.ErrPreconditionFailed.
+func (rm *ReplicationManager) WriteKey(key string, value []byte, deleted bool, preconditions rest.Preconditions) (int64, error) {
+	if !preconditions.Empty() {
+		result, err := rm.ConditionalWrite(key, value, deleted, preconditions.Condition())
+		if err != nil {
+			return 0, err
+		}
+		if !result.Applied {
+			return 0, rest.ErrPreconditionFailed
+		}
+		return result.Version, nil
+	}
+
+	var result *WriteResult
+	var err error
+	if deleted {
+		result, err = rm.DeleteWithCoordination(key)
+	} else {
+		result, err = rm.WriteWithCoordination(key, value)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return result.Version, nil
+}
+
+// CausalWriteResult represents the result of a causally versioned write
+type CausalWriteResult struct {
+	Sibling  kvstore.Sibling   // The value written
+	Siblings []kvstore.Sibling // The coordinator's sibling set after the write
+	Hinted   int               // Nodes whose copy is held as a hint on a substitute
+}
+
+// WriteCausal writes a value under causal versioning with a quorum of W nodes
+// context is the causal context the client read; every sibling it covers is
+// replaced by the new value, all others are kept as concurrent siblings.
+func (rm *ReplicationManager) WriteCausal(key string, value []byte, context kvstore.VectorClock) (*CausalWriteResult, error) {
+	// Coordinator sets the value locally first
+	sibling, siblings, err := rm.store.SetCausal(key, value, context, rm.actor)
+	if err != nil {
+		return nil, err
+	}
+
+	dot := sibling.Dot
+	hinted, err := rm.replicateToQuorum(ReplicateWriteRequest{
+		Key:     key,
+		Value:   sibling.Value,
+		Dot:     &dot,
+		Context: sibling.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CausalWriteResult{Sibling: sibling, Siblings: siblings, Hinted: hinted}, nil
+}
+
+// replicateToQuorum sends a write (already applied locally) to every other
+// node and waits until W nodes, including this one, have acknowledged it
+// With a sloppy quorum, a node that cannot be reached also counts once a
+// healthy substitute has stored a hint for it.
+// Returns the number of nodes whose write was hinted so far
+func (rm *ReplicationManager) replicateToQuorum(req ReplicateWriteRequest) (int, error) {
+	_, writeW := rm.config.GetReplicationParamsFor(req.Key)
+	sloppyQuorum := rm.config.UsesSloppyQuorum()
+
+	// Get addresses of the key's other replicas; while rebalancing, the
+	// replicas on the pending ring must acknowledge the write as well
+	otherNodeAddrs, pending := rm.config.GetWriteReplicas(req.Key)
+	writeW += pending
+
 	if len(otherNodeAddrs) == 0 {
-		// Only one node, no replication needed
-		return &WriteResult{Version: version, Success: true}, nil
+		// Only one replica, no replication needed
+		return 0, nil
 	}
 
-	// Replicate to all other nodes
-	results := make(chan *ReplicateWriteResponse, len(otherNodeAddrs))
+	type replicaResult struct {
+		success bool
+		hinted  bool
+	}
+
+	// Buffered so that stragglers never block after the quorum is reached
+	results := make(chan replicaResult, len(otherNodeAddrs))
 
 	// Send replication requests to all other nodes
 	for i, addr := range otherNodeAddrs {
 		go func(addr string, index int) {
 			// Coordinator sleeps 200ms after each message (except the first one)
-			response, err := rm.client.ReplicateWrite(addr, key, value, version, index > 0)
-			if err != nil {
-				results <- &ReplicateWriteResponse{Success: false, Error: err.Error()}
+			response, err := rm.client.Replicate(addr, req, index > 0)
+			if err == nil && response.Success {
+				results <- replicaResult{success: true}
 				return
 			}
-			results <- response
+			if sloppyQuorum && rm.handOff(addr, req) {
+				results <- replicaResult{success: true, hinted: true}
+				return
+			}
+			results <- replicaResult{}
 		}(addr, i)
 	}
 
-	// Wait for all other nodes to confirm (W=N means all nodes must be updated)
+	// Wait for W acknowledgments
 	successCount := 1 // Coordinator already updated
-	for i := 0; i < len(otherNodeAddrs); i++ {
+	failureCount := 0
+	hintedCount := 0
+	for i := 0; i < len(otherNodeAddrs) && successCount < writeW; i++ {
 		result := <-results
-		if result.Success {
+		if result.success {
 			successCount++
+			if result.hinted {
+				hintedCount++
+			}
+			continue
+		}
+		failureCount++
+		// Stop waiting once W can no longer be reached
+		if len(otherNodeAddrs)-failureCount+1 < writeW {
+			break
+		}
+	}
+
+	if successCount < writeW {
+		return hintedCount, fmt.Errorf("%w: %d/%d nodes acknowledged the write", ErrNoQuorum, successCount, writeW)
+	}
+
+	return hintedCount, nil
+}
+
+// WriteBatch writes several values, each with a quorum of W of its replicas
+// (the key's own W for namespaced keys; last-writer-wins only)
+// Every replica of any of the keys receives all of its writes in one
+// request, so the cost is one request per node rather than per write. Keys
+// this node replicates are written locally first; the others are
+// coordinated without a local copy. Returns the result of each write.
+func (rm *ReplicationManager) WriteBatch(writes []ReplicateWriteRequest) []WriteResult {
+	sloppyQuorum := rm.config.UsesSloppyQuorum()
+
+	results := make([]WriteResult, len(writes))
+	acks := make([]int, len(writes))
+	needed := make([]int, len(writes))
+
+	// Writes each other replica receives, by node
+	requests := make(map[string][]int)
+	for i := range writes {
+		write := &writes[i]
+		write.Version = rm.clock.Now()
+		results[i].Version = write.Version
+
+		if err := rm.store.CheckValueSize(len(write.Value)); err != nil {
+			results[i].Error = err
+			continue
+		}
+		if rm.config.IsWriteReplica(write.Key) {
+			if _, err := rm.store.SetWithVersion(write.Key, write.Value, write.Version); err != nil {
+				results[i].Error = err
+				continue
+			}
+			acks[i] = 1
+		}
+
+		_, writeW := rm.config.GetReplicationParamsFor(write.Key)
+		others, pending := rm.config.GetWriteReplicas(write.Key)
+		needed[i] = writeW + pending
+		for _, addr := range others {
+			requests[addr] = append(requests[addr], i)
+		}
+	}
+
+	type batchReply struct {
+		indexes []int
+		acked   []bool
+		hinted  []bool
+	}
+
+	// Buffered so that stragglers never block after every quorum is reached
+	replies := make(chan batchReply, len(requests))
+	index := 0
+	for addr, indexes := range requests {
+		go func(addr string, indexes []int, index int) {
+			batch := make([]ReplicateWriteRequest, len(indexes))
+			for j, i := range indexes {
+				batch[j] = writes[i]
+			}
+
+			// Coordinator sleeps 200ms after each message (except the first one)
+			responses, err := rm.client.ReplicateBatch(addr, batch, index > 0)
+			reply := batchReply{indexes: indexes, acked: make([]bool, len(indexes)), hinted: make([]bool, len(indexes))}
+			for j := range indexes {
+				if err == nil && responses[j].Success {
+					reply.acked[j] = true
+					continue
+				}
+				if sloppyQuorum && rm.handOff(addr, batch[j]) {
+					reply.acked[j] = true
+					reply.hinted[j] = true
+				}
+			}
+			replies <- reply
+		}(addr, indexes, index)
+		index++
+	}
+
+	// Wait until every write has W acknowledgments, or every replica has
+	// replied
+	waiting := 0
+	for i := range writes {
+		if results[i].Error == nil && acks[i] < needed[i] {
+			waiting++
+		}
+	}
+	for n := 0; n < len(requests) && waiting > 0; n++ {
+		reply := <-replies
+		for j, i := range reply.indexes {
+			if !reply.acked[j] || results[i].Error != nil || acks[i] >= needed[i] {
+				continue
+			}
+			acks[i]++
+			if reply.hinted[j] {
+				results[i].Hinted++
+			}
+			if acks[i] == needed[i] {
+				waiting--
+			}
+		}
+	}
+
+	for i := range writes {
+		if results[i].Error != nil {
+			continue
+		}
+		if acks[i] < needed[i] {
+			results[i].Error = fmt.Errorf("%w: %d/%d nodes acknowledged the write", ErrNoQuorum, acks[i], needed[i])
+			continue
+		}
+		results[i].Success = true
+	}
+	return results
+}
+
+// handOff asks a healthy substitute to hold a write for an unreachable
+// owner. Substitutes are tried in ring order: first the nodes after the
+// key's preference list, then the key's other replicas.
+// Returns true once a substitute has stored the hint
+func (rm *ReplicationManager) handOff(owner string, req ReplicateWriteRequest) bool {
+	req.HintFor = owner
+	myAddr := rm.config.GetMyAddr()
+
+	rf := rm.config.GetKeyReplicationFactor(req.Key)
+	ring := rm.config.GetRing().PreferenceList(req.Key, rm.config.GetN())
+	candidates := append(append([]string{}, ring[rf:]...), ring[:rf]...)
+
+	for _, addr := range candidates {
+		if addr == owner || addr == myAddr {
+			continue
+		}
+		response, err := rm.client.Replicate(addr, req, false)
+		if err == nil && response.Success {
+			return true
+		}
+	}
+	return false
+}
+
+// Forward sends a client request for a key this node does not replicate to
+// the first reachable node in the key's preference list
+func (rm *ReplicationManager) Forward(key string, method string, path string, header http.Header, body []byte) (*http.Response, error) {
+	var lastErr error
+	for _, addr := range rm.config.GetPreferenceList(key) {
+		resp, err := rm.client.Forward(addr, method, path, header, body)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%w: no replica of key %q is reachable: %v", ErrNoQuorum, key, lastErr)
+}
+
+// StoreHint keeps a hint for the node a write was meant for
+// Called on a substitute (after applying the write if it is also a replica)
+func (rm *ReplicationManager) StoreHint(req ReplicateWriteRequest) error {
+	return rm.hints.Add(req.HintFor, req)
+}
+
+// HintStats returns the hinted handoff metrics of this node
+func (rm *ReplicationManager) HintStats() HintStats {
+	return rm.hints.Stats()
+}
+
+// replayHints periodically hands off stored hints to their owners and
+// discards hints past the retention limit
+func (rm *ReplicationManager) replayHints() {
+	for {
+		time.Sleep(hintReplayInterval)
+
+		if expired, err := rm.hints.Expire(); err != nil {
+			log.Printf("Failed to expire hints: %v", err)
+		} else if expired > 0 {
+			log.Printf("Discarded %d expired hints", expired)
+		}
+
+		for _, owner := range rm.hints.Owners() {
+			rm.replayHintsTo(owner)
+		}
+	}
+}
+
+// replayHintsTo hands off the hints held for one owner, oldest first,
+// stopping at the first failure
+func (rm *ReplicationManager) replayHintsTo(owner string) {
+	for {
+		pending := rm.hints.Pending(owner, hintReplayBatchSize)
+		if len(pending) == 0 {
+			return
+		}
+
+		var delivered []*Hint
+		for _, hint := range pending {
+			response, err := rm.client.Replicate(owner, hint.Write, false)
+			if err != nil || !response.Success {
+				break
+			}
+			delivered = append(delivered, hint)
+		}
+
+		if err := rm.hints.Delivered(delivered); err != nil {
+			log.Printf("Failed to persist hints: %v", err)
+		}
+		if len(delivered) > 0 {
+			log.Printf("Handed off %d hints to %s", len(delivered), owner)
+		}
+		if len(delivered) < len(pending) {
+			return
+		}
+	}
+}
+
+// Read reads a value from a quorum of R nodes
+// With R=1 the local value is returned immediately (no coordination).
+// Otherwise this node queries every other node and returns the most recent
+// version among the first R responses (including its own). A node that does
+// not have the key counts as a response.
+func (rm *ReplicationManager) Read(key string) (*kvstore.KeyValue, error) {
+	readR, _ := rm.config.GetReplicationParamsFor(key)
+	if readR <= 1 {
+		return rm.ReadLocal(key)
+	}
+
+	otherNodeAddrs := rm.config.GetOtherReplicas(key)
+
+	// The local value is the first response
+	var responses []*kvstore.KeyValue
+	if kv, exists := rm.store.Get(key); exists {
+		responses = append(responses, kv)
+	}
+	responseCount := 1
+
+	for _, response := range rm.readReplicas(otherNodeAddrs, key, readR-1) {
+		responseCount++
+		if response.Exists {
+			responses = append(responses, &kvstore.KeyValue{
+				Key:      response.Key,
+				Value:    response.Value,
+				Version:  response.Version,
+				Siblings: response.Siblings,
+				CRDT:     response.CRDT,
+				Deleted:  response.Deleted,
+			})
+		}
+	}
+
+	if responseCount < readR {
+		return nil, fmt.Errorf("%w: %d/%d nodes responded to the read", ErrNoQuorum, responseCount, readR)
+	}
+
+	return rm.resolve(key, responses)
+}
+
+// resolve returns the value of a key given the responses of a read quorum
+// (only the replicas that have the key)
+func (rm *ReplicationManager) resolve(key string, responses []*kvstore.KeyValue) (*kvstore.KeyValue, error) {
+	if len(responses) == 0 {
+		return nil, ErrKeyNotFound
+	}
+
+	// CRDT states are merged, so the result includes every update any of
+	// the replicas has seen
+	for _, kv := range responses {
+		if kv.CRDT != nil {
+			return mergeCRDTs(key, responses), nil
+		}
+	}
+
+	// Under causal versioning every concurrent value is returned
+	if rm.config.UsesVectorClocks() {
+		return reconcileValues(key, responses), nil
+	}
+
+	// Return the most recent version (not found if it is a delete)
+	kv := getMostRecentValue(responses)
+	if kv.Deleted {
+		return nil, ErrKeyNotFound
+	}
+	return kv, nil
+}
+
+// readReplicas reads a key from other replicas until `needed` of them have
+// replied
+// With hedged reads the fastest replicas are asked first and the others only
+// when replies are slow; otherwise every replica is asked at once. Returns
+// fewer responses if too many replicas fail.
+func (rm *ReplicationManager) readReplicas(addrs []string, key string, needed int) []*ReadResponse {
+	if hedged, _, _ := rm.config.GetHedging(); hedged {
+		replies := rm.hedger.Do(addrs, needed, func(ctx context.Context, addr string) (interface{}, error) {
+			return rm.client.ReadFromNodeContext(ctx, addr, key)
+		})
+		responses := make([]*ReadResponse, 0, len(replies))
+		for _, reply := range replies {
+			responses = append(responses, reply.Value.(*ReadResponse))
+		}
+		return responses
+	}
+
+	results := make(chan *ReadResponse, len(addrs))
+	for _, addr := range addrs {
+		go func(addr string) {
+			response, err := rm.client.ReadFromNode(addr, key)
+			if err != nil {
+				results <- nil
+				return
+			}
+			results <- response
+		}(addr)
+	}
+
+	var responses []*ReadResponse
+	failureCount := 0
+	for i := 0; i < len(addrs) && len(responses) < needed; i++ {
+		response := <-results
+		if response == nil {
+			failureCount++
+			// Stop waiting once enough replies can no longer arrive
+			if len(addrs)-failureCount < needed {
+				break
+			}
+			continue
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// ReadBatch reads several keys, each from a quorum of R of its replicas
+// (the key's own R for namespaced keys)
+// Every replica of any of the keys is asked for all of them in one request,
+// so the cost is one request per node rather than per key (reads are not
+// hedged). Keys this node replicates count its local value as a response.
+// Returns the value or the error of each key.
+func (rm *ReplicationManager) ReadBatch(keys []string) ([]*kvstore.KeyValue, []error) {
+	myAddr := rm.config.GetMyAddr()
+
+	responses := make([][]*kvstore.KeyValue, len(keys))
+	counts := make([]int, len(keys))
+	needed := make([]int, len(keys)) // R of each key
+
+	// Keys each other replica is asked for, by node
+	requests := make(map[string][]int)
+	for i, key := range keys {
+		needed[i], _ = rm.config.GetReplicationParamsFor(key)
+		replicas := rm.config.GetPreferenceList(key)
+		for _, addr := range replicas {
+			if addr != myAddr {
+				continue
+			}
+			if kv, exists := rm.store.Get(key); exists {
+				responses[i] = append(responses[i], kv)
+			}
+			counts[i]++
+		}
+		if counts[i] >= needed[i] {
+			continue
+		}
+		for _, addr := range replicas {
+			if addr != myAddr {
+				requests[addr] = append(requests[addr], i)
+			}
+		}
+	}
+
+	type batchReply struct {
+		indexes   []int
+		responses []ReadResponse // nil if the replica did not reply
+	}
+
+	replies := make(chan batchReply, len(requests))
+	for addr, indexes := range requests {
+		go func(addr string, indexes []int) {
+			batch := make([]string, len(indexes))
+			for j, i := range indexes {
+				batch[j] = keys[i]
+			}
+			responses, err := rm.client.ReadBatch(addr, batch)
+			if err != nil {
+				responses = nil
+			}
+			replies <- batchReply{indexes: indexes, responses: responses}
+		}(addr, indexes)
+	}
+
+	// Wait until every key has R responses, or every replica has replied
+	waiting := 0
+	for i := range keys {
+		if counts[i] < needed[i] {
+			waiting++
+		}
+	}
+	for n := 0; n < len(requests) && waiting > 0; n++ {
+		reply := <-replies
+		if reply.responses == nil {
+			continue
+		}
+		for j, i := range reply.indexes {
+			if counts[i] >= needed[i] {
+				continue
+			}
+			counts[i]++
+			if response := reply.responses[j]; response.Exists {
+				responses[i] = append(responses[i], &kvstore.KeyValue{
+					Key:      response.Key,
+					Value:    response.Value,
+					Version:  response.Version,
+					Siblings: response.Siblings,
+					CRDT:     response.CRDT,
+					Deleted:  response.Deleted,
+				})
+			}
+			if counts[i] == needed[i] {
+				waiting--
+			}
+		}
+	}
+
+	values := make([]*kvstore.KeyValue, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		if counts[i] < needed[i] {
+			errs[i] = fmt.Errorf("%w: %d/%d nodes responded to the read", ErrNoQuorum, counts[i], needed[i])
+			continue
 		}
+		values[i], errs[i] = rm.resolve(key, responses[i])
+	}
+	return values, errs
+}
+
+// Acceptor returns this replica's Paxos state for compare-and-set
+func (rm *ReplicationManager) Acceptor() *Acceptor {
+	return rm.paxos
+}
+
+// GetReadLatency returns the observed replica read latencies and hedging
+// counters
+func (rm *ReplicationManager) GetReadLatency() hedge.Stats {
+	return rm.hedger.Stats()
+}
+
+// mergeCRDTs merges the CRDT states of multiple KeyValue responses
+func mergeCRDTs(key string, responses []*kvstore.KeyValue) *kvstore.KeyValue {
+	var merged *kvstore.CRDT
+	for _, kv := range responses {
+		if kv.CRDT == nil {
+			continue
+		}
+		if merged == nil {
+			merged = kv.CRDT.Clone()
+			continue
+		}
+		merged.Merge(kv.CRDT)
+	}
+	return &kvstore.KeyValue{Key: key, CRDT: merged}
+}
+
+// reconcileValues combines the siblings of multiple KeyValue responses,
+// dropping every sibling that another one supersedes
+func reconcileValues(key string, responses []*kvstore.KeyValue) *kvstore.KeyValue {
+	sets := make([][]kvstore.Sibling, 0, len(responses))
+	for _, kv := range responses {
+		sets = append(sets, kv.Siblings)
 	}
+	return &kvstore.KeyValue{
+		Key:      key,
+		Siblings: kvstore.ReconcileSiblings(sets...),
+	}
+}
 
-	// All nodes (N) must be updated
-	n := rm.config.GetN()
-	if successCount < n {
-		return nil, fmt.Errorf("failed to replicate to all nodes: %d/%d succeeded", successCount, n)
+// getMostRecentValue compares multiple KeyValue responses and returns the one with highest version
+func getMostRecentValue(responses []*kvstore.KeyValue) *kvstore.KeyValue {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	mostRecent := responses[0]
+	for _, kv := range responses[1:] {
+		if kv != nil && kv.Version > mostRecent.Version {
+			mostRecent = kv
+		}
 	}
 
-	return &WriteResult{Version: version, Success: true}, nil
+	return mostRecent
 }
 
 // ReadLocal implements R=1 strategy
 // Returns the local value immediately (no coordination)
 func (rm *ReplicationManager) ReadLocal(key string) (*kvstore.KeyValue, error) {
 	kv, exists := rm.store.Get(key)
-	if !exists {
-		return nil, fmt.Errorf("key not found")
+	if !exists || kv.Deleted {
+		return nil, ErrKeyNotFound
 	}
 	return kv, nil
 }
 
+// configRetryInterval is how long a node waits before re-sending a
+// configuration change to a node that did not acknowledge it
+const configRetryInterval = 1 * time.Second
+
+// UpdateConfig applies a new R/W configuration locally and propagates it
+// to every other node. Any node can accept a configuration change.
+// The version is a hybrid logical clock timestamp, so changes accepted
+// concurrently by different nodes are totally ordered (by time, then node
+// id) and every node keeps the same one.
+// Returns the new config version and the number of nodes (including this
+// one) that applied it. Nodes that could not be reached are retried in the
+// background. Returns ErrConfigSuperseded if a newer change was applied
+// first, here or on another node.
+func (rm *ReplicationManager) UpdateConfig(r, w int) (int64, int, error) {
+	version := rm.clock.Now()
+	if !rm.config.ApplyReplicationParams(r, w, version) {
+		return rm.config.GetConfigVersion(), 0, ErrConfigSuperseded
+	}
+	clusterConfig := ClusterConfig{R: r, W: w, Version: version}
+
+	type pushResult struct {
+		addr    string
+		current int64 // The node's config version after the push
+		ok      bool
+	}
+
+	otherNodeAddrs := rm.config.GetOtherNodeAddrs()
+	results := make(chan pushResult, len(otherNodeAddrs))
+
+	for _, addr := range otherNodeAddrs {
+		go func(addr string) {
+			current, ok := rm.pushConfig(addr, clusterConfig)
+			results <- pushResult{addr: addr, current: current, ok: ok}
+		}(addr)
+	}
+
+	appliedCount := 1 // This node already updated
+	superseded := false
+	for i := 0; i < len(otherNodeAddrs); i++ {
+		result := <-results
+		switch {
+		case !result.ok:
+			// Keep retrying in the background until the node catches up
+			// or a newer configuration supersedes this one
+			go rm.retryConfig(result.addr, clusterConfig)
+		case result.current == version:
+			appliedCount++
+		case result.current > version:
+			// The node took a newer change, which it propagates itself
+			superseded = true
+		}
+	}
+
+	if superseded || rm.config.GetConfigVersion() != version {
+		return version, appliedCount, ErrConfigSuperseded
+	}
+	return version, appliedCount, nil
+}
+
+// ApplyConfig applies a configuration change propagated by another node if
+// it is newer than the current one
+// Returns true if the configuration was applied
+func (rm *ReplicationManager) ApplyConfig(clusterConfig ClusterConfig) bool {
+	// Later changes through this node must order after this one
+	rm.clock.Update(clusterConfig.Version)
+	return rm.config.ApplyReplicationParams(clusterConfig.R, clusterConfig.W, clusterConfig.Version)
+}
+
+// pushConfig sends a configuration to a single node
+// Returns the node's config version after applying it, and true if the
+// node acknowledged it
+func (rm *ReplicationManager) pushConfig(addr string, clusterConfig ClusterConfig) (int64, bool) {
+	response, err := rm.client.ReplicateConfig(addr, clusterConfig)
+	if err != nil || !response.Success {
+		return 0, false
+	}
+	return response.Version, true
+}
+
+// retryConfig re-sends a configuration to a node until it is acknowledged
+func (rm *ReplicationManager) retryConfig(addr string, clusterConfig ClusterConfig) {
+	for {
+		time.Sleep(configRetryInterval)
+		if rm.config.GetConfigVersion() != clusterConfig.Version {
+			return
+		}
+		if _, ok := rm.pushConfig(addr, clusterConfig); ok {
+			log.Printf("Config version %d delivered to %s after retry", clusterConfig.Version, addr)
+			return
+		}
+	}
+}
+
+// SyncConfig pulls the cluster configuration from all other nodes and
+// adopts the highest version found. Used when a node (re)starts so that it
+// picks up the current configuration instead of the startup defaults
+// Returns true if at least one other node responded
+func (rm *ReplicationManager) SyncConfig() bool {
+	otherNodeAddrs := rm.config.GetOtherNodeAddrs()
+
+	results := make(chan *ClusterConfig, len(otherNodeAddrs))
+	for _, addr := range otherNodeAddrs {
+		go func(addr string) {
+			clusterConfig, err := rm.client.FetchConfig(addr)
+			if err != nil {
+				results <- nil
+				return
+			}
+			results <- clusterConfig
+		}(addr)
+	}
+
+	var latest *ClusterConfig
+	var membership *Membership
+	responded := 0
+	for i := 0; i < len(otherNodeAddrs); i++ {
+		clusterConfig := <-results
+		if clusterConfig == nil {
+			continue
+		}
+		responded++
+		if latest == nil || clusterConfig.Version > latest.Version {
+			latest = clusterConfig
+		}
+		if m := clusterConfig.Membership; m != nil && (membership == nil || m.Version > membership.Version) {
+			membership = m
+		}
+	}
+
+	if latest != nil && rm.ApplyConfig(*latest) {
+		log.Printf("Synced cluster config: R=%d W=%d (version %d)", latest.R, latest.W, latest.Version)
+	}
+	if membership != nil && rm.config.ApplyMembership(*membership, rm.config.IsJoining()) {
+		log.Printf("Synced ring membership: %v (version %d)", membership.Members, membership.Version)
+	}
+	if membership != nil {
+		rm.config.SetJoining(false)
+	}
+
+	return responded > 0
+}
@@ -0,0 +1,119 @@
+package leaderless
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestBatchQuorumPerKey(t *testing.T) {
+	// Three nodes with two replicas per key: this one, a healthy peer and
+	// an unreachable one
+	var peer http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	self, healthy, down := "127.0.0.1:1", strings.TrimPrefix(server.URL, "http://"), "127.0.0.1:2"
+	members := []string{self, healthy, down}
+
+	newConfig := func(addr string) *Config {
+		config := NewConfig(addr, addr, members)
+		config.RF = 2
+		config.R, config.W = 2, 2
+		return config
+	}
+	handler := NewHandler(kvstore.NewStore(), newConfig(healthy))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/internal/replicate_batch", handler.ReplicateBatchHandler)
+	mux.HandleFunc("/internal/read_batch", handler.InternalReadBatchHandler)
+	peer = mux
+
+	config := newConfig(self)
+	rm := NewReplicationManager(kvstore.NewStore(), config)
+
+	var keys []string
+	var writes []ReplicateWriteRequest
+	for i := 0; i < 100; i++ {
+		key := "key:" + strconv.Itoa(i)
+		keys = append(keys, key)
+		writes = append(writes, ReplicateWriteRequest{Key: key, Value: []byte("v" + strconv.Itoa(i))})
+	}
+
+	// A key reaches its quorum unless the unreachable node is one of its
+	// replicas, whatever happens to the other keys of the batch
+	tests := []struct {
+		name     string
+		replicas []string
+		wantOK   bool
+	}{
+		{name: "this node and the healthy peer", replicas: []string{self, healthy}, wantOK: true},
+		{name: "this node and the unreachable one", replicas: []string{self, down}},
+		{name: "the healthy peer and the unreachable one", replicas: []string{healthy, down}},
+	}
+
+	results := rm.WriteBatch(writes)
+	values, errs := rm.ReadBatch(keys)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked := 0
+			for i, key := range keys {
+				if !sameMembers(config.GetPreferenceList(key), tt.replicas) {
+					continue
+				}
+				checked++
+				if results[i].Success != tt.wantOK || (results[i].Error == nil) != tt.wantOK {
+					t.Fatalf("%s: got write result %+v, want success %v", key, results[i], tt.wantOK)
+				}
+				if !tt.wantOK {
+					if !errors.Is(results[i].Error, ErrNoQuorum) || !errors.Is(errs[i], ErrNoQuorum) {
+						t.Fatalf("%s: got write error %v and read error %v, want %v", key, results[i].Error, errs[i], ErrNoQuorum)
+					}
+					continue
+				}
+				if errs[i] != nil || values[i] == nil || string(values[i].Value) != string(writes[i].Value) {
+					t.Fatalf("%s: got %+v (%v), want %q", key, values[i], errs[i], writes[i].Value)
+				}
+			}
+			if checked == 0 {
+				t.Fatal("no key has these replicas")
+			}
+		})
+	}
+}
+
+func TestCausalWritesAfterRestart(t *testing.T) {
+	addr := "localhost:1"
+	config := NewConfig("n1", addr, []string{addr})
+	peer := kvstore.NewStore()
+
+	// The peer holds a write the node coordinated before it restarted with
+	// an empty store
+	before, err := NewReplicationManager(kvstore.NewStore(), config).WriteCausal("k", []byte("x"), kvstore.VectorClock{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer.MergeSibling("k", before.Sibling)
+
+	restarted := NewReplicationManager(kvstore.NewStore(), config)
+	after, err := restarted.WriteCausal("k", []byte("y"), kvstore.VectorClock{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Sibling.Dot == before.Sibling.Dot {
+		t.Fatalf("the restarted node reused the dot %+v", after.Sibling.Dot)
+	}
+
+	// The new write is concurrent with the forgotten one, not a duplicate
+	if added, _ := peer.MergeSibling("k", after.Sibling); !added {
+		t.Fatal("the peer dropped the write of the restarted node")
+	}
+	if kv, _ := peer.Get("k"); len(kv.Siblings) != 2 {
+		t.Fatalf("got siblings %+v, want x and y", kv.Siblings)
+	}
+}
@@ -0,0 +1,130 @@
+package leaderless
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of tokens each node owns on the ring
+const DefaultVirtualNodes = 128
+
+// Token is a position on the hash ring owned by a node
+type Token struct {
+	Hash uint64 `json:"token"`
+	Addr string `json:"addr"`
+}
+
+// NodeOwnership summarizes the share of the ring a node owns
+type NodeOwnership struct {
+	Addr      string  `json:"addr"`
+	Tokens    int     `json:"tokens"`
+	Ownership float64 `json:"ownership"` // Fraction of the key space for which the node is the first replica
+}
+
+// Ring is a consistent-hash ring with virtual nodes
+// Each node owns vnodes tokens; a key belongs to the first token at or after
+// its hash (clockwise), and its preference list is the distinct nodes found
+// walking clockwise from there. The ring is immutable once built, and every
+// node builds the same ring from the same address list.
+type Ring struct {
+	vnodes int
+	addrs  []string
+	tokens []Token // Sorted by hash
+}
+
+// NewRing builds a ring over the given node addresses
+func NewRing(addrs []string, vnodes int) *Ring {
+	if vnodes < 1 {
+		vnodes = DefaultVirtualNodes
+	}
+	tokens := make([]Token, 0, len(addrs)*vnodes)
+	for _, addr := range addrs {
+		for i := 0; i < vnodes; i++ {
+			tokens = append(tokens, Token{Hash: hashString(addr + "#" + strconv.Itoa(i)), Addr: addr})
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Hash == tokens[j].Hash {
+			return tokens[i].Addr < tokens[j].Addr
+		}
+		return tokens[i].Hash < tokens[j].Hash
+	})
+	return &Ring{
+		vnodes: vnodes,
+		addrs:  append([]string{}, addrs...),
+		tokens: tokens,
+	}
+}
+
+// PreferenceList returns the first n distinct nodes responsible for a key
+func (r *Ring) PreferenceList(key string, n int) []string {
+	if n > len(r.addrs) {
+		n = len(r.addrs)
+	}
+	if len(r.tokens) == 0 || n < 1 {
+		return nil
+	}
+
+	hash := hashString(key)
+	start := sort.Search(len(r.tokens), func(i int) bool {
+		return r.tokens[i].Hash >= hash
+	})
+
+	seen := make(map[string]bool, n)
+	list := make([]string, 0, n)
+	for i := 0; i < len(r.tokens) && len(list) < n; i++ {
+		token := r.tokens[(start+i)%len(r.tokens)]
+		if !seen[token.Addr] {
+			seen[token.Addr] = true
+			list = append(list, token.Addr)
+		}
+	}
+	return list
+}
+
+// VirtualNodes returns the number of tokens per node
+func (r *Ring) VirtualNodes() int {
+	return r.vnodes
+}
+
+// Tokens returns every token on the ring, sorted by hash
+func (r *Ring) Tokens() []Token {
+	return append([]Token{}, r.tokens...)
+}
+
+// Ownership returns each node's share of the ring
+func (r *Ring) Ownership() []NodeOwnership {
+	owned := make(map[string]float64, len(r.addrs))
+	counts := make(map[string]int, len(r.addrs))
+	for i, token := range r.tokens {
+		// A token owns the range from the previous token (exclusive) to itself
+		var width float64
+		if i == 0 {
+			// Wraps around from the last token
+			width = float64(token.Hash) + float64(^uint64(0)-r.tokens[len(r.tokens)-1].Hash) + 1
+		} else {
+			width = float64(token.Hash - r.tokens[i-1].Hash)
+		}
+		owned[token.Addr] += width / (1 << 64)
+		counts[token.Addr]++
+	}
+
+	ownership := make([]NodeOwnership, 0, len(r.addrs))
+	for _, addr := range r.addrs {
+		ownership = append(ownership, NodeOwnership{
+			Addr:      addr,
+			Tokens:    counts[addr],
+			Ownership: owned[addr],
+		})
+	}
+	return ownership
+}
+
+// hashString places a string on the ring
+// MD5 spreads similar strings (such as a node's token names) evenly
+func hashString(s string) uint64 {
+	sum := md5.Sum([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
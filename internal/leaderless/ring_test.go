@@ -0,0 +1,96 @@
+package leaderless
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRingPlacementStability(t *testing.T) {
+	const (
+		keys = 10000
+		rf   = 3
+	)
+	nodes := []string{"n1:8080", "n2:8080", "n3:8080", "n4:8080", "n5:8080"}
+
+	tests := []struct {
+		name          string
+		before, after []string
+		changed       string // Node added or removed
+	}{
+		{name: "add a node", before: nodes[:4], after: nodes, changed: nodes[4]},
+		{name: "add a node in the middle of the list", before: []string{nodes[0], nodes[2], nodes[3]}, after: nodes[:4], changed: nodes[1]},
+		{name: "remove a node", before: nodes, after: nodes[1:], changed: nodes[0]},
+		{name: "remove the last node", before: nodes, after: nodes[:4], changed: nodes[4]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := NewRing(tt.before, DefaultVirtualNodes)
+			after := NewRing(tt.after, DefaultVirtualNodes)
+
+			moved := 0
+			for i := 0; i < keys; i++ {
+				key := "key:" + strconv.Itoa(i)
+				old, updated := before.PreferenceList(key, rf), after.PreferenceList(key, rf)
+
+				// Leaving out the changed node, the replicas keep their order
+				// and only the one after the end of the list can join
+				oldKept, updatedKept := without(old, tt.changed), without(updated, tt.changed)
+				if !isPrefix(oldKept, updatedKept) && !isPrefix(updatedKept, oldKept) {
+					t.Fatalf("%s moved from %v to %v", key, old, updated)
+				}
+				if old[0] != updated[0] {
+					if old[0] != tt.changed && updated[0] != tt.changed {
+						t.Fatalf("%s moved from %s to %s, neither of them %s", key, old[0], updated[0], tt.changed)
+					}
+					moved++
+				}
+			}
+
+			// About 1/N of the keys change their first replica, where N is the
+			// larger cluster size
+			n := len(tt.before)
+			if len(tt.after) > n {
+				n = len(tt.after)
+			}
+			if expected := keys / n; moved < expected/2 || moved > expected*3/2 {
+				t.Fatalf("%d keys moved, want about %d", moved, expected)
+			}
+		})
+	}
+}
+
+func TestRingIgnoresAddressOrder(t *testing.T) {
+	a := NewRing([]string{"n1:8080", "n2:8080", "n3:8080"}, DefaultVirtualNodes)
+	b := NewRing([]string{"n3:8080", "n1:8080", "n2:8080"}, DefaultVirtualNodes)
+	for i := 0; i < 1000; i++ {
+		key := "key:" + strconv.Itoa(i)
+		if x, y := a.PreferenceList(key, 2), b.PreferenceList(key, 2); !isPrefix(x, y) || len(x) != len(y) {
+			t.Fatalf("%s has preference lists %v and %v", key, x, y)
+		}
+	}
+}
+
+// without returns addrs without addr
+func without(addrs []string, addr string) []string {
+	kept := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		if a != addr {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// isPrefix returns true if prefix starts list
+func isPrefix(prefix, list []string) bool {
+	if len(prefix) > len(list) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != list[i] {
+			return false
+		}
+	}
+	return true
+}
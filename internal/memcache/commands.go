@@ -0,0 +1,335 @@
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// version is the memcached release whose text protocol is served, reported
+// by the version command
+const version = "1.6.0"
+
+// maxRelativeExptime is the longest exptime in seconds from now; larger
+// exptimes are Unix times (30 days, as in memcached)
+const maxRelativeExptime = 60 * 60 * 24 * 30
+
+// Errors of incr and decr
+var (
+	errNotFound   = errors.New("not found")
+	errNotNumeric = errors.New("cannot increment or decrement non-numeric value")
+)
+
+// errBadDataChunk is a value not followed by CRLF; the connection is closed
+// after replying with it
+var errBadDataChunk = errors.New("bad data chunk")
+
+// get replies with the values of keys (missing keys are left out):
+// get|gets <key>*
+// gets also replies with each key's cas unique, its version.
+func (s *Server) get(w *bufio.Writer, keys []string, withCAS bool) {
+	if len(keys) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	for _, key := range keys {
+		if !validKey(key) {
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+
+	values := make([]*kvstore.KeyValue, len(keys))
+	flags := make([]uint32, len(keys))
+	for i, key := range keys {
+		kv, err := s.backend.Get(key)
+		if err == nil && kv != nil {
+			flags[i], err = s.flags(key, kv)
+		}
+		if err != nil {
+			replyError(w, false, err)
+			return
+		}
+		values[i] = kv
+	}
+
+	for i, kv := range values {
+		if kv == nil {
+			continue
+		}
+		line := "VALUE " + keys[i] + " " + strconv.FormatUint(uint64(flags[i]), 10) + " " + strconv.Itoa(len(kv.Value))
+		if withCAS {
+			line += " " + strconv.FormatInt(kv.Version, 10)
+		}
+		w.WriteString(line + "\r\n")
+		w.Write(kv.Value)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("END\r\n")
+}
+
+// store runs a storage command, reading its value from the connection:
+// set|add|replace <key> <flags> <exptime> <bytes> [noreply]
+// cas <key> <flags> <exptime> <bytes> <cas unique> [noreply]
+// add only writes a missing key and replace an existing one; cas writes the
+// key only if its version is still the cas unique read by gets.
+func (s *Server) store(r *bufio.Reader, w *bufio.Writer, name string, args []string) error {
+	args, noreply := parseNoreply(args)
+	want := 4
+	if name == "cas" {
+		want = 5
+	}
+	if len(args) != want {
+		w.WriteString("ERROR\r\n")
+		return nil
+	}
+
+	size, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil || size < 0 {
+		reply(w, noreply, "CLIENT_ERROR bad command line format")
+		return nil
+	}
+	if size > s.maxValue {
+		reply(w, noreply, "SERVER_ERROR object too large for cache")
+		_, err := r.Discard(int(size + 2))
+		return err
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	if data[size] != '\r' || data[size+1] != '\n' {
+		reply(w, noreply, "CLIENT_ERROR "+errBadDataChunk.Error())
+		return errBadDataChunk
+	}
+	value := data[:size]
+
+	key := args[0]
+	flags, flagsErr := strconv.ParseUint(args[1], 10, 32)
+	exptime, exptimeErr := strconv.ParseInt(args[2], 10, 64)
+	if !validKey(key) || flagsErr != nil || exptimeErr != nil {
+		reply(w, noreply, "CLIENT_ERROR bad command line format")
+		return nil
+	}
+
+	var preconditions rest.Preconditions
+	switch name {
+	case "add":
+		preconditions.IfNoneMatch = "*"
+	case "replace":
+		preconditions.IfMatch = "*"
+	case "cas":
+		unique, err := strconv.ParseInt(args[4], 10, 64)
+		if err != nil {
+			reply(w, noreply, "CLIENT_ERROR bad command line format")
+			return nil
+		}
+		preconditions.IfMatch = rest.ETag(unique)
+	}
+
+	written, err := s.backend.Set(key, value, preconditions)
+	if errors.Is(err, rest.ErrPreconditionFailed) {
+		if name != "cas" {
+			reply(w, noreply, "NOT_STORED")
+			return nil
+		}
+		kv, err := s.backend.Get(key)
+		switch {
+		case err != nil:
+			replyError(w, noreply, err)
+		case kv == nil:
+			reply(w, noreply, "NOT_FOUND")
+		default:
+			reply(w, noreply, "EXISTS")
+		}
+		return nil
+	}
+	if err == nil && flags != 0 {
+		err = s.setFlags(key, written, uint32(flags))
+	}
+	if err != nil {
+		replyError(w, noreply, err)
+		return nil
+	}
+	s.expiries.Set(key, written, timeout(exptime))
+	reply(w, noreply, "STORED")
+	return nil
+}
+
+// delete deletes a key: delete <key> [0] [noreply]
+func (s *Server) delete(w *bufio.Writer, args []string) {
+	args, noreply := parseNoreply(args)
+	if len(args) == 2 && args[1] == "0" {
+		// Old clients send a hold time, which must be 0
+		args = args[:1]
+	}
+	if len(args) != 1 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	key := args[0]
+	if !validKey(key) {
+		reply(w, noreply, "CLIENT_ERROR bad command line format")
+		return
+	}
+
+	kv, err := s.backend.Get(key)
+	if err != nil {
+		replyError(w, noreply, err)
+		return
+	}
+	if kv == nil {
+		reply(w, noreply, "NOT_FOUND")
+		return
+	}
+	if err := s.backend.Delete(key, rest.Preconditions{}); err != nil {
+		replyError(w, noreply, err)
+		return
+	}
+	s.expiries.Clear(key)
+	if err := s.clearFlags(key, kv.Version); err != nil {
+		log.Printf("memcached: clearing the flags of %q failed: %v", key, err)
+	}
+	reply(w, noreply, "DELETED")
+}
+
+// incr adds to (or, for decr, subtracts from) a 64-bit unsigned decimal value
+// and replies with the new value: incr|decr <key> <value> [noreply]
+// incr wraps around and decr stops at 0, as in memcached. Concurrent updates
+// through any nodes are not lost.
+func (s *Server) incr(w *bufio.Writer, args []string, decr bool) {
+	args, noreply := parseNoreply(args)
+	if len(args) != 2 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	key := args[0]
+	if !validKey(key) {
+		reply(w, noreply, "CLIENT_ERROR bad command line format")
+		return
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		reply(w, noreply, "CLIENT_ERROR invalid numeric delta argument")
+		return
+	}
+
+	var n uint64
+	previous, updated, err := frontend.Update(s.backend, key, func(current *kvstore.KeyValue) ([]byte, error) {
+		if current == nil {
+			return nil, errNotFound
+		}
+		v, err := strconv.ParseUint(string(current.Value), 10, 64)
+		if err != nil {
+			return nil, errNotNumeric
+		}
+		switch {
+		case !decr:
+			n = v + delta
+		case delta > v:
+			n = 0
+		default:
+			n = v - delta
+		}
+		return []byte(strconv.FormatUint(n, 10)), nil
+	})
+	switch {
+	case errors.Is(err, errNotFound):
+		reply(w, noreply, "NOT_FOUND")
+		return
+	case errors.Is(err, errNotNumeric):
+		reply(w, noreply, "CLIENT_ERROR "+err.Error())
+		return
+	case err != nil:
+		replyError(w, noreply, err)
+		return
+	}
+	s.expiries.Move(key, previous.Version, updated.Version)
+
+	// The new value keeps the flags of the one it replaced
+	flags, err := s.flags(key, previous)
+	if err == nil && flags != 0 {
+		err = s.setFlags(key, updated.Version, flags)
+	}
+	if err != nil {
+		replyError(w, noreply, err)
+		return
+	}
+	reply(w, noreply, strconv.FormatUint(n, 10))
+}
+
+// touch replaces a key's exptime: touch <key> <exptime> [noreply]
+// Like all exptimes, it is kept by this node (and lost if it restarts);
+// writing the key from anywhere before it passes clears it.
+func (s *Server) touch(w *bufio.Writer, args []string) {
+	args, noreply := parseNoreply(args)
+	if len(args) != 2 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	key := args[0]
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if !validKey(key) || err != nil {
+		reply(w, noreply, "CLIENT_ERROR bad command line format")
+		return
+	}
+
+	kv, err := s.backend.Get(key)
+	if err != nil {
+		replyError(w, noreply, err)
+		return
+	}
+	if kv == nil {
+		reply(w, noreply, "NOT_FOUND")
+		return
+	}
+	s.expiries.Set(key, kv.Version, timeout(exptime))
+	reply(w, noreply, "TOUCHED")
+}
+
+// parseNoreply strips a trailing noreply argument
+func parseNoreply(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+// validKey reports whether a key is at most 250 bytes without control
+// characters or spaces
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeySize {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// timeout converts an exptime into a timeout from now (0 = never expires)
+// Exptimes over 30 days are Unix times; negative ones have already passed.
+func timeout(exptime int64) time.Duration {
+	switch {
+	case exptime == 0:
+		return 0
+	case exptime < 0:
+		return time.Nanosecond
+	case exptime > maxRelativeExptime:
+		if d := time.Until(time.Unix(exptime, 0)); d > 0 {
+			return d
+		}
+		return time.Nanosecond
+	default:
+		return time.Duration(exptime) * time.Second
+	}
+}
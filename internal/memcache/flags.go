@@ -0,0 +1,92 @@
+package memcache
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// flagsPrefix starts the reserved keys holding the client flags of values
+// stored with nonzero flags ('.' is not allowed in namespace names)
+const flagsPrefix = kvstore.ReservedPrefix + "memcache.flags" + kvstore.ReservedPrefix
+
+// flagsSize is the size of a flags record: the flags and the version of the
+// value they belong to
+const flagsSize = 4 + 8
+
+// errFlagsSuperseded stops the write of a flags record older than the stored one
+var errFlagsSuperseded = errors.New("flags of a later version are stored")
+
+// flagsKey returns the reserved key holding the flags of a key
+func flagsKey(key string) string {
+	return flagsPrefix + key
+}
+
+// encodeFlags returns the flags record of a version of a key
+func encodeFlags(flags uint32, version int64) []byte {
+	record := make([]byte, flagsSize)
+	binary.BigEndian.PutUint32(record, flags)
+	binary.BigEndian.PutUint64(record[4:], uint64(version))
+	return record
+}
+
+// decodeFlags returns the flags and the version of a flags record
+func decodeFlags(record []byte) (uint32, int64, bool) {
+	if len(record) != flagsSize {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint32(record), int64(binary.BigEndian.Uint64(record[4:])), true
+}
+
+// flags returns the client flags of a value read
+// The flags record only applies to the version it was written for, so a
+// value written since (through any API) has flags 0.
+func (s *Server) flags(key string, kv *kvstore.KeyValue) (uint32, error) {
+	record, err := s.internal.Get(flagsKey(key))
+	if err != nil || record == nil {
+		return 0, err
+	}
+	flags, version, ok := decodeFlags(record.Value)
+	if !ok || version != kv.Version {
+		return 0, nil
+	}
+	return flags, nil
+}
+
+// setFlags records the client flags of the version of a key just written
+// A record of a later version is kept, so concurrent writes cannot pair a
+// value with the flags of another.
+func (s *Server) setFlags(key string, version int64, flags uint32) error {
+	_, _, err := frontend.Update(s.internal, flagsKey(key), func(current *kvstore.KeyValue) ([]byte, error) {
+		if current != nil {
+			if _, stored, ok := decodeFlags(current.Value); ok && stored > version {
+				return nil, errFlagsSuperseded
+			}
+		}
+		return encodeFlags(flags, version), nil
+	})
+	if errors.Is(err, errFlagsSuperseded) {
+		return nil
+	}
+	return err
+}
+
+// clearFlags deletes the flags record of a key deleted at version, unless
+// it belongs to a later write
+func (s *Server) clearFlags(key string, version int64) error {
+	record, err := s.internal.Get(flagsKey(key))
+	if err != nil || record == nil {
+		return err
+	}
+	if _, stored, ok := decodeFlags(record.Value); ok && stored > version {
+		return nil
+	}
+	err = s.internal.Delete(flagsKey(key), rest.Preconditions{IfMatch: rest.ETag(record.Version)})
+	if errors.Is(err, rest.ErrPreconditionFailed) {
+		return nil
+	}
+	return err
+}
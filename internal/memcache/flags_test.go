@@ -0,0 +1,80 @@
+package memcache
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// storeBackend is a Backend writing straight to a store
+type storeBackend struct {
+	store *kvstore.Store
+}
+
+func (b storeBackend) Get(key string) (*kvstore.KeyValue, error) {
+	kv, _ := b.store.LocalRead(key)
+	return kv, nil
+}
+
+func (b storeBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	return b.store.SetIf(key, value, preconditions.Condition())
+}
+
+func (b storeBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.store.DeleteIf(key, preconditions.Condition())
+	return err
+}
+
+func TestFlags(t *testing.T) {
+	// Each step runs one command against the same server
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{name: "set with flags", command: "set k 42 0 1\r\n1\r\n", want: "STORED\r\n"},
+		{name: "get returns the flags", command: "get k\r\n", want: "VALUE k 42 1\r\n1\r\nEND\r\n"},
+		{name: "incr", command: "incr k 1\r\n", want: "2\r\n"},
+		{name: "incr keeps the flags", command: "get k\r\n", want: "VALUE k 42 1\r\n2\r\nEND\r\n"},
+		{name: "largest flags", command: "set k 4294967295 0 1\r\nx\r\n", want: "STORED\r\n"},
+		{name: "get largest flags", command: "get k\r\n", want: "VALUE k 4294967295 1\r\nx\r\nEND\r\n"},
+		{name: "flags over 32 bits", command: "set k 4294967296 0 1\r\nx\r\n", want: "CLIENT_ERROR bad command line format\r\n"},
+		{name: "set without flags", command: "set k 0 0 1\r\ny\r\n", want: "STORED\r\n"},
+		{name: "get resets the flags", command: "get k\r\n", want: "VALUE k 0 1\r\ny\r\nEND\r\n"},
+		{name: "set before delete", command: "set k 7 0 1\r\nz\r\n", want: "STORED\r\n"},
+		{name: "delete", command: "delete k\r\n", want: "DELETED\r\n"},
+		{name: "add after delete", command: "add k 0 0 1\r\nw\r\n", want: "STORED\r\n"},
+		{name: "get after delete", command: "get k\r\n", want: "VALUE k 0 1\r\nw\r\nEND\r\n"},
+	}
+
+	store := kvstore.NewStore()
+	s := NewServer(store, storeBackend{store})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.command))
+			var out bytes.Buffer
+			w := bufio.NewWriter(&out)
+
+			line, err := readLine(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := strings.Fields(line)
+			if err := s.run(r, w, fields[0], fields[1:]); err != nil {
+				t.Fatal(err)
+			}
+			w.Flush()
+			if out.String() != tt.want {
+				t.Fatalf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	if kv, _ := store.LocalRead(flagsKey("k")); kv != nil && !kv.Deleted {
+		t.Fatalf("the flags record of a deleted key was kept: %v", kv)
+	}
+}
@@ -0,0 +1,163 @@
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Protocol limits (the memcached defaults)
+const (
+	maxLineSize     = 64 * 1024        // Longest command line
+	maxKeySize      = 250              // Longest key
+	defaultMaxValue = 1024 * 1024 * 16 // Largest value when values are unlimited
+)
+
+// errLineTooLong is a command line over maxLineSize; the connection is closed
+// after replying with it
+var errLineTooLong = errors.New("line too long")
+
+// Server serves the memcached text protocol over TCP
+type Server struct {
+	backend  frontend.Backend
+	internal frontend.Backend // Unwrapped, for the reserved flags keys
+	maxValue int64
+	expiries *frontend.Expiries // Keys given an exptime through this server
+}
+
+// NewServer creates a server for a node's store and backend
+func NewServer(store *kvstore.Store, backend frontend.Backend) *Server {
+	maxValue := store.GetMaxValueSize()
+	if maxValue <= 0 {
+		maxValue = defaultMaxValue
+	}
+	public := frontend.Public(backend)
+	return &Server{
+		backend:  public,
+		internal: backend,
+		maxValue: maxValue,
+		expiries: frontend.NewExpiries(public),
+	}
+}
+
+// ListenAndServe accepts memcached connections on addr
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts memcached connections on a listener
+func (s *Server) Serve(listener net.Listener) error {
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn runs the commands of one client until it disconnects
+// Replies are flushed once no more pipelined commands are buffered.
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReaderSize(conn, maxLineSize)
+	w := bufio.NewWriter(conn)
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			if errors.Is(err, errLineTooLong) {
+				w.WriteString("CLIENT_ERROR " + err.Error() + "\r\n")
+				w.Flush()
+			} else if err != io.EOF {
+				log.Printf("memcached connection from %s failed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			w.WriteString("ERROR\r\n")
+			continue
+		}
+
+		name := args[0]
+		if name == "quit" {
+			w.Flush()
+			return
+		}
+		if err := s.run(r, w, name, args[1:]); err != nil {
+			if err != io.EOF {
+				log.Printf("memcached connection from %s failed: %v", conn.RemoteAddr(), err)
+			}
+			w.Flush()
+			return
+		}
+
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// run executes one command
+// An error means the connection can no longer be read and must be closed.
+func (s *Server) run(r *bufio.Reader, w *bufio.Writer, name string, args []string) error {
+	switch name {
+	case "get", "gets":
+		s.get(w, args, name == "gets")
+	case "set", "add", "replace", "cas":
+		return s.store(r, w, name, args)
+	case "delete":
+		s.delete(w, args)
+	case "incr", "decr":
+		s.incr(w, args, name == "decr")
+	case "touch":
+		s.touch(w, args)
+	case "version":
+		w.WriteString("VERSION " + version + "\r\n")
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return nil
+}
+
+// readLine reads one command line without its line ending
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", errLineTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return string(line), nil
+}
+
+// reply writes a reply line unless the command was sent with noreply
+func reply(w *bufio.Writer, noreply bool, msg string) {
+	if !noreply {
+		w.WriteString(msg + "\r\n")
+	}
+}
+
+// replyError writes a backend error as a SERVER_ERROR reply
+func replyError(w *bufio.Writer, noreply bool, err error) {
+	reply(w, noreply, "SERVER_ERROR "+strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error()))
+}
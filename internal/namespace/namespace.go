@@ -0,0 +1,435 @@
+package namespace
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Consistency levels of a namespace, applied to both reads and writes
+const (
+	ConsistencyOne    = "one"    // One replica
+	ConsistencyQuorum = "quorum" // A majority of the replicas
+	ConsistencyAll    = "all"    // Every replica
+)
+
+const (
+	// separator starts the stored keys of namespaces and ends their name;
+	// clients cannot use keys starting with it (kvstore.ReservedPrefix), so
+	// they cannot collide with keys of the default namespace
+	separator = kvstore.ReservedPrefix
+	// registryKey holds the settings of every namespace, replicated like
+	// any other key
+	registryKey = separator + "namespaces"
+	// reloadInterval is the least time between two loads of the registry
+	// for namespaces this node does not know yet
+	reloadInterval = time.Second
+	// syncInterval is how often the local copy of the registry is checked
+	// for indexes to build
+	syncInterval = time.Second
+	// expiryHeader is the size of the deadline stored in front of the
+	// values of namespaces with a default_ttl
+	expiryHeader = 8
+)
+
+// validName matches namespace names
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Errors returned by the registry
+var (
+	ErrNotFound      = errors.New("namespace not found")
+	ErrExists        = errors.New("namespace already exists")
+	ErrIndexExists   = errors.New("index already exists")
+	ErrIndexNotFound = errors.New("index not found")
+	// ErrInvalidSettings wraps the reason a namespace cannot be created
+	ErrInvalidSettings = errors.New("invalid namespace settings")
+)
+
+// Settings are a namespace's name and settings
+// Zero values use the node's settings. Settings are fixed once the
+// namespace is created; only indexes can be added.
+type Settings struct {
+	Name              string          `json:"name"`
+	DefaultTTL        int64           `json:"default_ttl,omitempty"`        // Seconds after its last write a key expires (0 = never)
+	MaxValueSize      int64           `json:"max_value_size,omitempty"`     // Largest value in bytes, up to --max-value-size
+	ReplicationFactor int             `json:"replication_factor,omitempty"` // Replicas per key
+	Consistency       string          `json:"consistency,omitempty"`        // one, quorum or all ("" = the cluster's R and W)
+	Indexes           []IndexSettings `json:"indexes,omitempty"`            // Secondary indexes over JSON values
+}
+
+// IndexSettings declare a secondary index of a namespace: its keys by the
+// value of a field of their JSON values
+type IndexSettings struct {
+	Name string `json:"name"`
+	Path string `json:"path"` // Dot-separated field path ("user.id")
+}
+
+// validate checks an index's name and path
+func (i IndexSettings) validate() error {
+	if !validName.MatchString(i.Name) {
+		return fmt.Errorf("%w: index names are 1 to 64 lowercase letters, digits, '-' or '_'", ErrInvalidSettings)
+	}
+	for _, field := range strings.Split(i.Path, ".") {
+		if field == "" {
+			return fmt.Errorf("%w: index paths are dot-separated field names (\"user.id\")", ErrInvalidSettings)
+		}
+	}
+	return nil
+}
+
+// Index returns the settings of one of the namespace's indexes
+func (s Settings) Index(name string) (IndexSettings, bool) {
+	for _, index := range s.Indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return IndexSettings{}, false
+}
+
+// storeIndex returns the store index that serves one of the namespace's
+// indexes
+func (s Settings) storeIndex(index IndexSettings) kvstore.Index {
+	def := kvstore.Index{Name: s.Name + "/" + index.Name, Prefix: Key(s.Name, ""), Path: index.Path}
+	if s.DefaultTTL > 0 {
+		def.Offset = expiryHeader
+	}
+	return def
+}
+
+// validate checks the settings that do not depend on the replication mode
+func (s Settings) validate() error {
+	if !validName.MatchString(s.Name) {
+		return fmt.Errorf("%w: names are 1 to 64 lowercase letters, digits, '-' or '_'", ErrInvalidSettings)
+	}
+	if s.DefaultTTL < 0 || s.MaxValueSize < 0 || s.ReplicationFactor < 0 {
+		return fmt.Errorf("%w: default_ttl, max_value_size and replication_factor cannot be negative", ErrInvalidSettings)
+	}
+	switch s.Consistency {
+	case "", ConsistencyOne, ConsistencyQuorum, ConsistencyAll:
+	default:
+		return fmt.Errorf("%w: consistency must be one, quorum or all", ErrInvalidSettings)
+	}
+	seen := make(map[string]bool, len(s.Indexes))
+	for _, index := range s.Indexes {
+		if err := index.validate(); err != nil {
+			return err
+		}
+		if seen[index.Name] {
+			return fmt.Errorf("%w: index %q is declared twice", ErrInvalidSettings, index.Name)
+		}
+		seen[index.Name] = true
+	}
+	return nil
+}
+
+// TTL returns the time after its last write a key expires (0 = never)
+func (s Settings) TTL() time.Duration {
+	return time.Duration(s.DefaultTTL) * time.Second
+}
+
+// wrap returns the value stored for a key written at now
+// Namespaces with a default_ttl store the key's deadline in front of its
+// value (Unix milliseconds, big-endian), so every replica knows when it
+// expires, including after a restart or a failover.
+func (s Settings) wrap(value []byte, now time.Time) []byte {
+	if s.DefaultTTL <= 0 {
+		return value
+	}
+	stored := make([]byte, expiryHeader+len(value))
+	binary.BigEndian.PutUint64(stored, uint64(now.Add(s.TTL()).UnixMilli()))
+	copy(stored[expiryHeader:], value)
+	return stored
+}
+
+// unwrap returns a stored value without its deadline; expired is true once
+// the deadline has passed at now
+func (s Settings) unwrap(stored []byte, now time.Time) (value []byte, expired bool) {
+	if s.DefaultTTL <= 0 || len(stored) < expiryHeader {
+		return stored, false
+	}
+	deadline := time.UnixMilli(int64(binary.BigEndian.Uint64(stored)))
+	return stored[expiryHeader:], !now.Before(deadline)
+}
+
+// Quorums returns R and W for a key with n replicas at the namespace's
+// consistency level; ok is false if it has none
+func (s Settings) Quorums(n int) (r, w int, ok bool) {
+	switch s.Consistency {
+	case ConsistencyOne:
+		return 1, 1, true
+	case ConsistencyQuorum:
+		return n/2 + 1, n/2 + 1, true
+	case ConsistencyAll:
+		return n, n, true
+	}
+	return 0, 0, false
+}
+
+// Key returns the key a namespace's key is stored under
+func Key(name, key string) string {
+	return separator + name + separator + key
+}
+
+// IsRegistryKey returns true for the key holding the settings of every
+// namespace
+func IsRegistryKey(key string) bool {
+	return key == registryKey
+}
+
+// Split returns the namespace and the key a stored key belongs to
+// ok is false for keys of the default namespace.
+func Split(stored string) (name, key string, ok bool) {
+	if !strings.HasPrefix(stored, separator) {
+		return "", "", false
+	}
+	name, key, ok = strings.Cut(stored[len(separator):], separator)
+	return name, key, ok
+}
+
+// Registry keeps the settings of the namespaces
+// They are stored in one key written through the node's backend, so every
+// replication mode replicates them like its own data. Each node caches the
+// namespaces it has seen and loads the key again for names it does not
+// know. The indexes of every namespace are built in the node's store, so
+// replicated writes keep them up to date on every replica.
+type Registry struct {
+	store   *kvstore.Store
+	backend frontend.Backend
+	check   func(Settings) error // Checks the settings against the replication mode (nil = any)
+
+	mu         sync.Mutex
+	namespaces map[string]Settings
+	loaded     time.Time // Last load of the registry key
+	synced     int64     // Version of the local copy of the registry key last synced
+}
+
+// NewRegistry creates the namespace registry of a node's store and backend
+// check rejects settings the node's replication mode cannot apply.
+func NewRegistry(store *kvstore.Store, backend frontend.Backend, check func(Settings) error) *Registry {
+	return &Registry{
+		store:      store,
+		backend:    backend,
+		check:      check,
+		namespaces: make(map[string]Settings),
+	}
+}
+
+// Start builds the indexes of the namespaces in the local copy of the
+// registry key, now and whenever replication changes it
+// On startup the store's indexes are rebuilt once the key is replicated to
+// this node.
+func (r *Registry) Start() {
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			r.sync()
+			<-ticker.C
+		}
+	}()
+}
+
+// Create adds a namespace, returning ErrExists if the name is taken
+func (r *Registry) Create(settings Settings) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+	if r.check != nil {
+		if err := r.check(settings); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+		}
+	}
+
+	_, _, err := frontend.Update(r.backend, registryKey, func(current *kvstore.KeyValue) ([]byte, error) {
+		namespaces, err := decode(current)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := namespaces[settings.Name]; exists {
+			return nil, ErrExists
+		}
+		namespaces[settings.Name] = settings
+		return json.Marshal(namespaces)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.add(map[string]Settings{settings.Name: settings})
+	return nil
+}
+
+// CreateIndex adds an index to a namespace and returns its new settings
+func (r *Registry) CreateIndex(name string, index IndexSettings) (Settings, error) {
+	if err := index.validate(); err != nil {
+		return Settings{}, err
+	}
+
+	var settings Settings
+	_, _, err := frontend.Update(r.backend, registryKey, func(current *kvstore.KeyValue) ([]byte, error) {
+		namespaces, err := decode(current)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if settings, ok = namespaces[name]; !ok {
+			return nil, ErrNotFound
+		}
+		if _, exists := settings.Index(index.Name); exists {
+			return nil, ErrIndexExists
+		}
+		settings.Indexes = append(append([]IndexSettings{}, settings.Indexes...), index)
+		namespaces[name] = settings
+		return json.Marshal(namespaces)
+	})
+	if err != nil {
+		return Settings{}, err
+	}
+
+	r.add(map[string]Settings{name: settings})
+	return settings, nil
+}
+
+// Get returns the settings of a namespace, or ErrNotFound
+func (r *Registry) Get(name string) (Settings, error) {
+	r.mu.Lock()
+	settings, ok := r.namespaces[name]
+	r.mu.Unlock()
+	if ok {
+		return settings, nil
+	}
+
+	if err := r.load(false); err != nil {
+		return Settings{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if settings, ok := r.namespaces[name]; ok {
+		return settings, nil
+	}
+	return Settings{}, ErrNotFound
+}
+
+// List returns every namespace, sorted by name
+func (r *Registry) List() ([]Settings, error) {
+	if err := r.load(true); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Settings, 0, len(r.namespaces))
+	for _, settings := range r.namespaces {
+		list = append(list, settings)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Lookup returns the settings of the namespace a stored key belongs to
+// ok is false for keys of the default namespace, or if the namespace cannot
+// be loaded; the node's settings apply to them. A nil registry has no
+// namespaces.
+func (r *Registry) Lookup(key string) (Settings, bool) {
+	if r == nil {
+		return Settings{}, false
+	}
+	name, _, ok := Split(key)
+	if !ok {
+		return Settings{}, false
+	}
+	settings, err := r.Get(name)
+	return settings, err == nil
+}
+
+// load reads the registry key into the cache
+// Unless forced, it is read at most once per reloadInterval.
+func (r *Registry) load(force bool) error {
+	r.mu.Lock()
+	if !force && time.Since(r.loaded) < reloadInterval {
+		r.mu.Unlock()
+		return nil
+	}
+	r.loaded = time.Now()
+	r.mu.Unlock()
+
+	current, err := r.backend.Get(registryKey)
+	if err != nil {
+		return fmt.Errorf("failed to load namespaces: %w", err)
+	}
+	namespaces, err := decode(current)
+	if err != nil {
+		return err
+	}
+
+	r.add(namespaces)
+	return nil
+}
+
+// sync adds the namespaces of the local copy of the registry key, if it
+// changed since the last sync
+func (r *Registry) sync() {
+	current, exists := r.store.Get(registryKey)
+	if !exists || current.Deleted {
+		return
+	}
+	r.mu.Lock()
+	if current.Version == r.synced {
+		r.mu.Unlock()
+		return
+	}
+	r.synced = current.Version
+	r.mu.Unlock()
+
+	namespaces, err := decode(current)
+	if err != nil {
+		log.Printf("Failed to sync namespaces: %v", err)
+		return
+	}
+	r.add(namespaces)
+}
+
+// add caches namespaces and builds their indexes in the store
+// Namespaces are never removed and indexes only added, so the cache only
+// grows; settings read before an index was added are not kept.
+func (r *Registry) add(namespaces map[string]Settings) {
+	r.mu.Lock()
+	for name, settings := range namespaces {
+		if len(settings.Indexes) >= len(r.namespaces[name].Indexes) {
+			r.namespaces[name] = settings
+		}
+	}
+	r.mu.Unlock()
+
+	for _, settings := range namespaces {
+		for _, index := range settings.Indexes {
+			if err := r.store.AddIndex(settings.storeIndex(index)); err != nil {
+				log.Printf("Failed to build index %s of namespace %s: %v", index.Name, settings.Name, err)
+			}
+		}
+	}
+}
+
+// decode returns the namespaces stored in the registry key
+func decode(kv *kvstore.KeyValue) (map[string]Settings, error) {
+	namespaces := make(map[string]Settings)
+	if kv == nil {
+		return namespaces, nil
+	}
+	if err := json.Unmarshal(kv.Value, &namespaces); err != nil {
+		return nil, fmt.Errorf("failed to decode namespaces: %w", err)
+	}
+	return namespaces, nil
+}
@@ -0,0 +1,58 @@
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestWrapExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		ttl         int64
+		readAt      time.Duration
+		wantExpired bool
+	}{
+		{name: "no ttl", ttl: 0, readAt: time.Hour},
+		{name: "before the deadline", ttl: 60, readAt: 59 * time.Second},
+		{name: "at the deadline", ttl: 60, readAt: 60 * time.Second, wantExpired: true},
+		{name: "after the deadline", ttl: 60, readAt: time.Hour, wantExpired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Settings{Name: "s", DefaultTTL: tt.ttl}
+			stored := settings.wrap([]byte(`{"u":"x"}`), now)
+
+			value, expired := settings.unwrap(stored, now.Add(tt.readAt))
+			if string(value) != `{"u":"x"}` {
+				t.Fatalf("got value %q", value)
+			}
+			if expired != tt.wantExpired {
+				t.Fatalf("got expired %v, want %v", expired, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestIndexSkipsExpiryHeader(t *testing.T) {
+	settings := Settings{Name: "s", DefaultTTL: 60, Indexes: []IndexSettings{{Name: "u", Path: "u"}}}
+	store := kvstore.NewStore()
+	def := settings.storeIndex(settings.Indexes[0])
+	if err := store.AddIndex(def); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Set(Key("s", "a"), settings.wrap([]byte(`{"u":"x"}`), time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	matches, err := store.Query(def.Name, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Key != Key("s", "a") {
+		t.Fatalf("got %v, want key a", matches)
+	}
+}
@@ -0,0 +1,500 @@
+package namespace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// Query settings
+const (
+	queryTimeout = 5 * time.Second // Deadline of a query sent to another node
+	queryReads   = 16              // Matches read at once to check them
+)
+
+// Server serves the namespace admin endpoints and the resource API of keys
+// in a namespace, on top of a node's frontend backend
+type Server struct {
+	store    *kvstore.Store
+	backend  frontend.Backend
+	registry *Registry
+	maxValue int64 // The node's largest value (0 = unlimited)
+	expiries *frontend.Expiries
+	peers    func() []string // Other nodes queried for index matches (nil = every key is stored locally)
+	client   *http.Client
+}
+
+// NewServer creates the namespace endpoints of a node
+func NewServer(store *kvstore.Store, backend frontend.Backend, registry *Registry) *Server {
+	return &Server{
+		store:    store,
+		backend:  backend,
+		registry: registry,
+		maxValue: store.GetMaxValueSize(),
+		expiries: frontend.NewExpiries(backend),
+		client:   &http.Client{Timeout: queryTimeout},
+	}
+}
+
+// SetPeers makes queries also look up the indexes of other nodes, for modes
+// where a node does not store every key
+func (s *Server) SetPeers(peers func() []string) {
+	s.peers = peers
+}
+
+// CreateHandler handles POST /admin/namespaces, creating a namespace
+// ({"name": "team-a", "default_ttl": 3600, "max_value_size": 1024,
+// "replication_factor": 3, "consistency": "quorum"})
+func (s *Server) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	var settings Settings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.registry.Create(settings); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidSettings):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// ListHandler handles GET /admin/namespaces
+func (s *Server) ListHandler(w http.ResponseWriter, r *http.Request) {
+	namespaces, err := s.registry.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"namespaces": namespaces,
+	})
+}
+
+// NamespaceHandler handles GET /admin/namespaces/{namespace}
+func (s *Server) NamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// IndexCreateHandler handles POST /admin/namespaces/{namespace}/indexes,
+// adding a secondary index ({"name": "user_id", "path": "user_id"})
+func (s *Server) IndexCreateHandler(w http.ResponseWriter, r *http.Request) {
+	var index IndexSettings
+	if err := json.NewDecoder(r.Body).Decode(&index); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := s.registry.CreateIndex(mux.Vars(r)["namespace"], index)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidSettings):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrIndexExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// QueryHandler handles GET /ns/{namespace}/query?index=...&value=...,
+// returning the keys whose indexed field has the value
+// Matches are found in the indexes of this node (and, with peers, of the
+// others), then read like a GET so that results have the namespace's
+// consistency. With local=true only this node's index is used, without
+// reading the matches again.
+func (s *Server) QueryHandler(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	name, value := query.Get("index"), query.Get("value")
+	if name == "" || !query.Has("value") {
+		http.Error(w, "index and value parameters are required", http.StatusBadRequest)
+		return
+	}
+	index, ok := settings.Index(name)
+	if !ok {
+		// The index may have been added since the namespace was cached
+		s.registry.sync()
+		settings, _ = s.registry.Get(settings.Name)
+		if index, ok = settings.Index(name); !ok {
+			http.Error(w, ErrIndexNotFound.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	// Build the index here if the registry key has not been synced yet
+	storeIndex := settings.storeIndex(index)
+	if err := s.store.AddIndex(storeIndex); err != nil {
+		writeError(w, err)
+		return
+	}
+	matches, err := s.store.Query(storeIndex.Name, value)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if query.Get("local") == "true" {
+		matches = unexpired(settings, matches)
+	} else {
+		candidates := make(map[string]bool, len(matches))
+		for _, kv := range matches {
+			candidates[kv.Key] = true
+		}
+		if s.peers != nil {
+			for _, key := range s.queryPeers(settings.Name, name, value) {
+				candidates[Key(settings.Name, key)] = true
+			}
+		}
+		if matches, err = s.check(settings, candidates, index.Path, value); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	results := make([]map[string]interface{}, len(matches))
+	for i, kv := range matches {
+		_, key, _ := Split(kv.Key)
+		results[i] = map[string]interface{}{
+			"key":     key,
+			"value":   string(kv.Value),
+			"version": kv.Version,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"index":   name,
+		"value":   value,
+		"results": results,
+	})
+}
+
+// queryPeers returns the keys matching a query in the indexes of the other
+// nodes; nodes that do not reply are skipped, as the keys they store have
+// other replicas
+func (s *Server) queryPeers(namespace, index, value string) []string {
+	params := url.Values{"index": {index}, "value": {value}, "local": {"true"}}.Encode()
+	peers := s.peers()
+
+	replies := make(chan []string, len(peers))
+	for _, addr := range peers {
+		go func(addr string) {
+			keys, err := s.queryPeer(fmt.Sprintf("http://%s/ns/%s/query?%s", addr, namespace, params))
+			if err != nil {
+				keys = nil
+			}
+			replies <- keys
+		}(addr)
+	}
+
+	var keys []string
+	for range peers {
+		keys = append(keys, <-replies...)
+	}
+	return keys
+}
+
+// queryPeer returns the keys of a local query sent to another node
+func (s *Server) queryPeer(url string) ([]string, error) {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	var reply struct {
+		Results []struct {
+			Key string `json:"key"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	keys := make([]string, len(reply.Results))
+	for i, result := range reply.Results {
+		keys[i] = result.Key
+	}
+	return keys, nil
+}
+
+// check reads candidate keys through the backend and returns those whose
+// current value still matches, sorted by key
+// An index may be behind or ahead of the value a read returns, so each
+// match is checked again against the value read.
+func (s *Server) check(settings Settings, candidates map[string]bool, path, value string) ([]*kvstore.KeyValue, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		matches []*kvstore.KeyValue
+		readErr error
+	)
+	sem := make(chan struct{}, queryReads)
+	for key := range candidates {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer func() { <-sem; wg.Done() }()
+
+			kv, err := s.get(settings, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				readErr = err
+				return
+			}
+			if kv == nil {
+				return
+			}
+			if current, ok := kvstore.IndexValue(kv.Value, path); ok && current == value {
+				matches = append(matches, &kvstore.KeyValue{Key: key, Value: kv.Value, Version: kv.Version})
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Key < matches[j].Key })
+	return matches, nil
+}
+
+// unexpired returns the stored values of index matches without their
+// deadline, dropping those that have expired
+func unexpired(settings Settings, matches []*kvstore.KeyValue) []*kvstore.KeyValue {
+	now := time.Now()
+	kept := matches[:0]
+	for _, kv := range matches {
+		value, expired := settings.unwrap(kv.Value, now)
+		if expired {
+			continue
+		}
+		kv.Value = value
+		kept = append(kept, kv)
+	}
+	return kept
+}
+
+// get reads a key of a namespace, returning nil if it does not exist or has
+// expired
+// Expired keys are deleted when a read finds them, in case the node that
+// took their last write lost its timer.
+func (s *Server) get(settings Settings, stored string) (*kvstore.KeyValue, error) {
+	kv, err := s.backend.Get(stored)
+	if err != nil || kv == nil {
+		return nil, err
+	}
+	value, expired := settings.unwrap(kv.Value, time.Now())
+	if expired {
+		go s.expire(stored, kv.Version)
+		return nil, nil
+	}
+	return &kvstore.KeyValue{Key: kv.Key, Value: value, Version: kv.Version}, nil
+}
+
+// expire deletes an expired key, unless it was written since
+// Nodes that do not take writes leave it to the others.
+func (s *Server) expire(stored string, version int64) {
+	err := s.backend.Delete(stored, rest.Preconditions{IfMatch: rest.ETag(version)})
+	if err != nil && !errors.Is(err, rest.ErrPreconditionFailed) && !errors.Is(err, frontend.ErrReadOnly) {
+		log.Printf("Failed to expire key %q: %v", stored, err)
+	}
+}
+
+// preconditions returns the preconditions of a write as they apply to the
+// stored key, for which an expired value counts as missing
+func (s *Server) preconditions(settings Settings, stored string, preconditions rest.Preconditions) (rest.Preconditions, error) {
+	if settings.DefaultTTL <= 0 || (preconditions.IfMatch == "" && preconditions.IfNoneMatch == "") {
+		return preconditions, nil
+	}
+	kv, err := s.backend.Get(stored)
+	if err != nil || kv == nil {
+		return preconditions, err
+	}
+	if _, expired := settings.unwrap(kv.Value, time.Now()); !expired {
+		return preconditions, nil
+	}
+	if preconditions.IfMatch != "" {
+		return preconditions, rest.ErrPreconditionFailed
+	}
+	// Replace the expired value, unless it is written concurrently
+	return rest.Preconditions{IfMatch: rest.ETag(kv.Version)}, nil
+}
+
+// KeyGetHandler handles GET /ns/{namespace}/keys/{key}
+// Conditional like the default namespace's GET /v1/keys/{key}
+func (s *Server) KeyGetHandler(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+	key := mux.Vars(r)["key"]
+
+	kv, err := s.get(settings, Key(settings.Name, key))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if status := rest.ParsePreconditions(r).ReadStatus(kv); status != 0 {
+		rest.WriteStatus(w, status, kv)
+		return
+	}
+	if kv == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+
+	// Answer with the key the client knows
+	rest.WriteValue(w, &kvstore.KeyValue{Key: key, Value: kv.Value, Version: kv.Version})
+}
+
+// KeyPutHandler handles PUT /ns/{namespace}/keys/{key}
+// Values are limited to the namespace's max_value_size, and expire after
+// its default_ttl from their last write.
+func (s *Server) KeyPutHandler(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+	key := mux.Vars(r)["key"]
+
+	max := s.maxValue
+	if max > 0 && settings.DefaultTTL > 0 {
+		max -= expiryHeader // The deadline is stored with the value
+	}
+	if settings.MaxValueSize > 0 && (max <= 0 || settings.MaxValueSize < max) {
+		max = settings.MaxValueSize
+	}
+	value, err := rest.ReadValue(w, r, max)
+	if err != nil {
+		if errors.Is(err, blob.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// JSON values are not limited while they are read
+	if max > 0 && int64(len(value)) > max {
+		http.Error(w, kvstore.ErrValueTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	stored := Key(settings.Name, key)
+	preconditions, err := s.preconditions(settings, stored, rest.ParsePreconditions(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	version, err := s.backend.Set(stored, settings.wrap(value, time.Now()), preconditions)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.expiries.Set(stored, version, settings.TTL())
+
+	rest.WriteStored(w, key, version)
+}
+
+// KeyDeleteHandler handles DELETE /ns/{namespace}/keys/{key}
+// Conditional like KeyPutHandler
+func (s *Server) KeyDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	settings, ok := s.namespace(w, r)
+	if !ok {
+		return
+	}
+
+	stored := Key(settings.Name, mux.Vars(r)["key"])
+	preconditions, err := s.preconditions(settings, stored, rest.ParsePreconditions(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.backend.Delete(stored, preconditions); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.expiries.Clear(stored)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// namespace returns the settings of the request's namespace, answering
+// with 404 if it does not exist
+func (s *Server) namespace(w http.ResponseWriter, r *http.Request) (Settings, bool) {
+	settings, err := s.registry.Get(mux.Vars(r)["namespace"])
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return Settings{}, false
+		}
+		writeError(w, err)
+		return Settings{}, false
+	}
+	return settings, true
+}
+
+// writeError answers a failed request with the status matching a backend
+// error
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, frontend.ErrReadOnly):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, frontend.ErrCausal):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, frontend.ErrContention):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		rest.WriteError(w, err)
+	}
+}
@@ -0,0 +1,97 @@
+package numeric
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Errors returned when a value cannot be incremented
+var (
+	ErrNotInteger = errors.New("value is not an integer")
+	ErrOverflow   = errors.New("increment or decrement would overflow")
+)
+
+// Op adds Delta to a key's integer value
+// A key that does not exist starts at Initial, so the first increment
+// stores Initial + Delta.
+type Op struct {
+	Key     string `json:"key"`
+	Delta   int64  `json:"delta"`
+	Initial int64  `json:"initial"`
+}
+
+// Decode returns the operation of an /incr (or, with decr, /decr) request
+// body ({"key": "a", "delta": 5, "initial": 0})
+// The delta defaults to 1 and is negated for /decr; the initial value
+// defaults to 0.
+func Decode(r *http.Request, decr bool) (Op, error) {
+	var req struct {
+		Key     string `json:"key"`
+		Delta   *int64 `json:"delta"`
+		Initial int64  `json:"initial"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return Op{}, errors.New("Invalid request body")
+	}
+	if err := kvstore.CheckKey(req.Key); err != nil {
+		return Op{}, err
+	}
+
+	op := Op{Key: req.Key, Delta: 1, Initial: req.Initial}
+	if req.Delta != nil {
+		op.Delta = *req.Delta
+	}
+	if decr {
+		if op.Delta == math.MinInt64 {
+			return Op{}, ErrOverflow
+		}
+		op.Delta = -op.Delta
+	}
+	return op, nil
+}
+
+// Apply returns the key's new value, as a kvstore.UpdateFunc
+func (op Op) Apply(current *kvstore.KeyValue) ([]byte, error) {
+	n := op.Initial
+	if current != nil {
+		if current.CRDT != nil {
+			return nil, kvstore.ErrCRDTKey
+		}
+		var err error
+		if n, err = Parse(current.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if (op.Delta > 0 && n > math.MaxInt64-op.Delta) || (op.Delta < 0 && n < math.MinInt64-op.Delta) {
+		return nil, ErrOverflow
+	}
+	return []byte(strconv.FormatInt(n+op.Delta, 10)), nil
+}
+
+// Parse returns the integer stored in a value (a base 10 64-bit integer)
+func Parse(value []byte) (int64, error) {
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return n, nil
+}
+
+// WriteResult writes the response of an increment: the key's new value (as
+// a number) and version
+func WriteResult(w http.ResponseWriter, kv *kvstore.KeyValue) {
+	n, _ := Parse(kv.Value)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     kv.Key,
+		"value":   n,
+		"version": kv.Version,
+	})
+}
@@ -0,0 +1,76 @@
+package numeric
+
+import (
+	"errors"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		current *kvstore.KeyValue // nil if the key does not exist
+		op      Op
+		want    string
+		wantErr error
+	}{
+		{name: "missing key starts at initial", op: Op{Delta: 1, Initial: 10}, want: "11"},
+		{name: "increment", current: value("41"), op: Op{Delta: 1}, want: "42"},
+		{name: "decrement below zero", current: value("1"), op: Op{Delta: -3}, want: "-2"},
+		{name: "up to the maximum", current: value("9223372036854775806"), op: Op{Delta: 1}, want: "9223372036854775807"},
+		{name: "past the maximum", current: value("9223372036854775807"), op: Op{Delta: 1}, wantErr: ErrOverflow},
+		{name: "large delta past the maximum", current: value("1"), op: Op{Delta: math.MaxInt64}, wantErr: ErrOverflow},
+		{name: "down to the minimum", current: value("-9223372036854775807"), op: Op{Delta: -1}, want: "-9223372036854775808"},
+		{name: "past the minimum", current: value("-9223372036854775808"), op: Op{Delta: -1}, wantErr: ErrOverflow},
+		{name: "initial past the maximum", op: Op{Delta: 2, Initial: math.MaxInt64 - 1}, wantErr: ErrOverflow},
+		{name: "not an integer", current: value("abc"), op: Op{Delta: 1}, wantErr: ErrNotInteger},
+		{name: "too large to be an integer", current: value("9223372036854775808"), op: Op{Delta: 1}, wantErr: ErrNotInteger},
+		{name: "CRDT key", current: &kvstore.KeyValue{CRDT: &kvstore.CRDT{}}, op: Op{Delta: 1}, wantErr: kvstore.ErrCRDTKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op.Apply(tt.current)
+			if !errors.Is(err, tt.wantErr) || string(got) != tt.want {
+				t.Fatalf("got %q (%v), want %q (%v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		decr    bool
+		want    Op
+		wantErr bool
+	}{
+		{name: "default delta", body: `{"key": "k"}`, want: Op{Key: "k", Delta: 1}},
+		{name: "delta and initial", body: `{"key": "k", "delta": 5, "initial": -2}`, want: Op{Key: "k", Delta: 5, Initial: -2}},
+		{name: "zero delta", body: `{"key": "k", "delta": 0}`, want: Op{Key: "k"}},
+		{name: "decrement", body: `{"key": "k", "delta": 5}`, decr: true, want: Op{Key: "k", Delta: -5}},
+		{name: "decrement of a negative delta", body: `{"key": "k", "delta": -5}`, decr: true, want: Op{Key: "k", Delta: 5}},
+		{name: "decrement by the minimum", body: `{"key": "k", "delta": -9223372036854775808}`, decr: true, wantErr: true},
+		{name: "delta too large", body: `{"key": "k", "delta": 9223372036854775808}`, wantErr: true},
+		{name: "missing key", body: `{"delta": 1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := Decode(httptest.NewRequest("POST", "/incr", strings.NewReader(tt.body)), tt.decr)
+			if (err != nil) != tt.wantErr || (err == nil && op != tt.want) {
+				t.Fatalf("got %+v (%v), want %+v (error %v)", op, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// value returns a stored plain value
+func value(s string) *kvstore.KeyValue {
+	return &kvstore.KeyValue{Key: "k", Value: []byte(s), Version: 1}
+}
@@ -0,0 +1,423 @@
+package resp
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+)
+
+// command is a supported command taking minArgs to maxArgs arguments
+// (maxArgs -1 = no limit)
+type command struct {
+	minArgs int
+	maxArgs int
+	run     func(s *Server, w writer, args [][]byte)
+}
+
+// commands maps lower-case command names to their implementation
+var commands = map[string]command{
+	"ping":   {0, 1, (*Server).ping},
+	"get":    {1, 1, (*Server).get},
+	"set":    {2, -1, (*Server).set},
+	"del":    {1, -1, (*Server).del},
+	"exists": {1, -1, (*Server).exists},
+	"mget":   {1, -1, (*Server).mget},
+	"mset":   {2, -1, (*Server).mset},
+	"incr":   {1, 1, (*Server).incr},
+	"expire": {2, 2, (*Server).expire},
+	"scan":   {1, -1, (*Server).scan},
+}
+
+// ping replies PONG, or echoes its argument
+func (s *Server) ping(w writer, args [][]byte) {
+	if len(args) == 0 {
+		w.simple("PONG")
+		return
+	}
+	w.bulk(args[0])
+}
+
+// get replies with a key's value, or null if it does not exist
+func (s *Server) get(w writer, args [][]byte) {
+	kv, err := s.backend.Get(string(args[0]))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.bulk(value(kv))
+}
+
+// set writes a key: SET key value [NX|XX] [EX seconds|PX milliseconds]
+// NX and XX reply null if the key does (or does not) exist.
+func (s *Server) set(w writer, args [][]byte) {
+	key := string(args[0])
+	var preconditions rest.Preconditions
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToLower(string(args[i])); {
+		case option == "nx" && preconditions.Empty():
+			preconditions.IfNoneMatch = "*"
+		case option == "xx" && preconditions.Empty():
+			preconditions.IfMatch = "*"
+		case (option == "ex" || option == "px") && ttl == 0 && i+1 < len(args):
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil || n <= 0 {
+				w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if option == "ex" {
+				ttl *= 1000
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	version, err := s.backend.Set(key, args[1], preconditions)
+	if errors.Is(err, rest.ErrPreconditionFailed) {
+		w.bulk(nil)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.expiries.Set(key, version, ttl)
+	w.simple("OK")
+}
+
+// del deletes keys and replies with the number that existed
+func (s *Server) del(w writer, args [][]byte) {
+	var deleted int64
+	for _, arg := range args {
+		key := string(arg)
+		kv, err := s.backend.Get(key)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if kv == nil {
+			continue
+		}
+		if err := s.backend.Delete(key, rest.Preconditions{}); err != nil {
+			writeError(w, err)
+			return
+		}
+		s.expiries.Clear(key)
+		deleted++
+	}
+	w.integer(deleted)
+}
+
+// exists replies with the number of the keys that exist
+func (s *Server) exists(w writer, args [][]byte) {
+	var count int64
+	for _, arg := range args {
+		kv, err := s.backend.Get(string(arg))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if kv != nil {
+			count++
+		}
+	}
+	w.integer(count)
+}
+
+// mget replies with the values of several keys (null for missing keys)
+func (s *Server) mget(w writer, args [][]byte) {
+	values := make([][]byte, len(args))
+	for i, arg := range args {
+		kv, err := s.backend.Get(string(arg))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		values[i] = value(kv)
+	}
+
+	w.array(len(values))
+	for _, v := range values {
+		w.bulk(v)
+	}
+}
+
+// mset writes several keys, one after the other
+func (s *Server) mset(w writer, args [][]byte) {
+	if len(args)%2 != 0 {
+		w.error("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+	for i := 0; i < len(args); i += 2 {
+		key := string(args[i])
+		if _, err := s.backend.Set(key, args[i+1], rest.Preconditions{}); err != nil {
+			writeError(w, err)
+			return
+		}
+		s.expiries.Clear(key)
+	}
+	w.simple("OK")
+}
+
+// incr adds one to an integer value (a missing key counts as 0) without
+// losing concurrent increments
+func (s *Server) incr(w writer, args [][]byte) {
+	key := string(args[0])
+	errNotInteger := errors.New("value is not an integer or out of range")
+	errOverflow := errors.New("increment or decrement would overflow")
+
+	var n int64
+	previous, updated, err := frontend.Update(s.backend, key, func(current *kvstore.KeyValue) ([]byte, error) {
+		n = 0
+		if current != nil {
+			var err error
+			if n, err = strconv.ParseInt(string(current.Value), 10, 64); err != nil {
+				return nil, errNotInteger
+			}
+		}
+		if n == math.MaxInt64 {
+			return nil, errOverflow
+		}
+		n++
+		return []byte(strconv.FormatInt(n, 10)), nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if previous != nil {
+		s.expiries.Move(key, previous.Version, updated.Version)
+	}
+	w.integer(n)
+}
+
+// expire gives a key a timeout in seconds and replies 1, or 0 if the key
+// does not exist
+// The timeout is kept by this node (and lost if it restarts); writing the
+// key from anywhere before it passes clears it.
+func (s *Server) expire(w writer, args [][]byte) {
+	key := string(args[0])
+	seconds, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		w.error("ERR value is not an integer or out of range")
+		return
+	}
+
+	kv, err := s.backend.Get(key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if kv == nil {
+		w.integer(0)
+		return
+	}
+
+	if seconds <= 0 {
+		err := s.backend.Delete(key, rest.Preconditions{IfMatch: rest.ETag(kv.Version)})
+		if errors.Is(err, rest.ErrPreconditionFailed) {
+			w.integer(0)
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		s.expiries.Clear(key)
+		w.integer(1)
+		return
+	}
+
+	s.expiries.Set(key, kv.Version, time.Duration(seconds)*time.Second)
+	w.integer(1)
+}
+
+// scan iterates over the keys stored on this node:
+// SCAN cursor [MATCH pattern] [COUNT count]
+// Keys are visited in the order of their hashes and the cursor is the hash
+// of the next key, so keys that exist for the whole iteration are returned
+// even if others are added or deleted in between. Like Redis Cluster, each
+// node only lists its own keys.
+func (s *Server) scan(w writer, args [][]byte) {
+	cursor, err := strconv.ParseUint(string(args[0]), 10, 64)
+	if err != nil {
+		w.error("ERR invalid cursor")
+		return
+	}
+
+	pattern := ""
+	count := 10
+	for i := 1; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			w.error("ERR syntax error")
+			return
+		}
+		switch strings.ToLower(string(args[i])) {
+		case "match":
+			pattern = string(args[i+1])
+		case "count":
+			count, err = strconv.Atoi(string(args[i+1]))
+			if err != nil || count < 1 {
+				w.error("ERR syntax error")
+				return
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	type hashedKey struct {
+		hash uint64
+		key  string
+	}
+	var keys []hashedKey
+	for _, key := range s.store.Keys() {
+		if strings.HasPrefix(key, kvstore.ReservedPrefix) {
+			continue
+		}
+		if h := keyHash(key); h >= cursor {
+			keys = append(keys, hashedKey{h, key})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].hash != keys[j].hash {
+			return keys[i].hash < keys[j].hash
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	var next uint64
+	if len(keys) > count {
+		next = keys[count].hash
+		keys = keys[:count]
+	}
+
+	var found []string
+	for _, k := range keys {
+		if pattern != "" && !match(pattern, k.key) {
+			continue
+		}
+		if _, exists := s.store.LocalRead(k.key); exists {
+			found = append(found, k.key)
+		}
+	}
+
+	w.array(2)
+	w.bulk([]byte(strconv.FormatUint(next, 10)))
+	w.array(len(found))
+	for _, key := range found {
+		w.bulk([]byte(key))
+	}
+}
+
+// value returns the value of a read, or nil if the key does not exist
+func value(kv *kvstore.KeyValue) []byte {
+	if kv == nil {
+		return nil
+	}
+	if kv.Value == nil {
+		return []byte{}
+	}
+	return kv.Value
+}
+
+// keyHash orders keys for SCAN
+// Hashes start at 1, as cursor 0 ends an iteration.
+func keyHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	if sum := h.Sum64(); sum != 0 {
+		return sum
+	}
+	return 1
+}
+
+// match reports whether a key matches a Redis glob pattern: * matches any
+// characters, ? one character, [abc] and [a-z] one of a set ([^...]
+// negates it) and \ escapes the next character
+func match(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if match(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				// An unterminated set matches a literal '['
+				if key[0] != '[' {
+					return false
+				}
+				break
+			}
+			set := pattern[1 : end+1]
+			negate := len(set) > 0 && set[0] == '^'
+			if negate {
+				set = set[1:]
+			}
+			if inSet(set, key[0]) == negate {
+				return false
+			}
+			pattern = pattern[end+1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		key = key[1:]
+	}
+	return len(key) == 0
+}
+
+// inSet reports whether c is in a glob character set like "abc" or "a-z"
+func inSet(set string, c byte) bool {
+	for i := 0; i < len(set); i++ {
+		if i+2 < len(set) && set[i+1] == '-' {
+			if set[i] <= c && c <= set[i+2] {
+				return true
+			}
+			i += 2
+			continue
+		}
+		if set[i] == c {
+			return true
+		}
+	}
+	return false
+}
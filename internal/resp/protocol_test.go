@@ -0,0 +1,55 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "array", input: "*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", want: []string{"GET", "k"}},
+		{name: "inline", input: "SET k v\r\n", want: []string{"SET", "k", "v"}},
+		{name: "empty line", input: "\r\n", want: nil},
+		{name: "empty array", input: "*0\r\n", want: nil},
+		{name: "null array", input: "*-1\r\n", want: nil},
+		{name: "negative count", input: "*-5\r\n", want: nil},
+		{name: "oversized count", input: "*1048577\r\n", wantErr: true},
+		{name: "huge count", input: "*9223372036854775807\r\n", wantErr: true},
+		{name: "count not a number", input: "*x\r\n", wantErr: true},
+		{name: "negative bulk length", input: "*1\r\n$-1\r\n", wantErr: true},
+		{name: "oversized bulk length", input: "*1\r\n$1025\r\n", wantErr: true},
+		{name: "missing bulk header", input: "*1\r\nGET\r\n", wantErr: true},
+		{name: "unterminated bulk", input: "*1\r\n$3\r\nGETX\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := readCommand(bufio.NewReader(strings.NewReader(tt.input)), 1024)
+			if tt.wantErr {
+				var perr protocolError
+				if !errors.As(err, &perr) {
+					t.Fatalf("got args %q, err %v; want a protocol error", args, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(args) != len(tt.want) {
+				t.Fatalf("got %q, want %q", args, tt.want)
+			}
+			for i := range args {
+				if string(args[i]) != tt.want[i] {
+					t.Fatalf("got %q, want %q", args, tt.want)
+				}
+			}
+		})
+	}
+}
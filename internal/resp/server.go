@@ -0,0 +1,119 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+// Server serves a subset of the Redis protocol (RESP) over TCP
+type Server struct {
+	store    *kvstore.Store // This node's store, listed by SCAN
+	backend  frontend.Backend
+	maxBulk  int64
+	expiries *frontend.Expiries // Keys given a timeout through this server
+}
+
+// NewServer creates a server for a node's store and backend
+func NewServer(store *kvstore.Store, backend frontend.Backend) *Server {
+	maxBulk := store.GetMaxValueSize()
+	if maxBulk <= 0 {
+		maxBulk = defaultMaxBulk
+	}
+	backend = frontend.Public(backend)
+	return &Server{
+		store:    store,
+		backend:  backend,
+		maxBulk:  maxBulk,
+		expiries: frontend.NewExpiries(backend),
+	}
+}
+
+// ListenAndServe accepts RESP connections on addr
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts RESP connections on a listener
+func (s *Server) Serve(listener net.Listener) error {
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn runs the commands of one client until it disconnects
+// Replies are flushed once no more pipelined commands are buffered.
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReaderSize(conn, maxInlineSize)
+	w := writer{bufio.NewWriter(conn)}
+
+	for {
+		args, err := readCommand(r, s.maxBulk)
+		if err != nil {
+			var perr protocolError
+			if errors.As(err, &perr) {
+				w.error("ERR " + perr.Error())
+				w.Flush()
+			} else if err != io.EOF {
+				log.Printf("RESP connection from %s failed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(string(args[0]))
+		if name == "quit" {
+			w.simple("OK")
+			w.Flush()
+			return
+		}
+		s.run(w, name, args[1:])
+
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// run executes one command
+func (s *Server) run(w writer, name string, args [][]byte) {
+	cmd, ok := commands[name]
+	if !ok {
+		w.error("ERR unknown command '" + name + "'")
+		return
+	}
+	if len(args) < cmd.minArgs || (cmd.maxArgs >= 0 && len(args) > cmd.maxArgs) {
+		w.error("ERR wrong number of arguments for '" + name + "' command")
+		return
+	}
+	cmd.run(s, w, args)
+}
+
+// writeError replies with a backend error
+func writeError(w writer, err error) {
+	if errors.Is(err, frontend.ErrReadOnly) {
+		w.error("READONLY You can't write against a read only replica.")
+		return
+	}
+	w.error("ERR " + err.Error())
+}
@@ -0,0 +1,225 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/numeric"
+)
+
+// ErrPreconditionFailed is returned when an If-Match or If-None-Match header
+// of a write does not hold for the key's current value
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Conditional request headers, forwarded along with the requests that
+// carry them
+var ConditionalHeaders = []string{"If-Match", "If-None-Match"}
+
+// ETag returns the entity tag of a version
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Preconditions are the conditional headers of a request
+type Preconditions struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// ParsePreconditions returns the conditional headers of a request
+func ParsePreconditions(r *http.Request) Preconditions {
+	return Preconditions{
+		IfMatch:     r.Header.Get("If-Match"),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+	}
+}
+
+// Empty returns true if the request is unconditional
+func (p Preconditions) Empty() bool {
+	return p.IfMatch == "" && p.IfNoneMatch == ""
+}
+
+// SetHeaders sets the conditional headers of a request carrying the
+// preconditions
+func (p Preconditions) SetHeaders(header http.Header) {
+	if p.IfMatch != "" {
+		header.Set("If-Match", p.IfMatch)
+	}
+	if p.IfNoneMatch != "" {
+		header.Set("If-None-Match", p.IfNoneMatch)
+	}
+}
+
+// Check returns ErrPreconditionFailed unless the current value of a key
+// (nil if it does not exist) satisfies the preconditions of a write
+func (p Preconditions) Check(current *kvstore.KeyValue) error {
+	if p.IfMatch != "" && !matches(p.IfMatch, current, false) {
+		return ErrPreconditionFailed
+	}
+	if p.IfNoneMatch != "" && matches(p.IfNoneMatch, current, true) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// Condition returns the preconditions as a store condition, or nil if the
+// request is unconditional
+func (p Preconditions) Condition() kvstore.Condition {
+	if p.Empty() {
+		return nil
+	}
+	return p.Check
+}
+
+// ReadStatus returns the status a read of the current value (nil if the key
+// does not exist) answers with instead of the value: 412 Precondition
+// Failed if If-Match does not hold, 304 Not Modified if If-None-Match
+// matches, or 0 to return the value
+func (p Preconditions) ReadStatus(current *kvstore.KeyValue) int {
+	if p.IfMatch != "" && !matches(p.IfMatch, current, false) {
+		return http.StatusPreconditionFailed
+	}
+	if p.IfNoneMatch != "" && matches(p.IfNoneMatch, current, true) {
+		return http.StatusNotModified
+	}
+	return 0
+}
+
+// matches returns true if a header's entity tags include the current
+// value's ("*" matches any existing value)
+// Weak comparison ignores the W/ prefix, as If-None-Match requires; If-Match
+// never matches a weak tag.
+func matches(header string, current *kvstore.KeyValue, weak bool) bool {
+	if current == nil {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag := ETag(current.Version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadValue reads the value of a PUT: the raw body if it is sent as
+// application/octet-stream, otherwise a JSON object {"value": "..."}
+func ReadValue(w http.ResponseWriter, r *http.Request, max int64) ([]byte, error) {
+	if blob.IsRaw(r) {
+		return blob.ReadValue(w, r, max)
+	}
+
+	var req struct {
+		Value *string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	if req.Value == nil {
+		return nil, errors.New("value is required")
+	}
+	return []byte(*req.Value), nil
+}
+
+// WriteValue writes a key's value as the response of a read
+func WriteValue(w http.ResponseWriter, kv *kvstore.KeyValue) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", ETag(kv.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     kv.Key,
+		"value":   string(kv.Value),
+		"version": kv.Version,
+	})
+}
+
+// WriteStatus answers a read with a status from ReadStatus
+func WriteStatus(w http.ResponseWriter, status int, current *kvstore.KeyValue) {
+	if status == http.StatusNotModified {
+		w.Header().Set("ETag", ETag(current.Version))
+		w.WriteHeader(status)
+		return
+	}
+	http.Error(w, ErrPreconditionFailed.Error(), status)
+}
+
+// WriteStored writes the response of a successful PUT
+func WriteStored(w http.ResponseWriter, key string, version int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", ETag(version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     key,
+		"version": version,
+	})
+}
+
+// ValidKey answers 400 and returns false if a client cannot use a key:
+// an empty key, or one starting with kvstore.ReservedPrefix
+func ValidKey(w http.ResponseWriter, key string) bool {
+	if err := kvstore.CheckKey(key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// WriteError answers a failed write with the status matching its error
+// Errors that are not specific to a replication mode map to 400, 409, 412
+// or 413; anything else is a 500.
+func WriteError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, kvstore.ErrValueTooLarge), errors.Is(err, blob.ErrTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, kvstore.ErrCRDTKey), errors.Is(err, numeric.ErrNotInteger), errors.Is(err, numeric.ErrOverflow):
+		status = http.StatusConflict
+	case errors.Is(err, kvstore.ErrEmptyKey), errors.Is(err, kvstore.ErrReservedKey):
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// ReadStored reads the response of a PUT or DELETE sent to another node's
+// resource API and returns the version written (0 for a DELETE)
+// A 412 response returns ErrPreconditionFailed.
+func ReadStored(resp *http.Response) (int64, error) {
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var stored struct {
+			Version int64 `json:"version"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&stored); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		return stored.Version, nil
+	case http.StatusNoContent:
+		return 0, nil
+	case http.StatusPreconditionFailed:
+		return 0, ErrPreconditionFailed
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+)
+
+func TestPreconditions(t *testing.T) {
+	current := &kvstore.KeyValue{Key: "k", Value: []byte("v"), Version: 7}
+
+	tests := []struct {
+		name       string
+		pre        Preconditions
+		current    *kvstore.KeyValue
+		wantWrite  bool // Check accepts the write
+		wantStatus int  // ReadStatus
+	}{
+		{name: "unconditional", current: current, wantWrite: true},
+		{name: "If-Match the current version", pre: Preconditions{IfMatch: `"7"`}, current: current, wantWrite: true},
+		{name: "If-Match another version", pre: Preconditions{IfMatch: `"6"`}, current: current, wantStatus: http.StatusPreconditionFailed},
+		{name: "If-Match one of a list", pre: Preconditions{IfMatch: `"5", "7"`}, current: current, wantWrite: true},
+		{name: "If-Match never matches a weak tag", pre: Preconditions{IfMatch: `W/"7"`}, current: current, wantStatus: http.StatusPreconditionFailed},
+		{name: "If-Match * with a value", pre: Preconditions{IfMatch: "*"}, current: current, wantWrite: true},
+		{name: "If-Match * without a value", pre: Preconditions{IfMatch: "*"}, wantStatus: http.StatusPreconditionFailed},
+		{name: "If-None-Match * without a value", pre: Preconditions{IfNoneMatch: "*"}, wantWrite: true},
+		{name: "If-None-Match * with a value", pre: Preconditions{IfNoneMatch: "*"}, current: current, wantStatus: http.StatusNotModified},
+		{name: "If-None-Match the current version", pre: Preconditions{IfNoneMatch: `"7"`}, current: current, wantStatus: http.StatusNotModified},
+		{name: "If-None-Match a weak tag", pre: Preconditions{IfNoneMatch: `W/"7"`}, current: current, wantStatus: http.StatusNotModified},
+		{name: "If-None-Match another version", pre: Preconditions{IfNoneMatch: `"6"`}, current: current, wantWrite: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pre.Check(tt.current)
+			if tt.wantWrite && err != nil {
+				t.Fatalf("got %v, want the write accepted", err)
+			}
+			if !tt.wantWrite && !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("got %v, want %v", err, ErrPreconditionFailed)
+			}
+			if status := tt.pre.ReadStatus(tt.current); status != tt.wantStatus {
+				t.Fatalf("got read status %d, want %d", status, tt.wantStatus)
+			}
+			if (tt.pre.Condition() == nil) != tt.pre.Empty() {
+				t.Fatal("an unconditional request must have no condition")
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// preface starts every HTTP/2 connection; gRPC clients use HTTP/2 without
+// TLS, so it tells their connections apart from HTTP/1.1 ones
+const preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// prefaceTimeout bounds how long a new connection may take to send its
+// first bytes
+const prefaceTimeout = 10 * time.Second
+
+// ListenAndServe serves HTTP and gRPC on the same address, so nodes keep a
+// single address for both
+// gRPC connections go to server and all others to handler.
+func ListenAndServe(addr string, handler http.Handler, server *grpc.Server) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	httpListener := newConnListener(listener.Addr())
+	grpcListener := newConnListener(listener.Addr())
+	defer httpListener.Close()
+	defer grpcListener.Close()
+	go http.Serve(httpListener, handler)
+	go server.Serve(grpcListener)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go route(conn, httpListener, grpcListener)
+	}
+}
+
+// route hands a connection to the gRPC listener if it starts with the
+// HTTP/2 preface, or to the HTTP listener as soon as it does not
+func route(conn net.Conn, httpListener, grpcListener *connListener) {
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(prefaceTimeout))
+	isGRPC := true
+	for n := 1; n <= len(preface); n++ {
+		b, err := r.Peek(n)
+		if err != nil {
+			if len(b) == 0 {
+				conn.Close()
+				return
+			}
+			isGRPC = false
+			break
+		}
+		if b[n-1] != preface[n-1] {
+			isGRPC = false
+			break
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	target := httpListener
+	if isGRPC {
+		target = grpcListener
+	}
+	if err := target.deliver(&peekedConn{Conn: conn, r: r}); err != nil {
+		log.Printf("Dropping connection from %s: %v", conn.RemoteAddr(), err)
+		conn.Close()
+	}
+}
+
+// peekedConn is a connection whose first bytes were read into r
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// connListener is a listener fed with connections by route
+type connListener struct {
+	addr   net.Addr
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+// newConnListener creates a listener reporting addr as its address
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{
+		addr:   addr,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// deliver hands a connection to the server accepting from the listener
+func (l *connListener) deliver(conn net.Conn) error {
+	select {
+	case l.conns <- conn:
+		return nil
+	case <-l.closed:
+		return net.ErrClosed
+	}
+}
+
+// Accept waits for the next connection
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops the listener
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+// Addr returns the address of the underlying listener
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yourusername/distributed-kv-store/internal/fault"
+	"github.com/yourusername/distributed-kv-store/internal/rpc/kvpb"
+)
+
+// Pool keeps one gRPC connection per peer, shared by every request to it
+// Unary calls go through the fault injector like HTTP requests do.
+type Pool struct {
+	faults *fault.Injector
+	mu     sync.Mutex
+	conns  map[string]*grpc.ClientConn
+}
+
+// NewPool creates an empty connection pool
+func NewPool(faults *fault.Injector) *Pool {
+	return &Pool{
+		faults: faults,
+		conns:  make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Replication returns a client of a peer's internal API
+func (p *Pool) Replication(addr string) (kvpb.ReplicationClient, error) {
+	conn, err := p.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+	return kvpb.NewReplicationClient(conn), nil
+}
+
+// conn returns the connection to a peer, creating it on first use
+// Connections are established lazily and re-established by gRPC after
+// failures, so they are never removed.
+func (p *Pool) conn(addr string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(maxMessageSize),
+			grpc.MaxCallSendMsgSize(maxMessageSize),
+		),
+		grpc.WithUnaryInterceptor(p.inject),
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// inject applies the fault injector's rule for the peer to a unary call
+// A duplicated call is also sent in the background and its reply discarded.
+func (p *Pool) inject(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	duplicate, err := p.faults.Outbound(cc.Target())
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		if m, ok := reply.(proto.Message); ok {
+			go invoker(context.WithoutCancel(ctx), method, req, proto.Clone(m), cc, opts...)
+		}
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,150 @@
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative kvpb/kv.proto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/distributed-kv-store/internal/blob"
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+	"github.com/yourusername/distributed-kv-store/internal/rpc/kvpb"
+)
+
+// maxMessageSize is the largest gRPC message sent or received
+// Values are limited by the store (--max-value-size), not by gRPC.
+const maxMessageSize = math.MaxInt32
+
+// NewServer creates a gRPC server with the public KV service of a node's
+// backend and, unless replication is nil, the node's internal service
+func NewServer(backend frontend.Backend, replication kvpb.ReplicationServer) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	)
+	kvpb.RegisterKVServer(server, &kvService{backend: frontend.Public(backend)})
+	if replication != nil {
+		kvpb.RegisterReplicationServer(server, replication)
+	}
+	return server
+}
+
+// kvService serves the public KV API through a frontend backend
+type kvService struct {
+	kvpb.UnimplementedKVServer
+	backend frontend.Backend
+}
+
+// Get reads a key
+func (s *kvService) Get(ctx context.Context, req *kvpb.GetRequest) (*kvpb.GetResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, kvstore.ErrEmptyKey.Error())
+	}
+	kv, err := s.backend.Get(req.Key)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if kv == nil {
+		return nil, status.Error(codes.NotFound, "key not found")
+	}
+	return &kvpb.GetResponse{Value: kv.Value, Version: kv.Version}, nil
+}
+
+// Put writes a key if its preconditions hold
+func (s *kvService) Put(ctx context.Context, req *kvpb.PutRequest) (*kvpb.PutResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, kvstore.ErrEmptyKey.Error())
+	}
+	value := req.Value
+	if value == nil {
+		value = []byte{}
+	}
+	version, err := s.backend.Set(req.Key, value, rest.Preconditions{IfMatch: req.IfMatch, IfNoneMatch: req.IfNoneMatch})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &kvpb.PutResponse{Version: version}, nil
+}
+
+// Delete deletes a key if its preconditions hold
+func (s *kvService) Delete(ctx context.Context, req *kvpb.DeleteRequest) (*kvpb.DeleteResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, kvstore.ErrEmptyKey.Error())
+	}
+	if err := s.backend.Delete(req.Key, rest.Preconditions{IfMatch: req.IfMatch, IfNoneMatch: req.IfNoneMatch}); err != nil {
+		return nil, statusError(err)
+	}
+	return &kvpb.DeleteResponse{}, nil
+}
+
+// Stream runs requests one after the other as they arrive
+// A failed request replies with an error and does not end the stream.
+func (s *kvService) Stream(stream kvpb.KV_StreamServer) error {
+	ctx := stream.Context()
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		resp := &kvpb.StreamResponse{}
+		switch r := req.Request.(type) {
+		case *kvpb.StreamRequest_Get:
+			var get *kvpb.GetResponse
+			if get, err = s.Get(ctx, r.Get); err == nil {
+				resp.Response = &kvpb.StreamResponse_Get{Get: get}
+			}
+		case *kvpb.StreamRequest_Put:
+			var put *kvpb.PutResponse
+			if put, err = s.Put(ctx, r.Put); err == nil {
+				resp.Response = &kvpb.StreamResponse_Put{Put: put}
+			}
+		case *kvpb.StreamRequest_Delete:
+			var del *kvpb.DeleteResponse
+			if del, err = s.Delete(ctx, r.Delete); err == nil {
+				resp.Response = &kvpb.StreamResponse_Delete{Delete: del}
+			}
+		default:
+			err = status.Error(codes.InvalidArgument, "empty request")
+		}
+		if err != nil {
+			st := status.Convert(err)
+			resp.Response = &kvpb.StreamResponse_Error{Error: &kvpb.Error{Code: int32(st.Code()), Message: st.Message()}}
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// statusError converts a backend error into a gRPC status, with the codes
+// the gRPC guidelines give for each (a failed If-Match is a test-and-set
+// the client retries from a new read, so ABORTED)
+func statusError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, rest.ErrPreconditionFailed):
+		code = codes.Aborted
+	case errors.Is(err, frontend.ErrReadOnly), errors.Is(err, frontend.ErrCausal), errors.Is(err, kvstore.ErrCRDTKey):
+		code = codes.FailedPrecondition
+	case errors.Is(err, kvstore.ErrValueTooLarge), errors.Is(err, blob.ErrTooLarge):
+		code = codes.ResourceExhausted
+	case errors.Is(err, kvstore.ErrEmptyKey), errors.Is(err, kvstore.ErrReservedKey):
+		code = codes.InvalidArgument
+	case errors.Is(err, frontend.ErrContention):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
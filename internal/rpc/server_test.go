@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/distributed-kv-store/internal/frontend"
+	"github.com/yourusername/distributed-kv-store/internal/kvstore"
+	"github.com/yourusername/distributed-kv-store/internal/rest"
+	"github.com/yourusername/distributed-kv-store/internal/rpc/kvpb"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: rest.ErrPreconditionFailed, want: codes.Aborted},
+		{err: fmt.Errorf("put: %w", rest.ErrPreconditionFailed), want: codes.Aborted},
+		{err: frontend.ErrReadOnly, want: codes.FailedPrecondition},
+		{err: frontend.ErrCausal, want: codes.FailedPrecondition},
+		{err: kvstore.ErrCRDTKey, want: codes.FailedPrecondition},
+		{err: kvstore.ErrValueTooLarge, want: codes.ResourceExhausted},
+		{err: kvstore.ErrEmptyKey, want: codes.InvalidArgument},
+		{err: frontend.ErrContention, want: codes.Unavailable},
+		{err: errors.New("replication failed"), want: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			st := status.Convert(statusError(tt.err))
+			if st.Code() != tt.want || st.Message() != tt.err.Error() {
+				t.Fatalf("got %s %q, want %s %q", st.Code(), st.Message(), tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestKVService(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	put, err := client.Put(ctx, &kvpb.PutRequest{Key: "k", Value: []byte{0, 1, 2}, IfNoneMatch: "*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := rest.ETag(put.Version)
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{name: "get", call: func() error {
+			get, err := client.Get(ctx, &kvpb.GetRequest{Key: "k"})
+			if err == nil && (string(get.Value) != "\x00\x01\x02" || get.Version != put.Version) {
+				return fmt.Errorf("got %q at version %d", get.Value, get.Version)
+			}
+			return err
+		}},
+		{name: "get a missing key", call: func() error {
+			_, err := client.Get(ctx, &kvpb.GetRequest{Key: "missing"})
+			return err
+		}, want: codes.NotFound},
+		{name: "empty key", call: func() error {
+			_, err := client.Put(ctx, &kvpb.PutRequest{Value: []byte("v")})
+			return err
+		}, want: codes.InvalidArgument},
+		{name: "create an existing key", call: func() error {
+			_, err := client.Put(ctx, &kvpb.PutRequest{Key: "k", Value: []byte("v"), IfNoneMatch: "*"})
+			return err
+		}, want: codes.Aborted},
+		{name: "delete another version", call: func() error {
+			_, err := client.Delete(ctx, &kvpb.DeleteRequest{Key: "k", IfMatch: `"0"`})
+			return err
+		}, want: codes.Aborted},
+		{name: "delete the current version", call: func() error {
+			_, err := client.Delete(ctx, &kvpb.DeleteRequest{Key: "k", IfMatch: etag})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamKeepsGoingAfterErrors(t *testing.T) {
+	client := newTestClient(t)
+	stream, err := client.Stream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []*kvpb.StreamRequest{
+		{Request: &kvpb.StreamRequest_Get{Get: &kvpb.GetRequest{Key: "k"}}},
+		{Request: &kvpb.StreamRequest_Put{Put: &kvpb.PutRequest{Key: "k", Value: []byte("v")}}},
+		{},
+		{Request: &kvpb.StreamRequest_Get{Get: &kvpb.GetRequest{Key: "k"}}},
+	}
+	for _, req := range requests {
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.CloseSend()
+
+	var got []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch r := resp.Response.(type) {
+		case *kvpb.StreamResponse_Error:
+			got = append(got, codes.Code(r.Error.Code).String())
+		case *kvpb.StreamResponse_Get:
+			got = append(got, string(r.Get.Value))
+		case *kvpb.StreamResponse_Put:
+			got = append(got, "put")
+		}
+	}
+	want := fmt.Sprint([]string{codes.NotFound.String(), "put", codes.InvalidArgument.String(), "v"})
+	if fmt.Sprint(got) != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestListenAndServeSharesTheAddress(t *testing.T) {
+	client, addr := newTestServer(t)
+	resp, err := http.Get("http://" + addr + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("got %q over HTTP, want ok", body)
+	}
+	if _, err := client.Put(context.Background(), &kvpb.PutRequest{Key: "k", Value: []byte("v")}); err != nil {
+		t.Fatalf("gRPC on the same address: %v", err)
+	}
+}
+
+// storeBackend is a frontend.Backend over a single store
+type storeBackend struct {
+	store *kvstore.Store
+}
+
+func (b storeBackend) Get(key string) (*kvstore.KeyValue, error) {
+	kv, _ := b.store.LocalRead(key)
+	return kv, nil
+}
+
+func (b storeBackend) Set(key string, value []byte, preconditions rest.Preconditions) (int64, error) {
+	return b.store.SetIf(key, value, preconditions.Condition())
+}
+
+func (b storeBackend) Delete(key string, preconditions rest.Preconditions) error {
+	_, err := b.store.DeleteIf(key, preconditions.Condition())
+	return err
+}
+
+// newTestClient serves the KV service of an empty store and returns a client
+func newTestClient(t *testing.T) kvpb.KVClient {
+	client, _ := newTestServer(t)
+	return client
+}
+
+// newTestServer serves HTTP and gRPC on a free port and returns a gRPC
+// client and the address
+func newTestServer(t *testing.T) (kvpb.KVClient, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	server := NewServer(storeBackend{store: kvstore.NewStore()}, nil)
+	go ListenAndServe(addr, mux, server)
+	t.Cleanup(server.Stop)
+
+	// Wait for the listener
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return kvpb.NewKVClient(conn), addr
+}
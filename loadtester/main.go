@@ -60,6 +60,9 @@ func main() {
 	log.Printf("  Concurrency: %d workers", *concurrency)
 	log.Printf("  Write ratio: %.1f%%, Read ratio: %.1f%%", config.WriteRatio*100, config.ReadRatio*100)
 	log.Printf("  Target: %s", config.TargetAddr)
+	if config.ReadAddr != "" {
+		log.Printf("  Read target: %s", config.ReadAddr)
+	}
 
 	startTime := time.Now()
 	endTime := startTime.Add(*duration)
@@ -136,7 +139,7 @@ func processRequest(
 		}
 	} else {
 		// Read request
-		response, err = httpClient.Read(config.TargetAddr, req.Key)
+		response, err = httpClient.Read(config.GetReadAddr(), req.Key)
 		if err == nil {
 			// Check for stale read
 			if storedVersion, ok := versionTracker.Load(req.Key); ok {
@@ -237,9 +240,18 @@ func exportResults(outputDir string, config *Config, statsCollector *stats.Colle
 type Config struct {
 	Name         string  `json:"name"`
 	TargetAddr   string  `json:"target_addr"`
+	ReadAddr     string  `json:"read_addr,omitempty"` // Optional separate read target (e.g. chain tail)
 	WriteRatio   float64 `json:"write_ratio"`   // 0.0 to 1.0
 	ReadRatio    float64 `json:"read_ratio"`    // 0.0 to 1.0
 	NumKeys      int     `json:"num_keys"`       // Total number of keys
 	KeyClusterSize int   `json:"key_cluster_size"` // Keys per cluster for local-in-time
 }
 
+// GetReadAddr returns the address reads are sent to
+// Falls back to TargetAddr when no separate read target is configured
+func (c *Config) GetReadAddr() string {
+	if c.ReadAddr != "" {
+		return c.ReadAddr
+	}
+	return c.TargetAddr
+}
@@ -69,6 +69,13 @@ run_test "loadtester/configs/ll_10_90.json"
 run_test "loadtester/configs/ll_50_50.json"
 run_test "loadtester/configs/ll_90_10.json"
 
+# Chain Replication
+echo "=== Chain Replication ==="
+run_test "loadtester/configs/chain_01_99.json"
+run_test "loadtester/configs/chain_10_90.json"
+run_test "loadtester/configs/chain_50_50.json"
+run_test "loadtester/configs/chain_90_10.json"
+
 echo "=== All Load Tests Complete ==="
 echo "Results saved to: $OUTPUT_BASE/"
 
@@ -31,6 +31,9 @@ if [ "$LEADER_RUNNING" != "yes" ]; then
     echo "For Leaderless tests:"
     echo "  1. Start 5 nodes on ports 8080-8084"
     echo ""
+    echo "For Chain Replication tests:"
+    echo "  1. Start 5 nodes on ports 8080-8084 (8080 = head, 8084 = tail)"
+    echo ""
     echo "Then run this script again."
     exit 1
 fi
@@ -130,6 +133,13 @@ run_test "loadtester/configs/ll_10_90.json"
 run_test "loadtester/configs/ll_50_50.json"
 run_test "loadtester/configs/ll_90_10.json"
 
+echo ""
+echo "Running Chain Replication tests..."
+run_test "loadtester/configs/chain_01_99.json"
+run_test "loadtester/configs/chain_10_90.json"
+run_test "loadtester/configs/chain_50_50.json"
+run_test "loadtester/configs/chain_90_10.json"
+
 echo ""
 echo "=== Generating Graphs ==="
 echo ""
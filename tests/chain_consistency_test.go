@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestChainConsistency tests consistency for Chain Replication database
+func TestChainConsistency(t *testing.T) {
+	client := NewConsistencyTestClient()
+
+	chainAddrs := []string{
+		"localhost:8080", // head
+		"localhost:8081",
+		"localhost:8082",
+		"localhost:8083",
+		"localhost:8084", // tail
+	}
+	headAddr := chainAddrs[0]
+	tailAddr := chainAddrs[len(chainAddrs)-1]
+
+	// Test 1: Write to head, read from tail (should be consistent)
+	t.Run("WriteToHead_ReadFromTail_Consistent", func(t *testing.T) {
+		key := fmt.Sprintf("test_chain_head_tail_%d", time.Now().UnixNano())
+		value := "value1"
+
+		writeResp, err := client.Write(headAddr, key, value)
+		if err != nil {
+			t.Fatalf("Failed to write to head: %v", err)
+		}
+
+		readResp, err := client.Read(tailAddr, key)
+		if err != nil {
+			t.Fatalf("Failed to read from tail: %v", err)
+		}
+
+		if readResp.Value != value {
+			t.Errorf("Inconsistent read from tail: expected %s, got %s", value, readResp.Value)
+		}
+		if readResp.Version != writeResp.Version {
+			t.Errorf("Version mismatch: expected %d, got %d", writeResp.Version, readResp.Version)
+		}
+	})
+
+	// Test 2: Write to a middle node (forwarded to head), read from any node (forwarded to tail)
+	t.Run("WriteToMiddle_ReadFromAnyNode_Consistent", func(t *testing.T) {
+		key := fmt.Sprintf("test_chain_forward_%d", time.Now().UnixNano())
+		value := "value2"
+
+		writeResp, err := client.Write(chainAddrs[2], key, value)
+		if err != nil {
+			t.Fatalf("Failed to write to middle node: %v", err)
+		}
+
+		for i, nodeAddr := range chainAddrs {
+			readResp, err := client.Read(nodeAddr, key)
+			if err != nil {
+				t.Errorf("Node %d: failed to read: %v", i, err)
+				continue
+			}
+			if readResp.Value != value || readResp.Version != writeResp.Version {
+				t.Errorf("Node %d: inconsistent read (expected %s v%d, got %s v%d)",
+					i, value, writeResp.Version, readResp.Value, readResp.Version)
+			}
+		}
+	})
+
+	// Test 3: After tail acknowledges, every node holds the write locally
+	t.Run("AfterTailAck_LocalReadOnEveryNode_Consistent", func(t *testing.T) {
+		key := fmt.Sprintf("test_chain_local_%d", time.Now().UnixNano())
+		value := "value3"
+
+		writeResp, err := client.Write(headAddr, key, value)
+		if err != nil {
+			t.Fatalf("Failed to write to head: %v", err)
+		}
+
+		// No wait: the ack is only sent once the tail has applied the write
+		for i, nodeAddr := range chainAddrs {
+			readResp, err := client.LocalRead(nodeAddr, key)
+			if err != nil {
+				t.Errorf("Node %d: key not found after ack: %v", i, err)
+				continue
+			}
+			if readResp.Version != writeResp.Version {
+				t.Errorf("Node %d: version mismatch (expected %d, got %d)",
+					i, writeResp.Version, readResp.Version)
+			}
+		}
+	})
+}
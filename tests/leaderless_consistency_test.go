@@ -3,6 +3,7 @@ package tests
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 )
@@ -132,6 +133,43 @@ func TestLeaderlessConsistency(t *testing.T) {
 			t.Errorf("Version mismatch: expected %d, got %d", writeResp.Version, readResp.Version)
 		}
 	})
+
+	// Test 4: Concurrent writes to the same key through different coordinators
+	// (all replicas should converge on the same value and version)
+	t.Run("ConcurrentCoordinators_SameKey_Converge", func(t *testing.T) {
+		key := fmt.Sprintf("test_concurrent_%d", time.Now().UnixNano())
+
+		var wg sync.WaitGroup
+		for i, nodeAddr := range allNodeAddrs {
+			wg.Add(1)
+			go func(i int, nodeAddr string) {
+				defer wg.Done()
+				if _, err := client.Write(nodeAddr, key, fmt.Sprintf("value_from_node_%d", i)); err != nil {
+					t.Logf("Write through node %d failed: %v", i, err)
+				}
+			}(i, nodeAddr)
+		}
+		wg.Wait()
+
+		// Wait for background replication to complete
+		time.Sleep(4 * time.Second)
+
+		var first *ReadResponse
+		for i, nodeAddr := range allNodeAddrs {
+			readResp, err := client.LocalRead(nodeAddr, key)
+			if err != nil {
+				t.Fatalf("Failed to read from node %d: %v", i, err)
+			}
+			if first == nil {
+				first = readResp
+				continue
+			}
+			if readResp.Value != first.Value || readResp.Version != first.Version {
+				t.Errorf("Replicas diverged: node 0 has %s v%d, node %d has %s v%d",
+					first.Value, first.Version, i, readResp.Value, readResp.Version)
+			}
+		}
+	})
 }
 
 // TestLeaderlessConsistencyHighLoad tests consistency under high load
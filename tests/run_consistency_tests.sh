@@ -14,6 +14,7 @@ cleanup() {
     echo "Cleaning up..."
     pkill -f "leader-follower" 2>/dev/null || true
     pkill -f "leaderless" 2>/dev/null || true
+    pkill -f "./chain" 2>/dev/null || true
     sleep 1
 }
 trap cleanup EXIT
@@ -22,6 +23,7 @@ trap cleanup EXIT
 echo "Building binaries..."
 go build -o leader-follower ./cmd/leader-follower
 go build -o leaderless ./cmd/leaderless
+go build -o chain ./cmd/chain
 echo "✓ Build successful"
 echo ""
 
@@ -83,6 +85,28 @@ start_leaderless() {
     echo "✓ Leaderless cluster ready"
 }
 
+# Function to start Chain Replication cluster
+start_chain() {
+    echo "Starting Chain Replication cluster..."
+
+    CHAIN_NODES="localhost:8080,localhost:8081,localhost:8082,localhost:8083,localhost:8084"
+
+    # Start all nodes (8080 = head, 8084 = tail)
+    for i in {1..5}; do
+        port=$((8080 + i - 1))
+        ./chain \
+            --node-id=node$i \
+            --chain-addrs=$CHAIN_NODES \
+            --port=$port > /tmp/chain_node$i.log 2>&1 &
+        sleep 1
+    done
+
+    echo "Waiting for Chain Replication cluster to be ready..."
+    sleep 3
+
+    echo "✓ Chain Replication cluster ready"
+}
+
 # Check which tests to run
 TEST_TYPE=${1:-"all"}
 
@@ -134,6 +158,27 @@ if [ "$TEST_TYPE" = "leaderless" ] || [ "$TEST_TYPE" = "all" ]; then
     sleep 2
 fi
 
+if [ "$TEST_TYPE" = "chain" ] || [ "$TEST_TYPE" = "all" ]; then
+    echo ""
+    echo "=== Running Chain Replication Consistency Tests ==="
+
+    # Clean up any existing processes
+    pkill -f "leader-follower" 2>/dev/null || true
+    pkill -f "leaderless" 2>/dev/null || true
+    pkill -f "./chain" 2>/dev/null || true
+    sleep 1
+
+    start_chain
+
+    echo ""
+    echo "Running tests..."
+    go test -v ./tests -run TestChainConsistency
+
+    # Cleanup
+    pkill -f "./chain" 2>/dev/null || true
+    sleep 2
+fi
+
 echo ""
 echo "=== All Consistency Tests Complete ==="
 